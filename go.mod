@@ -3,6 +3,7 @@ module goflow
 go 1.15
 
 require (
+	github.com/fsnotify/fsnotify v1.4.9
 	github.com/google/go-cmp v0.5.2
 	github.com/gorilla/mux v1.8.0
 	github.com/hashicorp/go-retryablehttp v0.6.8
@@ -10,6 +11,7 @@ require (
 	github.com/mattn/go-sqlite3 v1.14.5
 	github.com/robfig/cron v1.2.0
 	github.com/sirupsen/logrus v1.8.0 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
 	k8s.io/api v0.20.4
 	k8s.io/apimachinery v0.20.4
 	k8s.io/cli-runtime v0.20.4
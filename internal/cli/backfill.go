@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"goflow/internal/rest"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// Backfill parses the backfill subcommand arguments and asks a running goflow server to backfill
+// the given DAG, writing the execution dates of the queued runs to output
+func Backfill(args []string, output io.Writer) error {
+	flags := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	flags.SetOutput(output)
+	dagName := flags.String("dag", "", "Name of the DAG to backfill")
+	start := flags.String("start", "", "First date to backfill, as YYYY-MM-DD or RFC3339")
+	end := flags.String("end", "", "Last date to backfill, as YYYY-MM-DD or RFC3339")
+	host := flags.String("host", "localhost", "Host IP the goflow REST api is served on")
+	port := flags.Int("port", 8080, "Port the goflow REST api is served on")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if *dagName == "" || *start == "" || *end == "" {
+		return fmt.Errorf("-dag, -start and -end must all be given")
+	}
+
+	requestBytes, err := json.Marshal(rest.BackfillRequest{Start: *start, End: *end})
+	if err != nil {
+		return err
+	}
+	resp, err := http.Post(
+		fmt.Sprintf("http://%s:%d/dag/%s/backfill", *host, *port, *dagName),
+		"application/json",
+		bytes.NewReader(requestBytes),
+	)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("backfill of DAG %s failed: %s", *dagName, string(respBytes))
+	}
+
+	executionDates := make([]time.Time, 0)
+	err = json.Unmarshal(respBytes, &executionDates)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(output, "Queued %d runs of DAG %s\n", len(executionDates), *dagName)
+	for _, executionDate := range executionDates {
+		fmt.Fprintln(output, executionDate.Format(time.RFC3339))
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"goflow/internal/rest"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serverFlags returns the host and port flags needed to reach the given test server
+func serverFlags(server *httptest.Server) []string {
+	host, port, err := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		panic(err)
+	}
+	return []string{"-host", host, "-port", port}
+}
+
+func TestBackfill(t *testing.T) {
+	start := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	var received rest.BackfillRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/dag/my-dag/backfill" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+		json.NewEncoder(w).Encode([]time.Time{start, start.AddDate(0, 0, 1)})
+	}))
+	defer server.Close()
+
+	output := &bytes.Buffer{}
+	args := append(
+		serverFlags(server),
+		"-dag", "my-dag", "-start", "2019-01-01", "-end", "2019-01-02",
+	)
+	err := Backfill(args, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received.Start != "2019-01-01" || received.End != "2019-01-02" {
+		t.Errorf("Server received unexpected backfill request %v", received)
+	}
+	expectedOutput := "Queued 2 runs of DAG my-dag\n2019-01-01T00:00:00Z\n2019-01-02T00:00:00Z\n"
+	if output.String() != expectedOutput {
+		t.Errorf("Expected output:\n%s\nbut found:\n%s", expectedOutput, output.String())
+	}
+}
+
+func TestBackfillErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	cases := [][]string{
+		{"-dag", "my-dag", "-start", "2019-01-01"},
+		append(serverFlags(server), "-dag", "my-dag", "-start", "2019-01-01", "-end", "2019-01-02"),
+		{"-unknown-flag"},
+	}
+	for _, args := range cases {
+		err := Backfill(args, &bytes.Buffer{})
+		if err == nil {
+			t.Errorf("Expected an error for arguments %v", args)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"goflow/internal/logs"
+	"io"
+	"os"
+	"strings"
+)
+
+// usage lists the commands of goflow
+const usage = `Usage: goflow <command> [arguments]
+
+Commands:
+  serve                            Start the goflow server, the default when no command is given
+  dags list                        List the DAGs of a running server
+  dags pause|unpause <dag>         Stop or resume the scheduled runs of a DAG
+  dags trigger <dag> [-conf JSON]  Run a DAG now
+  runs list <dag>                  List the runs of a DAG
+  runs logs <dag> <run>            Print the logs of the tasks of a run
+  backfill                         Run a DAG for each time it was scheduled between two dates
+  validate <path>...               Check DAG files without starting a server
+  db migrate|reset                 Create goflow's database tables, or drop and create them
+  config show                      Print the goflow config
+
+Run goflow <command> -h to see the flags of a command.
+`
+
+// commands maps the name of each command to the function that runs it
+var commands = map[string]func(args []string, output io.Writer) error{
+	"serve":    Serve,
+	"dags":     DAGs,
+	"runs":     Runs,
+	"backfill": Backfill,
+	"validate": Validate,
+	"db":       DB,
+	"config":   Config,
+}
+
+// Run runs the goflow command that the arguments, which follow the program name, start with.
+// Arguments that do not start with a command are given to serve, so that goflow can still be
+// started with only its flags.
+func Run(args []string, output io.Writer) error {
+	command, commandArgs := Serve, args
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		if args[0] == "help" {
+			fmt.Fprint(output, usage)
+			return nil
+		}
+		var ok bool
+		command, ok = commands[args[0]]
+		if !ok {
+			return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+		}
+		commandArgs = args[1:]
+		if args[0] != "serve" {
+			// Only the results of the command are written to the output, and only warnings and
+			// errors from goflow's packages are logged, apart from them
+			logs.SetOutput(os.Stderr)
+			logs.SetLevel(logs.WarningLevel)
+		}
+	}
+	err := command(commandArgs, output)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	return err
+}
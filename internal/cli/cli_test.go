@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	output := &bytes.Buffer{}
+	err := Run([]string{"help"}, output)
+	if err != nil || output.String() != usage {
+		t.Errorf("Expected help to write the usage, found %v:\n%s", err, output.String())
+	}
+
+	output.Reset()
+	err = Run([]string{"dags", "list", "-h"}, output)
+	if err != nil || !strings.Contains(output.String(), "-output") {
+		t.Errorf("Expected -h to write the command's flags, found %v:\n%s", err, output.String())
+	}
+
+	err = Run([]string{"undeploy"}, output)
+	if err == nil || !strings.HasPrefix(err.Error(), `unknown command "undeploy"`) {
+		t.Errorf("Expected an error for an unknown command, found %v", err)
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	flags := flag.NewFlagSet("runs logs", flag.ContinueOnError)
+	format := addOutputFlag(flags)
+	positional, err := parseArgs(flags, []string{"etl", "-output", "json", "etl-run"}, "dag", "run")
+	if err != nil || strings.Join(positional, " ") != "etl etl-run" || *format != jsonOutput {
+		t.Errorf("Expected flags between arguments to be parsed, found %v, %v", positional, err)
+	}
+	_, err = parseArgs(flags, []string{"etl"}, "dag", "run")
+	if err == nil || err.Error() != `runs logs takes the arguments <dag> <run>, found ["etl"]` {
+		t.Errorf("Expected an error for a missing argument, found %v", err)
+	}
+}
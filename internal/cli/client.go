@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// server is the address of the REST api of a running goflow server
+type server struct {
+	host *string
+	port *int
+}
+
+// addServerFlags adds the flags that give the address of the goflow server to the flag set
+func addServerFlags(flags *flag.FlagSet) server {
+	return server{
+		host: flags.String("host", "localhost", "Host IP the goflow REST api is served on"),
+		port: flags.Int("port", 8080, "Port the goflow REST api is served on"),
+	}
+}
+
+// request sends a request to the goflow server, with the body given as JSON if it is not nil,
+// and decodes the JSON response into the result if it is not nil. A response with a status other
+// than 2xx is returned as an error holding the server's message.
+func (s server) request(method string, path string, body interface{}, result interface{}) error {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		bodyReader = bytes.NewReader(bodyBytes)
+	}
+	request, err := http.NewRequest(
+		method,
+		fmt.Sprintf("http://%s:%d%s", *s.host, *s.port, path),
+		bodyReader,
+	)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s (%s)", strings.Trim(string(respBytes), "\"\n "), resp.Status)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(respBytes, result)
+}
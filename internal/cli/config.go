@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"goflow/internal/config"
+	"goflow/internal/paths"
+	"io"
+	"os"
+	"reflect"
+)
+
+// loadConfig returns the goflow config at the path, or an error if there is no file there
+func loadConfig(configPath string) (*config.GoFlowConfig, error) {
+	if _, err := os.Stat(configPath); err != nil {
+		return nil, fmt.Errorf("could not read the goflow config: %w", err)
+	}
+	return config.CreateConfig(configPath), nil
+}
+
+// addConfigFlag adds the flag that gives the path of the goflow config to the flag set
+func addConfigFlag(flags *flag.FlagSet) *string {
+	return flags.String("path", paths.GetGoDefaultHomePath(), "The path to the configuration file")
+}
+
+// Config runs the config subcommand, which shows the goflow config that goflow would be started
+// with
+func Config(args []string, output io.Writer) error {
+	if len(args) == 0 || args[0] != "show" {
+		return fmt.Errorf("config needs a command: show")
+	}
+	flags := flag.NewFlagSet("config show", flag.ContinueOnError)
+	flags.SetOutput(output)
+	configPath := addConfigFlag(flags)
+	format := addOutputFlag(flags)
+	_, err := parseArgs(flags, args[1:])
+	if err != nil {
+		return err
+	}
+	if err = checkOutputFormat(*format); err != nil {
+		return err
+	}
+
+	goflowConfig, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	configValue := reflect.ValueOf(*goflowConfig)
+	rows := make([][]string, 0, configValue.NumField())
+	for i := 0; i < configValue.NumField(); i++ {
+		rows = append(rows, []string{
+			configValue.Type().Field(i).Name,
+			cell(fmt.Sprint(configValue.Field(i).Interface())),
+		})
+	}
+	return writeResults(output, *format, goflowConfig, []string{"SETTING", "VALUE"}, rows)
+}
@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"goflow/internal/config"
+	"goflow/internal/testutils"
+	"strings"
+	"testing"
+)
+
+func TestConfigShow(t *testing.T) {
+	output := &bytes.Buffer{}
+	err := Config([]string{"show", "-path", testutils.GetConfigPath(), "-output", "json"}, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shown := config.GoFlowConfig{}
+	err = json.Unmarshal(output.Bytes(), &shown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shown != *config.CreateConfig(testutils.GetConfigPath()) {
+		t.Errorf("Expected the test config to be shown, found\n%s", output.String())
+	}
+
+	output.Reset()
+	err = Config([]string{"show", "-path", testutils.GetConfigPath()}, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := output.String()
+	if !strings.HasPrefix(table, "SETTING ") || !strings.Contains(table, "\nDAGPath ") {
+		t.Errorf("Expected the test config to be shown as a table, found\n%s", output.String())
+	}
+
+	err = Config([]string{"show", "-path", "missing.json"}, output)
+	if err == nil {
+		t.Error("Expected an error for a config file that does not exist")
+	}
+}
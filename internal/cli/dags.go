@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"goflow/internal/rest"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// dagSummary describes a DAG of a goflow server as it is listed
+type dagSummary struct {
+	Name      string
+	Namespace string
+	Schedule  string
+	IsOn      bool
+	Runs      int
+}
+
+// DAGs runs the dags subcommand, which lists, pauses, unpauses or triggers the DAGs of a running
+// goflow server
+func DAGs(args []string, output io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("dags needs a command: list, pause, unpause or trigger")
+	}
+	switch args[0] {
+	case "list":
+		return listDAGs(args[1:], output)
+	case "pause":
+		return setDAGOnOff("pause", args[1:], output)
+	case "unpause":
+		return setDAGOnOff("unpause", args[1:], output)
+	case "trigger":
+		return triggerDAG(args[1:], output)
+	}
+	return fmt.Errorf("unknown dags command %q, use list, pause, unpause or trigger", args[0])
+}
+
+// listDAGs writes the DAGs of the server to output
+func listDAGs(args []string, output io.Writer) error {
+	flags := flag.NewFlagSet("dags list", flag.ContinueOnError)
+	flags.SetOutput(output)
+	goflowServer := addServerFlags(flags)
+	format := addOutputFlag(flags)
+	_, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if err = checkOutputFormat(*format); err != nil {
+		return err
+	}
+
+	dags := make([]struct {
+		Config struct {
+			Name      string
+			Namespace string
+			Schedule  string
+		}
+		IsOn    bool
+		DAGRuns []json.RawMessage
+	}, 0)
+	err = goflowServer.request(http.MethodGet, "/dags", nil, &dags)
+	if err != nil {
+		return fmt.Errorf("could not list DAGs: %w", err)
+	}
+	summaries := make([]dagSummary, 0, len(dags))
+	rows := make([][]string, 0, len(dags))
+	for _, dag := range dags {
+		summary := dagSummary{
+			Name:      dag.Config.Name,
+			Namespace: dag.Config.Namespace,
+			Schedule:  dag.Config.Schedule,
+			IsOn:      dag.IsOn,
+			Runs:      len(dag.DAGRuns),
+		}
+		summaries = append(summaries, summary)
+		rows = append(rows, []string{
+			summary.Name,
+			summary.Namespace,
+			cell(summary.Schedule),
+			strconv.FormatBool(summary.IsOn),
+			strconv.Itoa(summary.Runs),
+		})
+	}
+	header := []string{"NAME", "NAMESPACE", "SCHEDULE", "ON", "RUNS"}
+	return writeResults(output, *format, summaries, header, rows)
+}
+
+// setDAGOnOff pauses or unpauses the named DAG, as the command says, so that its scheduled runs
+// stop or resume
+func setDAGOnOff(command string, args []string, output io.Writer) error {
+	flags := flag.NewFlagSet("dags "+command, flag.ContinueOnError)
+	flags.SetOutput(output)
+	goflowServer := addServerFlags(flags)
+	format := addOutputFlag(flags)
+	positional, err := parseArgs(flags, args, "dag")
+	if err != nil {
+		return err
+	}
+	if err = checkOutputFormat(*format); err != nil {
+		return err
+	}
+
+	dagName := positional[0]
+	var dagIsOn bool
+	path := "/dag/" + url.PathEscape(dagName) + "/" + command
+	err = goflowServer.request(http.MethodPut, path, nil, &dagIsOn)
+	if err != nil {
+		return fmt.Errorf("could not %s DAG %s: %w", command, dagName, err)
+	}
+	if *format == jsonOutput {
+		return writeJSON(output, struct {
+			Name string
+			IsOn bool
+		}{dagName, dagIsOn})
+	}
+	fmt.Fprintf(output, "DAG %s is %sd\n", dagName, command)
+	return nil
+}
+
+// triggerDAG starts a run of the named DAG and writes the run to output
+func triggerDAG(args []string, output io.Writer) error {
+	flags := flag.NewFlagSet("dags trigger", flag.ContinueOnError)
+	flags.SetOutput(output)
+	goflowServer := addServerFlags(flags)
+	format := addOutputFlag(flags)
+	conf := flags.String(
+		"conf",
+		"",
+		"JSON object whose keys are passed to the run's tasks as environment variables",
+	)
+	executionDate := flags.String(
+		"date",
+		"",
+		"Execution date of the run, as YYYY-MM-DD or RFC3339, the current time by default",
+	)
+	positional, err := parseArgs(flags, args, "dag")
+	if err != nil {
+		return err
+	}
+	if err = checkOutputFormat(*format); err != nil {
+		return err
+	}
+
+	triggerRequest := rest.TriggerRequest{ExecutionDate: *executionDate}
+	if *conf != "" {
+		err = json.Unmarshal([]byte(*conf), &triggerRequest.Conf)
+		if err != nil {
+			return fmt.Errorf("-conf must be a JSON object: %w", err)
+		}
+	}
+	dagName := positional[0]
+	run := runSummary{}
+	path := "/dag/" + url.PathEscape(dagName) + "/runs"
+	err = goflowServer.request(http.MethodPost, path, triggerRequest, &run)
+	if err != nil {
+		return fmt.Errorf("could not trigger DAG %s: %w", dagName, err)
+	}
+	return writeResults(output, *format, run, runHeader, [][]string{run.row()})
+}
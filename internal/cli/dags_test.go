@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"goflow/internal/rest"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newTestServer returns a goflow server that answers each request whose method and path are one
+// of the keys of responses with the JSON of the response, and any other request with 404
+func newTestServer(responses map[string]interface{}, received *[]byte) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response, ok := responses[r.Method+" "+r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`"There is no DAG with given name"`))
+			return
+		}
+		if received != nil {
+			*received, _ = ioutil.ReadAll(r.Body)
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+}
+
+func TestListDAGs(t *testing.T) {
+	server := newTestServer(map[string]interface{}{"GET /dags": []interface{}{
+		map[string]interface{}{
+			"Config":  map[string]interface{}{"Name": "etl", "Namespace": "default"},
+			"IsOn":    true,
+			"DAGRuns": []interface{}{map[string]interface{}{}, map[string]interface{}{}},
+		},
+	}}, nil)
+	defer server.Close()
+
+	output := &bytes.Buffer{}
+	err := DAGs(append([]string{"list"}, serverFlags(server)...), output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedOutput := "NAME  NAMESPACE  SCHEDULE  ON    RUNS\netl   default    -         true  2\n"
+	if output.String() != expectedOutput {
+		t.Errorf("Expected output:\n%s\nbut found:\n%s", expectedOutput, output.String())
+	}
+
+	output.Reset()
+	err = DAGs(append([]string{"list", "-output", "json"}, serverFlags(server)...), output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summaries := make([]dagSummary, 0)
+	json.Unmarshal(output.Bytes(), &summaries)
+	expected := dagSummary{Name: "etl", Namespace: "default", IsOn: true, Runs: 2}
+	if len(summaries) != 1 || summaries[0] != expected {
+		t.Errorf("Expected JSON output of %v, found %s", expected, output.String())
+	}
+}
+
+func TestPauseAndUnpauseDAG(t *testing.T) {
+	server := newTestServer(map[string]interface{}{
+		"PUT /dag/etl/pause":   false,
+		"PUT /dag/etl/unpause": true,
+	}, nil)
+	defer server.Close()
+
+	output := &bytes.Buffer{}
+	err := DAGs(append([]string{"pause", "etl"}, serverFlags(server)...), output)
+	if err != nil || output.String() != "DAG etl is paused\n" {
+		t.Errorf("Expected DAG etl to be paused, found %v: %s", err, output.String())
+	}
+	output.Reset()
+	args := append([]string{"unpause", "etl", "-output", "json"}, serverFlags(server)...)
+	err = DAGs(args, output)
+	if err != nil || !strings.Contains(output.String(), `"IsOn": true`) {
+		t.Errorf("Expected DAG etl to be unpaused, found %v: %s", err, output.String())
+	}
+
+	err = DAGs(append([]string{"pause", "missing"}, serverFlags(server)...), output)
+	expectedError := "could not pause DAG missing: There is no DAG with given name (404 Not Found)"
+	if err == nil || err.Error() != expectedError {
+		t.Errorf("Expected error %q, found %v", expectedError, err)
+	}
+}
+
+func TestTriggerDAG(t *testing.T) {
+	var received []byte
+	server := newTestServer(map[string]interface{}{
+		"POST /dag/etl/runs": map[string]interface{}{
+			"Name":          "etl-run",
+			"ExecutionDate": "2019-01-01T00:00:00Z",
+			"Status":        "running",
+			"RunType":       "manual",
+		},
+	}, &received)
+	defer server.Close()
+
+	output := &bytes.Buffer{}
+	args := append([]string{"trigger", "etl"}, serverFlags(server)...)
+	args = append(args, "--conf", `{"TABLE": "users"}`)
+	err := DAGs(args, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := rest.TriggerRequest{}
+	json.Unmarshal(received, &request)
+	if request.Conf["TABLE"] != "users" {
+		t.Errorf("Expected the conf to be sent to the server, found %s", string(received))
+	}
+	if !strings.Contains(output.String(), "etl-run  2019-01-01T00:00:00Z  running") {
+		t.Errorf("Expected the triggered run to be written, found\n%s", output.String())
+	}
+
+	err = DAGs(append([]string{"trigger", "etl", "-conf", "[]"}, serverFlags(server)...), output)
+	if err == nil {
+		t.Error("A conf that is not a JSON object should not be sent")
+	}
+}
+
+func TestDAGsErrors(t *testing.T) {
+	cases := [][]string{
+		{},
+		{"remove", "etl"},
+		{"list", "extra"},
+		{"pause"},
+		{"list", "-output", "yaml"},
+	}
+	for _, args := range cases {
+		err := DAGs(args, &bytes.Buffer{})
+		if err == nil {
+			t.Errorf("Expected an error for arguments %v", args)
+		}
+	}
+}
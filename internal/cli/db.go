@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"goflow/internal/dag/orchestrator"
+	"goflow/internal/database"
+	"io"
+)
+
+// DB runs the db subcommand, which creates goflow's tables in the database of the goflow config,
+// or drops every table and creates them again. It works on the database directly, so it does
+// not need a running goflow server.
+func DB(args []string, output io.Writer) error {
+	if len(args) == 0 || (args[0] != "migrate" && args[0] != "reset") {
+		return fmt.Errorf("db needs a command: migrate or reset")
+	}
+	command := args[0]
+	flags := flag.NewFlagSet("db "+command, flag.ContinueOnError)
+	flags.SetOutput(output)
+	configPath := addConfigFlag(flags)
+	confirmed := flags.Bool("yes", false, "Confirms that reset may delete all of goflow's data")
+	_, err := parseArgs(flags, args[1:])
+	if err != nil {
+		return err
+	}
+	if command == "reset" && !*confirmed {
+		return fmt.Errorf("db reset deletes all of goflow's data, give -yes to confirm")
+	}
+
+	goflowConfig, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	sqlClient := database.NewSQLiteClient(goflowConfig.DatabaseDNS)
+	if command == "reset" {
+		database.PurgeDB(sqlClient)
+	}
+	orchestrator.SetupDatabase(sqlClient)
+	fmt.Fprintf(output, "Database %s is up to date\n", goflowConfig.DatabaseDNS)
+	return nil
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"bytes"
+	"goflow/internal/config"
+	"goflow/internal/database"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// writeTestConfig writes the test goflow config to the folder, with its database in the folder
+func writeTestConfig(folder string) string {
+	goflowConfig := validationDefaults
+	goflowConfig.DatabaseDNS = filepath.Join(folder, "goflow.sqlite3")
+	configPath := filepath.Join(folder, "config.json")
+	goflowConfig.SaveConfig(configPath)
+	return configPath
+}
+
+func TestDBMigrateAndReset(t *testing.T) {
+	folder, err := ioutil.TempDir("", "goflow-db")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(folder)
+	configPath := writeTestConfig(folder)
+	sqlClient := database.NewSQLiteClient(config.CreateConfig(configPath).DatabaseDNS)
+	expectedTables := []string{
+		"dagrun",
+		"dags",
+		"import_errors",
+		"metrics",
+		"taskattempt",
+		"taskinstance",
+	}
+
+	output := &bytes.Buffer{}
+	err = DB([]string{"migrate", "-path", configPath}, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables := sqlClient.Tables()
+	sort.Strings(tables)
+	if strings.Join(tables, ",") != strings.Join(expectedTables, ",") {
+		t.Errorf("Expected tables %v to be created, found %v", expectedTables, tables)
+	}
+
+	err = DB([]string{"reset", "-path", configPath}, output)
+	if err == nil {
+		t.Error("db reset should not delete goflow's data unless it is confirmed")
+	}
+	err = sqlClient.Exec("INSERT INTO import_errors (file_path, error) VALUES ('dag.json', 'bad')")
+	if err != nil {
+		panic(err)
+	}
+	err = DB([]string{"reset", "-path", configPath, "-yes"}, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := sqlClient.Query("SELECT * FROM import_errors")
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+	if rows.Next() {
+		t.Error("db reset should have deleted the rows of every table")
+	}
+}
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Formats that commands write their results in
+const (
+	tableOutput = "table"
+	jsonOutput  = "json"
+)
+
+// addOutputFlag adds the flag that sets the format of a command's results to the flag set
+func addOutputFlag(flags *flag.FlagSet) *string {
+	return flags.String("output", tableOutput, "Format of the results, table or json")
+}
+
+// checkOutputFormat returns an error if the output format is not one that results can be
+// written in, so that a command fails before it changes anything
+func checkOutputFormat(format string) error {
+	if format != tableOutput && format != jsonOutput {
+		return fmt.Errorf("-output must be %s or %s, not %q", tableOutput, jsonOutput, format)
+	}
+	return nil
+}
+
+// writeJSON writes the value to output as indented JSON
+func writeJSON(output io.Writer, value interface{}) error {
+	valueBytes, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(output, string(valueBytes))
+	return err
+}
+
+// writeTable writes the rows to output in aligned columns under the header
+func writeTable(output io.Writer, header []string, rows [][]string) error {
+	writer := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+	return writer.Flush()
+}
+
+// writeResults writes the value as JSON, or the rows as a table, as the format asks
+func writeResults(
+	output io.Writer,
+	format string,
+	value interface{},
+	header []string,
+	rows [][]string,
+) error {
+	if format == jsonOutput {
+		return writeJSON(output, value)
+	}
+	return writeTable(output, header, rows)
+}
+
+// cell returns the text of a table cell, which is a dash when the text is empty so that the
+// columns of the table stay readable
+func cell(text string) string {
+	if text == "" {
+		return "-"
+	}
+	return text
+}
+
+// timeCell returns the text of a table cell holding a time, which is a dash if the time is unset
+func timeCell(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
+
+// parseArgs parses the flags, which may come before, between or after the positional
+// arguments, and returns the positional arguments. An error is returned unless there is exactly
+// one positional argument for each of the names.
+func parseArgs(flags *flag.FlagSet, args []string, names ...string) ([]string, error) {
+	positional := make([]string, 0, len(names))
+	for {
+		err := flags.Parse(args)
+		if err != nil {
+			return nil, err
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+	if len(positional) != len(names) && len(names) == 0 {
+		return nil, fmt.Errorf("%s takes no arguments, found %q", flags.Name(), positional)
+	}
+	if len(positional) != len(names) {
+		usage := make([]string, 0, len(names))
+		for _, name := range names {
+			usage = append(usage, "<"+name+">")
+		}
+		return nil, fmt.Errorf(
+			"%s takes the arguments %s, found %q",
+			flags.Name(),
+			strings.Join(usage, " "),
+			positional,
+		)
+	}
+	return positional, nil
+}
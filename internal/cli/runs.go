@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"goflow/internal/rest"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// runSummary describes a run of a DAG as it is listed
+type runSummary struct {
+	Name          string
+	ExecutionDate time.Time
+	Status        string
+	RunType       string
+	StartTime     time.Time
+	EndTime       time.Time
+}
+
+// runHeader is the header of the table that runs are listed in
+var runHeader = []string{"NAME", "EXECUTION DATE", "STATUS", "TYPE", "STARTED", "ENDED"}
+
+// row returns the cells of the run's row in a table of runs
+func (run runSummary) row() []string {
+	return []string{
+		run.Name,
+		timeCell(run.ExecutionDate),
+		cell(run.Status),
+		cell(run.RunType),
+		timeCell(run.StartTime),
+		timeCell(run.EndTime),
+	}
+}
+
+// Runs runs the runs subcommand, which lists the runs of a DAG of a running goflow server or
+// writes the logs of one of them
+func Runs(args []string, output io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("runs needs a command: list or logs")
+	}
+	switch args[0] {
+	case "list":
+		return listRuns(args[1:], output)
+	case "logs":
+		return runLogs(args[1:], output)
+	}
+	return fmt.Errorf("unknown runs command %q, use list or logs", args[0])
+}
+
+// listRuns writes the runs of the named DAG to output
+func listRuns(args []string, output io.Writer) error {
+	flags := flag.NewFlagSet("runs list", flag.ContinueOnError)
+	flags.SetOutput(output)
+	goflowServer := addServerFlags(flags)
+	format := addOutputFlag(flags)
+	positional, err := parseArgs(flags, args, "dag")
+	if err != nil {
+		return err
+	}
+	if err = checkOutputFormat(*format); err != nil {
+		return err
+	}
+
+	dagName := positional[0]
+	runs := make([]runSummary, 0)
+	err = goflowServer.request(http.MethodGet, "/dag/"+url.PathEscape(dagName)+"/runs", nil, &runs)
+	if err != nil {
+		return fmt.Errorf("could not list the runs of DAG %s: %w", dagName, err)
+	}
+	rows := make([][]string, 0, len(runs))
+	for _, run := range runs {
+		rows = append(rows, run.row())
+	}
+	return writeResults(output, *format, runs, runHeader, rows)
+}
+
+// runLogs writes the logs of the latest attempt of each task of a DAG run to output
+func runLogs(args []string, output io.Writer) error {
+	flags := flag.NewFlagSet("runs logs", flag.ContinueOnError)
+	flags.SetOutput(output)
+	goflowServer := addServerFlags(flags)
+	format := addOutputFlag(flags)
+	positional, err := parseArgs(flags, args, "dag", "run")
+	if err != nil {
+		return err
+	}
+	if err = checkOutputFormat(*format); err != nil {
+		return err
+	}
+
+	dagName, runName := positional[0], positional[1]
+	taskLogs := make([]rest.TaskLogs, 0)
+	err = goflowServer.request(
+		http.MethodGet,
+		fmt.Sprintf("/dag/%s/runs/%s/logs", url.PathEscape(dagName), url.PathEscape(runName)),
+		nil,
+		&taskLogs,
+	)
+	if err != nil {
+		return fmt.Errorf("could not get the logs of run %s of DAG %s: %w", runName, dagName, err)
+	}
+	if *format == jsonOutput {
+		return writeJSON(output, taskLogs)
+	}
+	for _, task := range taskLogs {
+		fmt.Fprintf(output, "==> %s (attempt %d, %s) <==\n", task.Task, task.Attempt, task.State)
+		if task.Logs == "" {
+			fmt.Fprintln(output, "No logs")
+			continue
+		}
+		fmt.Fprint(output, task.Logs)
+		if !strings.HasSuffix(task.Logs, "\n") {
+			fmt.Fprintln(output)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"bytes"
+	"goflow/internal/rest"
+	"testing"
+)
+
+func TestListRuns(t *testing.T) {
+	server := newTestServer(map[string]interface{}{"GET /dag/etl/runs": []interface{}{
+		map[string]interface{}{
+			"Name":          "etl-run",
+			"ExecutionDate": "2019-01-01T00:00:00Z",
+			"StartTime":     "2019-01-01T00:00:01Z",
+			"EndTime":       nil,
+			"Status":        "running",
+			"RunType":       "scheduled",
+		},
+	}}, nil)
+	defer server.Close()
+
+	output := &bytes.Buffer{}
+	err := Runs(append([]string{"list", "etl"}, serverFlags(server)...), output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedOutput := "" +
+		"NAME     EXECUTION DATE        STATUS   TYPE       STARTED               ENDED\n" +
+		"etl-run  2019-01-01T00:00:00Z  running  scheduled  2019-01-01T00:00:01Z  -\n"
+	if output.String() != expectedOutput {
+		t.Errorf("Expected output:\n%s\nbut found:\n%s", expectedOutput, output.String())
+	}
+}
+
+func TestRunLogs(t *testing.T) {
+	taskLogs := []rest.TaskLogs{
+		{Task: "extract", Attempt: 1, State: "success", Logs: "extracted\n"},
+		{Task: "load", Attempt: 2, State: "failed", Logs: ""},
+	}
+	server := newTestServer(map[string]interface{}{"GET /dag/etl/runs/etl-run/logs": taskLogs}, nil)
+	defer server.Close()
+
+	output := &bytes.Buffer{}
+	err := Runs(append([]string{"logs", "etl", "etl-run"}, serverFlags(server)...), output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedOutput := "==> extract (attempt 1, success) <==\nextracted\n" +
+		"==> load (attempt 2, failed) <==\nNo logs\n"
+	if output.String() != expectedOutput {
+		t.Errorf("Expected output:\n%s\nbut found:\n%s", expectedOutput, output.String())
+	}
+
+	err = Runs(append([]string{"logs", "etl", "other-run"}, serverFlags(server)...), output)
+	if err == nil {
+		t.Error("Expected an error for a run that the server does not have")
+	}
+	err = Runs(append([]string{"logs", "etl"}, serverFlags(server)...), output)
+	if err == nil {
+		t.Error("Expected an error when the run is not given")
+	}
+}
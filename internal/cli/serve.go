@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"flag"
+	"goflow/internal/config"
+	"goflow/internal/dag/metrics"
+	"goflow/internal/dag/orchestrator"
+	k8sclient "goflow/internal/k8s/client"
+	"goflow/internal/logs"
+	"goflow/internal/rest"
+	"goflow/internal/termination"
+	"goflow/internal/testutils"
+	"io"
+	"time"
+
+	core "k8s.io/api/core/v1"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// newFakeKubeClient returns a mocked kubernetes client with a default namespace
+func newFakeKubeClient() *fake.Clientset {
+	kubeClient := fake.NewSimpleClientset()
+	kubeClient.Tracker().Add(&core.Namespace{
+		ObjectMeta: v1.ObjectMeta{
+			Name: "default",
+		},
+	})
+	return kubeClient
+}
+
+// Serve runs the serve subcommand, which starts the orchestrator and the REST api and returns
+// once the orchestrator has been stopped
+func Serve(args []string, output io.Writer) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.SetOutput(output)
+	configPath := addConfigFlag(flags)
+	host := flags.String("host", "localhost", "Host IP to serve REST api on")
+	port := flags.Int("port", 8080, "Port to serve REST API on")
+	verbosePtr := flags.Bool("V", false, "Logs at the debug level, whatever the config sets")
+	testMode := flags.Bool("T", false, "Uses test mode which leverage a mocked kubernetes client")
+	_, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	goflowConfig := config.CreateConfig(*configPath)
+	err = logs.Configure(goflowConfig.LogOptions())
+	if err != nil {
+		return err
+	}
+	if *verbosePtr {
+		logs.SetLevel(logs.DebugLevel)
+	}
+
+	var orch *orchestrator.Orchestrator
+	if *testMode {
+		kubeClient := newFakeKubeClient()
+		testutils.RegisterContainerStatusesToPods(kubeClient)
+		goflowConfig.DAGsOn = true
+		orch = orchestrator.NewOrchestratorFromClientsAndConfig(
+			kubeClient,
+			goflowConfig,
+			metrics.NewDAGMetricsClient(kubeClient, true),
+		)
+	} else if goflowConfig.UsesLocalExecutor() {
+		// Tasks run on this machine, so no kubernetes cluster is needed
+		kubeClient := newFakeKubeClient()
+		orch = orchestrator.NewOrchestratorFromClientsAndConfig(
+			kubeClient,
+			goflowConfig,
+			metrics.NewDAGMetricsClient(kubeClient, true),
+		)
+	} else {
+		// Pods are left running on shutdown so that their runs can be resumed on restart
+		kubeClient := k8sclient.CreateKubeClient()
+		orch = orchestrator.NewOrchestratorFromClientsAndConfig(
+			kubeClient,
+			goflowConfig,
+			metrics.NewDAGMetricsClient(kubeClient, false),
+		)
+	}
+	logs.With(logs.Fields{"config": goflowConfig}).Infof("Starting GoFlow")
+	orch.Start(1 * time.Second)
+	go termination.Handle(func() {
+		orch.Stop()
+	})
+	go rest.Serve(*host, *port, orch)
+	orch.Wait()
+	return nil
+}
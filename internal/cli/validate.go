@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"goflow/internal/config"
+	dagconfig "goflow/internal/dag/config"
+	"goflow/internal/dag/dagtype"
+	"goflow/internal/paths"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	core "k8s.io/api/core/v1"
+)
+
+// validationDefaults are the goflow config defaults that DAG files are checked with when there is
+// no goflow config file
+var validationDefaults = config.GoFlowConfig{
+	DefaultNamespace:     "default",
+	DefaultDockerImage:   "busybox",
+	DefaultRestartPolicy: core.RestartPolicyNever,
+	MaxActiveRuns:        1,
+}
+
+// validationConfig returns the goflow config whose defaults DAG files are checked with, which is
+// read from the given path, or else from the default path if there is a config file there
+func validationConfig(configPath string) *config.GoFlowConfig {
+	if configPath == "" {
+		configPath = paths.GetGoDefaultHomePath()
+		if _, err := os.Stat(configPath); err != nil {
+			goflowConfig := validationDefaults
+			return &goflowConfig
+		}
+	}
+	return config.CreateConfig(configPath)
+}
+
+// dagFilesAt returns the DAG files at each of the paths, which may be DAG files or folders of
+// them, along with an error for each path that could not be read
+func dagFilesAt(dagPaths []string) ([]string, dagtype.ImportErrors) {
+	files := make([]string, 0)
+	walkErrors := make(dagtype.ImportErrors)
+	for _, dagPath := range dagPaths {
+		info, err := os.Stat(dagPath)
+		switch {
+		case err != nil:
+			walkErrors[dagPath] = err
+		case info.IsDir():
+			folderFiles, folderErrors := dagtype.DAGFiles(dagPath)
+			files = append(files, folderFiles...)
+			for path, err := range folderErrors {
+				walkErrors[path] = err
+			}
+		case !dagtype.IsDAGFile(dagPath):
+			walkErrors[dagPath] = fmt.Errorf("%s is not a DAG file that can be loaded", dagPath)
+		default:
+			files = append(files, dagPath)
+		}
+	}
+	return files, walkErrors
+}
+
+// Validate parses the validate subcommand arguments and checks the DAG files at the given paths,
+// which may be DAG files or folders of them, without loading them into goflow. Whether each file
+// is valid is written to output, and an error is returned if any of them is not, so that CI can
+// check DAG files before they are merged.
+func Validate(args []string, output io.Writer) error {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	flags.SetOutput(output)
+	configPath := flags.String(
+		"path",
+		"",
+		"The path to the configuration file whose defaults DAGs are checked with",
+	)
+	printSchema := flags.Bool("schema", false, "Print the JSON Schema of DAG files and exit")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if *printSchema {
+		_, err = output.Write(dagconfig.JSONSchema())
+		return err
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf("a DAG file or folder to validate must be given")
+	}
+	goflowConfig := validationConfig(*configPath)
+
+	files, invalid := dagFilesAt(flags.Args())
+	total := len(files) + len(invalid)
+	for _, file := range files {
+		configs, err := dagtype.ValidateDAGFile(file, *goflowConfig)
+		if err != nil {
+			invalid[file] = err
+			continue
+		}
+		names := make([]string, 0, len(configs))
+		for _, dagConfig := range configs {
+			names = append(names, dagConfig.Name)
+		}
+		fmt.Fprintf(output, "ok      %s: %s\n", file, strings.Join(names, ", "))
+	}
+	invalidPaths := make([]string, 0, len(invalid))
+	for path := range invalid {
+		invalidPaths = append(invalidPaths, path)
+	}
+	sort.Strings(invalidPaths)
+	for _, path := range invalidPaths {
+		message := strings.ReplaceAll(invalid[path].Error(), "\n", "\n        ")
+		fmt.Fprintf(output, "invalid %s: %s\n", path, message)
+	}
+	if len(invalid) != 0 {
+		return fmt.Errorf("%d of %d DAG files are not valid", len(invalid), total)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"bytes"
+	"goflow/internal/testutils"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	output := &bytes.Buffer{}
+	dagFolder := filepath.Join(testutils.GetTestFolder(), "test_dags")
+	err := Validate([]string{"-path", testutils.GetConfigPath(), dagFolder}, output)
+	if err != nil {
+		t.Fatalf("Expected the test DAG files to be valid, found %s\n%s", err, output.String())
+	}
+	for _, name := range []string{"my_json_dag.json", "my_yaml_dag.yaml", "my_python_dag.py"} {
+		if !strings.Contains(output.String(), "ok      "+filepath.Join(dagFolder, name)) {
+			t.Errorf("Expected %s to be reported as valid, found\n%s", name, output.String())
+		}
+	}
+}
+
+func TestValidateInvalidFiles(t *testing.T) {
+	folder, err := ioutil.TempDir("", "goflow-dags")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(folder)
+	files := map[string]string{
+		"good_dag.json":  `{"Name": "good", "StartDateTime": "2019-01-01"}`,
+		"typo_dag.json":  `{"Name": "typo", "Shedule": "@daily", "StartDateTime": "2019-01-01"}`,
+		"dates_dag.yaml": "Name: dates\nStartDateTime: 2019-01-02\nEndDateTime: 2019-01-01\n",
+	}
+	for name, contents := range files {
+		err := ioutil.WriteFile(filepath.Join(folder, name), []byte(contents), 0644)
+		if err != nil {
+			panic(err)
+		}
+	}
+	output := &bytes.Buffer{}
+	err = Validate([]string{"-path", testutils.GetConfigPath(), folder}, output)
+	if err == nil || err.Error() != "2 of 3 DAG files are not valid" {
+		t.Errorf("Expected 2 of the 3 DAG files to be invalid, found %v", err)
+	}
+	expectedLines := []string{
+		"ok      " + filepath.Join(folder, "good_dag.json") + ": good",
+		"invalid " + filepath.Join(folder, "typo_dag.json") + ": json: unknown field \"Shedule\"",
+		"invalid " + filepath.Join(folder, "dates_dag.yaml") + ": yaml: line 1: DAG dates must",
+	}
+	for _, line := range expectedLines {
+		if !strings.Contains(output.String(), line) {
+			t.Errorf("Expected output to contain %s, found\n%s", line, output.String())
+		}
+	}
+}
+
+func TestValidatePrintsSchema(t *testing.T) {
+	output := &bytes.Buffer{}
+	err := Validate([]string{"-schema"}, output)
+	if err != nil || !strings.Contains(output.String(), "\"title\": \"GoFlow DAG\"") {
+		t.Errorf("Expected the JSON Schema of DAG files to be printed, found %v\n%s", err, output)
+	}
+	if err := Validate(nil, output); err == nil {
+		t.Error("Expected an error when no DAG file or folder is given")
+	}
+}
@@ -2,13 +2,22 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"goflow/internal/jsonpanic"
 	"goflow/internal/logs"
 	"io/ioutil"
+	"time"
 
 	core "k8s.io/api/core/v1"
 )
 
+const (
+	// KubernetesExecutor runs tasks as pods in the kubernetes cluster, it is used by default
+	KubernetesExecutor = "kubernetes"
+	// LocalExecutor runs tasks as processes on the machine that goflow runs on
+	LocalExecutor = "local"
+)
+
 // GoFlowConfig is a configuration struct for the GoFlow application settings
 type GoFlowConfig struct {
 	DefaultNamespace     string
@@ -17,13 +26,39 @@ type GoFlowConfig struct {
 	Parallelism          int32
 	TimeLimit            int64
 	Retries              int32
+	RetryDelay           int64
+	RetryBackoff         string
 	MaxActiveRuns        int
 	DAGPath              string
 	DateFormat           string
 	DatabaseDNS          string
 	DAGsOn               bool
+	Catchup              bool
+	Executor             string
+	// Runs tasks in local containers of their images with the local executor, such as "docker"
+	LocalContainerRuntime string
+	// Terminates the runs of a DAG whose file is removed, instead of letting them finish
+	TerminateRemovedDAGRuns bool
+	// Seconds between full reads of the DAG folder, which catch any change that was not noticed
+	DAGResyncInterval int64
+	// Folder of goflow's Go module, whose pkg/dag Go DAG files are built against
+	GoDAGModulePath string
+	// Python interpreter that reads Airflow DAG files, "python3" by default
+	PythonCommand string
+	// Lowest level of the entries that are logged: debug, info, warning or error, info by default
+	LogLevel string
+	// File that logs are written to instead of standard output
+	LogFile string
+	// Megabytes that the log file may reach before it is rotated, 100 by default
+	LogFileMaxSize int
+	// Number of rotated log files that are kept, 5 by default
+	LogFileMaxBackups int
 }
 
+// defaultDAGResyncInterval is the number of seconds between full reads of the DAG folder when the
+// goflow config does not set it
+const defaultDAGResyncInterval = 300
+
 func readConfig(filePath string) []byte {
 	dat, err := ioutil.ReadFile(filePath)
 	if err != nil {
@@ -39,6 +74,37 @@ func verifyConfig(config GoFlowConfig) {
 	if config.DatabaseDNS == "" {
 		panic("Database DNS must be specified!")
 	}
+	switch config.Executor {
+	case "", KubernetesExecutor, LocalExecutor:
+	default:
+		panic(fmt.Sprintf("Executor must be \"%s\" or \"%s\"!", KubernetesExecutor, LocalExecutor))
+	}
+	if _, err := logs.ParseLevel(config.LogLevel); err != nil {
+		panic(err)
+	}
+}
+
+// UsesLocalExecutor returns true if tasks are run on the machine that goflow runs on
+func (config GoFlowConfig) UsesLocalExecutor() bool {
+	return config.Executor == LocalExecutor
+}
+
+// LogOptions returns how goflow logs, as the config sets it
+func (config GoFlowConfig) LogOptions() logs.Options {
+	return logs.Options{
+		Level:      config.LogLevel,
+		File:       config.LogFile,
+		MaxSize:    config.LogFileMaxSize,
+		MaxBackups: config.LogFileMaxBackups,
+	}
+}
+
+// DAGResyncDuration returns the time between full reads of the DAG folder
+func (config GoFlowConfig) DAGResyncDuration() time.Duration {
+	if config.DAGResyncInterval <= 0 {
+		return defaultDAGResyncInterval * time.Second
+	}
+	return time.Duration(config.DAGResyncInterval) * time.Second
 }
 
 // CreateConfig creates a configuration object based on the file at the given path
@@ -49,8 +115,6 @@ func CreateConfig(filePath string) *GoFlowConfig {
 	if err != nil {
 		panic(err)
 	}
-	logs.InfoLogger.Println("Starting GoFlow with the following configs:")
-	logs.InfoLogger.Println(jsonpanic.JSONPanicFormat(configStruct))
 	verifyConfig(*configStruct)
 	return configStruct
 }
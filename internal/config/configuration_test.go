@@ -4,6 +4,7 @@ import (
 	"goflow/internal/jsonpanic"
 	"goflow/internal/testutils"
 	"testing"
+	"time"
 
 	core "k8s.io/api/core/v1"
 )
@@ -36,3 +37,27 @@ func TestReadConfig(t *testing.T) {
 		t.Errorf("Expected: %s", jsonpanic.JSONPanicFormat(expectedConfig))
 	}
 }
+
+func TestDAGResyncDuration(t *testing.T) {
+	if duration := (GoFlowConfig{}).DAGResyncDuration(); duration != 5*time.Minute {
+		t.Errorf("Expected a default resync interval of 5m, found %s", duration)
+	}
+	configured := GoFlowConfig{DAGResyncInterval: 30}
+	if duration := configured.DAGResyncDuration(); duration != 30*time.Second {
+		t.Errorf("Expected a resync interval of 30s, found %s", duration)
+	}
+}
+
+func TestVerifyLogLevel(t *testing.T) {
+	config := *CreateConfig(configPath)
+	config.LogLevel = "warning"
+	verifyConfig(config)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a log level that does not exist to be rejected")
+		}
+	}()
+	config.LogLevel = "verbose"
+	verifyConfig(config)
+}
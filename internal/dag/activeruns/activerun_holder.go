@@ -34,3 +34,15 @@ func (runs *ActiveRuns) Dec() {
 	runs.count--
 	runs.lock.Unlock()
 }
+
+// IncIfBelow increments the number of runs only if it is below the given maximum, returns true if
+// the number of runs was incremented
+func (runs *ActiveRuns) IncIfBelow(max int) bool {
+	runs.lock.Lock()
+	defer runs.lock.Unlock()
+	if runs.count >= max {
+		return false
+	}
+	runs.count++
+	return true
+}
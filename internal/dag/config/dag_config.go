@@ -1,13 +1,17 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"goflow/internal/config"
 	"goflow/internal/jsonpanic"
 	"io/ioutil"
 	"regexp"
+	"strings"
 
 	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 const validNameRegexString = "^[[:alpha:]][a-zA-Z0-9_-]+$"
@@ -25,21 +29,40 @@ func init() {
 // DAGConfig is a struct storing the configurable values provided from the user in the DAG
 // definition file
 type DAGConfig struct {
-	Name          string
-	Namespace     string
-	Schedule      string
-	DockerImage   string
-	RetryPolicy   core.RestartPolicy
-	Command       []string
-	Parallelism   int32
-	TimeLimit     *int64
-	Retries       int32
-	MaxActiveRuns int
-	StartDateTime string
-	EndDateTime   string
-	Labels        map[string]string
-	Annotations   map[string]string
-	WithLogs      bool
+	Name        string
+	Namespace   string
+	Schedule    string
+	Timezone    string // IANA name of the zone for Schedule, StartDateTime and EndDateTime
+	DockerImage string
+	RetryPolicy core.RestartPolicy
+	Command     []string
+	Args        []string
+	Env         map[string]string
+	// Kubernetes Secrets and ConfigMaps whose keys are all passed to tasks as environment variables
+	EnvFromSecrets    []string
+	EnvFromConfigMaps []string
+	SecretEnv         map[string]SecretKeyRef // Environment variables read from a Secret's key
+	Tasks             []TaskConfig
+	ExecutionMode     string // "pod" (the default) or "job"
+	Parallelism       int32
+	Completions       *int32
+	BackoffLimit      *int32
+	TimeLimit         *int64
+	// Seconds after which a finished Job is deleted by kubernetes, only used in "job" mode
+	TTLSecondsAfterFinished *int32
+	Retries                 int32
+	RetryDelay              int64
+	RetryBackoff            string
+	MaxActiveRuns           int
+	Catchup                 *bool
+	TriggeredBy             []TriggerConfig // Upstream DAGs and datasets that trigger runs
+	Produces                []string        // Datasets updated by each successful run of the DAG
+	StartDateTime           string
+	EndDateTime             string
+	Labels                  map[string]string
+	Annotations             map[string]string
+	PodTemplate             *core.PodSpec // Merged over the pod that goflow generates for each task
+	WithLogs                bool
 }
 
 // Marshal returns a json bytes representation of DAGConfig
@@ -51,6 +74,31 @@ func (config DAGConfig) Marshal() []byte {
 	return jsonBytes
 }
 
+// Unmarshal reads a DAG config from JSON. Unlike json.Unmarshal, a key that is not a field of the
+// config, such as a misspelled key, is an error rather than being ignored.
+func Unmarshal(data []byte, config *DAGConfig) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(config)
+	if err != nil {
+		return err
+	}
+	if decoder.More() {
+		return fmt.Errorf("json: a DAG file must hold a single DAG config")
+	}
+	return nil
+}
+
+// UnknownField returns the key named by an error of Unmarshal for a key that is not a field of the
+// config, and false for any other error
+func UnknownField(err error) (string, bool) {
+	const prefix = "json: unknown field \""
+	if err == nil || !strings.HasPrefix(err.Error(), prefix) {
+		return "", false
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(err.Error(), prefix), "\""), true
+}
+
 // JSON returns a json string representation of DAGConfig
 func (config DAGConfig) JSON() string {
 	return string(config.Marshal())
@@ -72,8 +120,30 @@ func (config DAGConfig) Copy() DAGConfig {
 	configCopy := config
 	configCopy.Command = make([]string, len(config.Command))
 	copy(configCopy.Command, config.Command)
+	configCopy.Args = makeStrSliceCopy(config.Args)
+	configCopy.Env = makeStrMapCopy(config.Env)
+	configCopy.EnvFromSecrets = makeStrSliceCopy(config.EnvFromSecrets)
+	configCopy.EnvFromConfigMaps = makeStrSliceCopy(config.EnvFromConfigMaps)
+	if config.SecretEnv != nil {
+		configCopy.SecretEnv = make(map[string]SecretKeyRef, len(config.SecretEnv))
+		for name, ref := range config.SecretEnv {
+			configCopy.SecretEnv[name] = ref
+		}
+	}
+	if config.Tasks != nil {
+		configCopy.Tasks = make([]TaskConfig, 0, len(config.Tasks))
+		for _, task := range config.Tasks {
+			configCopy.Tasks = append(configCopy.Tasks, task.Copy())
+		}
+	}
+	if config.TriggeredBy != nil {
+		configCopy.TriggeredBy = make([]TriggerConfig, len(config.TriggeredBy))
+		copy(configCopy.TriggeredBy, config.TriggeredBy)
+	}
+	configCopy.Produces = makeStrSliceCopy(config.Produces)
 	configCopy.Annotations = makeStrMapCopy(config.Annotations)
 	configCopy.Labels = makeStrMapCopy(config.Labels)
+	configCopy.PodTemplate = config.PodTemplate.DeepCopy()
 	return configCopy
 }
 
@@ -94,9 +164,25 @@ func (config *DAGConfig) SetDefaults(goflowConfig config.GoFlowConfig) {
 	if config.Retries == 0 {
 		config.Retries = goflowConfig.Retries
 	}
+	if config.RetryDelay == 0 {
+		config.RetryDelay = goflowConfig.RetryDelay
+	}
+	if config.RetryBackoff == "" {
+		config.RetryBackoff = goflowConfig.RetryBackoff
+	}
 	if config.MaxActiveRuns == 0 {
 		config.MaxActiveRuns = goflowConfig.MaxActiveRuns
 	}
+	if config.Catchup == nil {
+		catchup := goflowConfig.Catchup
+		config.Catchup = &catchup
+	}
+}
+
+// CatchupEnabled returns true if the DAG should run every interval missed since its last run,
+// rather than only the latest one
+func (config *DAGConfig) CatchupEnabled() bool {
+	return config.Catchup != nil && *config.Catchup
 }
 
 // IsNameValid returns false if name does not match required DAG naming pattern
@@ -104,6 +190,48 @@ func (config *DAGConfig) IsNameValid() bool {
 	return validNameRegex.Match([]byte(config.Name))
 }
 
+// ValidateName returns an error if the DAG's name does not match the required naming pattern
+func (config *DAGConfig) ValidateName() error {
+	if !config.IsNameValid() {
+		return fmt.Errorf(
+			"DAG name \"%s\" must match the pattern \"%s\"",
+			config.Name,
+			config.Pattern(),
+		)
+	}
+	return nil
+}
+
+// ValidateNamespace returns an error if the DAG's Namespace is not a valid kubernetes namespace
+// name
+func (config *DAGConfig) ValidateNamespace() error {
+	if config.Namespace == "" {
+		return nil
+	}
+	problems := validation.IsDNS1123Label(config.Namespace)
+	if len(problems) != 0 {
+		return fmt.Errorf(
+			"DAG %s has an invalid Namespace \"%s\": %s",
+			config.Name,
+			config.Namespace,
+			strings.Join(problems, ", "),
+		)
+	}
+	return nil
+}
+
+// ValidateMaxActiveRuns returns an error if the DAG could never have a run in progress
+func (config *DAGConfig) ValidateMaxActiveRuns() error {
+	if config.MaxActiveRuns < 1 {
+		return fmt.Errorf(
+			"DAG %s must have a MaxActiveRuns greater than 0, found %d",
+			config.Name,
+			config.MaxActiveRuns,
+		)
+	}
+	return nil
+}
+
 // WriteToFile writes a dag file to the given folder
 func (config *DAGConfig) WriteToFile(path string) error {
 	return ioutil.WriteFile(path, jsonpanic.JSONPanicFormatBytes(config), 0600)
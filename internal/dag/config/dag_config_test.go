@@ -3,6 +3,7 @@ package config
 import (
 	"goflow/internal/config"
 	"goflow/internal/jsonpanic"
+	"strings"
 	"testing"
 
 	core "k8s.io/api/core/v1"
@@ -19,7 +20,9 @@ func TestSetConfigDefaults(t *testing.T) {
 		TimeLimit:            1,
 		Retries:              1,
 		MaxActiveRuns:        1,
+		Catchup:              true,
 	}
+	catchup := true
 	expectedDagConfig := DAGConfig{
 		Name:        "test-config",
 		Namespace:   goflowConfig.DefaultNamespace,
@@ -33,6 +36,7 @@ func TestSetConfigDefaults(t *testing.T) {
 		Parallelism:   1,
 		Retries:       1,
 		MaxActiveRuns: 1,
+		Catchup:       &catchup,
 		WithLogs:      false,
 	}
 	dagConfigCases := []DAGConfig{
@@ -53,3 +57,57 @@ func TestSetConfigDefaults(t *testing.T) {
 		}
 	}
 }
+
+func TestCatchupOverridesDefault(t *testing.T) {
+	goflowConfig := config.GoFlowConfig{Catchup: true}
+	catchup := false
+	dagConfig := DAGConfig{Name: "test-catchup", Catchup: &catchup}
+	dagConfig.SetDefaults(goflowConfig)
+	if dagConfig.CatchupEnabled() {
+		t.Error("Catchup set in the DAG should take precedence over the default")
+	}
+	if (&DAGConfig{}).CatchupEnabled() {
+		t.Error("Catchup should be disabled when it has not been set")
+	}
+}
+
+func TestUnmarshalUnknownField(t *testing.T) {
+	dagConfig := DAGConfig{}
+	err := Unmarshal([]byte(`{"Name": "test", "Shedule": "@daily"}`), &dagConfig)
+	if field, unknown := UnknownField(err); !unknown || field != "Shedule" {
+		t.Errorf("Expected an error for the unknown field Shedule, found %v", err)
+	}
+	err = Unmarshal([]byte(`{"name": "test", "tasks": [{"name": "first"}]}`), &dagConfig)
+	if err != nil || dagConfig.Tasks[0].Name != "first" {
+		t.Errorf("Expected keys to be read regardless of case, found %v", err)
+	}
+	err = Unmarshal([]byte(`{"Name": "test"} {"Name": "other"}`), &dagConfig)
+	if err == nil {
+		t.Error("Expected an error for a file with more than one DAG config")
+	}
+}
+
+func TestValidateNameAndNamespace(t *testing.T) {
+	cases := []struct {
+		config        DAGConfig
+		expectedError string
+	}{
+		{DAGConfig{Name: "test-dag", Namespace: "team-a"}, ""},
+		{DAGConfig{Name: "1-dag"}, "must match the pattern"},
+		{DAGConfig{Name: "test-dag", Namespace: "Team_A"}, "invalid Namespace"},
+	}
+	for _, testCase := range cases {
+		err := testCase.config.ValidateName()
+		if err == nil {
+			err = testCase.config.ValidateNamespace()
+		}
+		found := ""
+		if err != nil {
+			found = err.Error()
+		}
+		if testCase.expectedError == "" && found != "" ||
+			!strings.Contains(found, testCase.expectedError) {
+			t.Errorf("Expected error \"%s\", found \"%s\"", testCase.expectedError, found)
+		}
+	}
+}
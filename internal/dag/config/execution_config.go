@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+
+	core "k8s.io/api/core/v1"
+)
+
+const (
+	// PodExecution runs every task attempt as a bare pod
+	PodExecution = "pod"
+	// JobExecution runs every task attempt as a kubernetes Job, which creates the task's pods
+	JobExecution = "job"
+)
+
+// RunsAsJob returns true if the DAG's tasks are run as kubernetes Jobs
+func (config *DAGConfig) RunsAsJob() bool {
+	return config.ExecutionMode == JobExecution
+}
+
+// ValidateRestartPolicy returns an error if the DAG's RetryPolicy is not a kubernetes restart
+// policy
+func (config *DAGConfig) ValidateRestartPolicy() error {
+	switch config.RetryPolicy {
+	case "", core.RestartPolicyAlways, core.RestartPolicyOnFailure, core.RestartPolicyNever:
+		return nil
+	}
+	return fmt.Errorf(
+		"RetryPolicy must be \"%s\", \"%s\" or \"%s\", found \"%s\"",
+		core.RestartPolicyAlways,
+		core.RestartPolicyOnFailure,
+		core.RestartPolicyNever,
+		config.RetryPolicy,
+	)
+}
+
+// ValidateExecutionMode returns an error if the execution mode or the Job settings are not valid
+func (config *DAGConfig) ValidateExecutionMode() error {
+	switch config.ExecutionMode {
+	case "", PodExecution:
+		return nil
+	case JobExecution:
+	default:
+		return fmt.Errorf(
+			"ExecutionMode must be \"%s\" or \"%s\", found \"%s\"",
+			PodExecution,
+			JobExecution,
+			config.ExecutionMode,
+		)
+	}
+	switch config.RetryPolicy {
+	case "", core.RestartPolicyNever, core.RestartPolicyOnFailure:
+	default:
+		return fmt.Errorf(
+			"RetryPolicy must be \"%s\" or \"%s\" when running tasks as Jobs, found \"%s\"",
+			core.RestartPolicyNever,
+			core.RestartPolicyOnFailure,
+			config.RetryPolicy,
+		)
+	}
+	if config.Parallelism < 0 {
+		return fmt.Errorf("Parallelism must not be negative, found %d", config.Parallelism)
+	}
+	nonNegative := []struct {
+		name  string
+		value *int32
+	}{
+		{"Completions", config.Completions},
+		{"BackoffLimit", config.BackoffLimit},
+		{"TTLSecondsAfterFinished", config.TTLSecondsAfterFinished},
+	}
+	for _, field := range nonNegative {
+		if field.value != nil && *field.value < 0 {
+			return fmt.Errorf("%s must not be negative, found %d", field.name, *field.value)
+		}
+	}
+	if config.TimeLimit != nil && *config.TimeLimit <= 0 {
+		return fmt.Errorf("TimeLimit must be positive, found %d", *config.TimeLimit)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"testing"
+
+	core "k8s.io/api/core/v1"
+)
+
+func TestValidateExecutionMode(t *testing.T) {
+	negative := int32(-1)
+	noTime := int64(0)
+	cases := []struct {
+		config      DAGConfig
+		expectError bool
+	}{
+		{DAGConfig{}, false},
+		{DAGConfig{ExecutionMode: PodExecution, RetryPolicy: core.RestartPolicyAlways}, false},
+		{DAGConfig{ExecutionMode: JobExecution, RetryPolicy: core.RestartPolicyOnFailure}, false},
+		{DAGConfig{ExecutionMode: "deployment"}, true},
+		{DAGConfig{ExecutionMode: JobExecution, RetryPolicy: core.RestartPolicyAlways}, true},
+		{DAGConfig{ExecutionMode: JobExecution, Parallelism: -1}, true},
+		{DAGConfig{ExecutionMode: JobExecution, Completions: &negative}, true},
+		{DAGConfig{ExecutionMode: JobExecution, BackoffLimit: &negative}, true},
+		{DAGConfig{ExecutionMode: JobExecution, TTLSecondsAfterFinished: &negative}, true},
+		{DAGConfig{ExecutionMode: JobExecution, TimeLimit: &noTime}, true},
+	}
+	for _, testCase := range cases {
+		err := testCase.config.ValidateExecutionMode()
+		if (err != nil) != testCase.expectError {
+			t.Errorf(
+				"Expected error %t for config %s, found %v",
+				testCase.expectError,
+				testCase.config.JSON(),
+				err,
+			)
+		}
+	}
+}
+
+func TestValidateRestartPolicy(t *testing.T) {
+	for _, policy := range []core.RestartPolicy{"", core.RestartPolicyAlways, "Never"} {
+		if err := (&DAGConfig{RetryPolicy: policy}).ValidateRestartPolicy(); err != nil {
+			t.Errorf("Expected restart policy \"%s\" to be valid, found %s", policy, err)
+		}
+	}
+	if err := (&DAGConfig{RetryPolicy: "Sometimes"}).ValidateRestartPolicy(); err == nil {
+		t.Error("Expected an error for restart policy Sometimes")
+	}
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// imageReferenceRegex matches docker image references, such as "busybox", "python:3.9" or
+// "registry.example.com:5000/team/app:v1@sha256:<digest>", following the grammar of
+// docker/distribution's reference package
+var imageReferenceRegex = regexp.MustCompile(
+	`^(?:[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?` +
+		`(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?)*(?::[0-9]+)?/)?` +
+		`[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*` +
+		`(?::[a-zA-Z0-9_][a-zA-Z0-9_.-]{0,127})?` +
+		`(?:@sha256:[a-f0-9]{64})?$`,
+)
+
+// IsImageReferenceValid returns true if the image is a valid docker image reference
+func IsImageReferenceValid(image string) bool {
+	return len(image) <= 255 && imageReferenceRegex.MatchString(image)
+}
+
+// ValidateImages returns an error if the DockerImage of the DAG or of any of its tasks is not a
+// valid docker image reference
+func (config *DAGConfig) ValidateImages() error {
+	if config.DockerImage != "" && !IsImageReferenceValid(config.DockerImage) {
+		return fmt.Errorf(
+			"DAG %s has an invalid DockerImage \"%s\"",
+			config.Name,
+			config.DockerImage,
+		)
+	}
+	for _, task := range config.Tasks {
+		if task.DockerImage != "" && !IsImageReferenceValid(task.DockerImage) {
+			return fmt.Errorf(
+				"task \"%s\" in DAG %s has an invalid DockerImage \"%s\"",
+				task.Name,
+				config.Name,
+				task.DockerImage,
+			)
+		}
+	}
+	return nil
+}
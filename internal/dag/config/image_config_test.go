@@ -0,0 +1,37 @@
+package config
+
+import "testing"
+
+func TestValidateImages(t *testing.T) {
+	digest := "@sha256:" + "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	cases := []struct {
+		image       string
+		expectError bool
+	}{
+		{"busybox", false},
+		{"python:3.9-slim", false},
+		{"registry.example.com:5000/team/app:v1" + digest, false},
+		{"Busybox", true},
+		{"busybox:", true},
+		{"team//app", true},
+		{"app:tag with spaces", true},
+	}
+	for _, testCase := range cases {
+		dagImage := DAGConfig{Name: "test", DockerImage: testCase.image}
+		taskImage := DAGConfig{
+			Name:  "test",
+			Tasks: []TaskConfig{{Name: "task", DockerImage: testCase.image}},
+		}
+		for _, config := range []DAGConfig{dagImage, taskImage} {
+			err := config.ValidateImages()
+			if (err != nil) != testCase.expectError {
+				t.Errorf(
+					"Expected error %t for image \"%s\", found %v",
+					testCase.expectError,
+					testCase.image,
+					err,
+				)
+			}
+		}
+	}
+}
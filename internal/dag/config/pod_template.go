@@ -0,0 +1,124 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// TaskContainerName is the name of the container that runs a task's command. A container in the
+// PodTemplate with this name, or with no name, overrides the task's container.
+const TaskContainerName = "task"
+
+// templateWithTaskContainer returns a copy of the PodTemplate with its unnamed container named
+// after the task's container
+func (config *DAGConfig) templateWithTaskContainer() *core.PodSpec {
+	template := config.PodTemplate.DeepCopy()
+	for i := range template.Containers {
+		if template.Containers[i].Name == "" {
+			template.Containers[i].Name = TaskContainerName
+		}
+	}
+	return template
+}
+
+// templatePatch returns the PodTemplate as a strategic merge patch. Fields that are left out of
+// the template are dropped from the patch so that they do not clear the generated values.
+func (config *DAGConfig) templatePatch() ([]byte, error) {
+	templateBytes, err := json.Marshal(config.templateWithTaskContainer())
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]interface{})
+	err = json.Unmarshal(templateBytes, &fields)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range fields {
+		if value == nil {
+			delete(fields, name)
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// MergePodTemplate returns the given pod spec with the DAG's PodTemplate merged over it. Lists
+// such as containers, volume mounts and env are merged by name, so the template can both change
+// the task's container and add sidecar containers.
+func (config *DAGConfig) MergePodTemplate(spec core.PodSpec) (core.PodSpec, error) {
+	if config.PodTemplate == nil {
+		return spec, nil
+	}
+	specBytes, err := json.Marshal(spec)
+	if err != nil {
+		return core.PodSpec{}, err
+	}
+	patch, err := config.templatePatch()
+	if err != nil {
+		return core.PodSpec{}, err
+	}
+	mergedBytes, err := strategicpatch.StrategicMergePatch(specBytes, patch, core.PodSpec{})
+	if err != nil {
+		return core.PodSpec{}, err
+	}
+	merged := core.PodSpec{}
+	err = json.Unmarshal(mergedBytes, &merged)
+	return merged, err
+}
+
+// ValidatePodTemplate returns an error if the PodTemplate cannot be merged into a task's pod
+func (config *DAGConfig) ValidatePodTemplate() error {
+	if config.PodTemplate == nil {
+		return nil
+	}
+	err := config.validatePodTemplate()
+	if err != nil {
+		return fmt.Errorf("DAG %s has an invalid PodTemplate: %s", config.Name, err.Error())
+	}
+	return nil
+}
+
+func (config *DAGConfig) validatePodTemplate() error {
+	template := config.templateWithTaskContainer()
+	volumes := make(map[string]bool)
+	for _, volume := range template.Volumes {
+		for _, msg := range validation.IsDNS1123Label(volume.Name) {
+			return fmt.Errorf("volume name \"%s\" is not valid: %s", volume.Name, msg)
+		}
+		if volumes[volume.Name] {
+			return fmt.Errorf("there is more than one volume named \"%s\"", volume.Name)
+		}
+		volumes[volume.Name] = true
+	}
+	containers := make(map[string]bool)
+	for _, container := range template.Containers {
+		if containers[container.Name] {
+			return fmt.Errorf("there is more than one container named \"%s\"", container.Name)
+		}
+		containers[container.Name] = true
+		if container.Name != TaskContainerName && container.Image == "" {
+			return fmt.Errorf("container \"%s\" must have an Image", container.Name)
+		}
+		for _, mount := range container.VolumeMounts {
+			if !volumes[mount.Name] {
+				return fmt.Errorf(
+					"container \"%s\" mounts volume \"%s\" which is not in Volumes",
+					container.Name,
+					mount.Name,
+				)
+			}
+		}
+	}
+	switch template.RestartPolicy {
+	case "", core.RestartPolicyAlways, core.RestartPolicyOnFailure, core.RestartPolicyNever:
+	default:
+		return fmt.Errorf("RestartPolicy \"%s\" is not valid", template.RestartPolicy)
+	}
+	_, err := config.MergePodTemplate(core.PodSpec{
+		Containers: []core.Container{{Name: TaskContainerName}},
+	})
+	return err
+}
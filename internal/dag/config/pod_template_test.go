@@ -0,0 +1,107 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// podTemplateFromJSON returns a DAG config with the PodTemplate given in JSON
+func podTemplateFromJSON(templateJSON string) DAGConfig {
+	config := DAGConfig{Name: "test-config"}
+	err := json.Unmarshal([]byte(`{"PodTemplate": `+templateJSON+`}`), &config)
+	if err != nil {
+		panic(err)
+	}
+	return config
+}
+
+func TestMergePodTemplate(t *testing.T) {
+	config := podTemplateFromJSON(`{
+		"nodeSelector": {"gpu": "true"},
+		"tolerations": [{"key": "nvidia.com/gpu", "operator": "Exists", "effect": "NoSchedule"}],
+		"volumes": [{"name": "data", "persistentVolumeClaim": {"claimName": "data-pvc"}}],
+		"containers": [
+			{
+				"resources": {"limits": {"memory": "1Gi"}},
+				"volumeMounts": [{"name": "data", "mountPath": "/data"}]
+			},
+			{"name": "proxy", "image": "envoy"}
+		]
+	}`)
+	err := config.ValidatePodTemplate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := core.PodSpec{
+		Containers: []core.Container{{
+			Name:    TaskContainerName,
+			Image:   "busybox",
+			Command: []string{"echo", "1"},
+		}},
+		RestartPolicy:      core.RestartPolicyNever,
+		ServiceAccountName: "goflow",
+	}
+	merged, err := config.MergePodTemplate(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(merged.Containers) != 2 {
+		t.Fatalf("Expected the task container and a sidecar, found %d", len(merged.Containers))
+	}
+	task := merged.Containers[0]
+	if task.Name != TaskContainerName || task.Image != "busybox" || len(task.Command) != 2 {
+		t.Errorf("Expected the generated task container to be kept, found %v", task)
+	}
+	if !task.Resources.Limits.Memory().Equal(resource.MustParse("1Gi")) {
+		t.Errorf("Expected a memory limit of 1Gi, found %v", task.Resources.Limits)
+	}
+	if len(task.VolumeMounts) != 1 || task.VolumeMounts[0].MountPath != "/data" {
+		t.Errorf("Expected the data volume to be mounted, found %v", task.VolumeMounts)
+	}
+	if merged.Containers[1].Name != "proxy" {
+		t.Errorf("Expected sidecar container proxy, found %s", merged.Containers[1].Name)
+	}
+	if merged.NodeSelector["gpu"] != "true" || len(merged.Tolerations) != 1 {
+		t.Errorf("Expected node selector and toleration, found %s", jsonString(merged))
+	}
+	if merged.RestartPolicy != core.RestartPolicyNever || merged.ServiceAccountName != "goflow" {
+		t.Errorf("Expected generated fields to be kept, found %s", jsonString(merged))
+	}
+}
+
+func jsonString(value interface{}) string {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		panic(err)
+	}
+	return string(valueBytes)
+}
+
+func TestValidatePodTemplate(t *testing.T) {
+	cases := []struct {
+		name         string
+		templateJSON string
+	}{
+		{
+			"Missing Volume",
+			`{"containers": [{"volumeMounts": [{"name": "data", "mountPath": "/d"}]}]}`,
+		},
+		{"Invalid Volume Name", `{"volumes": [{"name": "Data_Volume", "emptyDir": {}}]}`},
+		{"Duplicate Task Container", `{"containers": [{"name": "task"}, {}]}`},
+		{"Sidecar Without Image", `{"containers": [{"name": "proxy"}]}`},
+		{"Invalid Restart Policy", `{"restartPolicy": "Sometimes"}`},
+	}
+	for _, testCase := range cases {
+		config := podTemplateFromJSON(testCase.templateJSON)
+		if config.ValidatePodTemplate() == nil {
+			t.Errorf("%s: expected a validation error", testCase.name)
+		}
+	}
+	config := DAGConfig{}
+	if config.ValidatePodTemplate() != nil {
+		t.Error("A DAG without a PodTemplate should be valid")
+	}
+}
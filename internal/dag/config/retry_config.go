@@ -0,0 +1,51 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// FixedBackoff waits RetryDelay seconds before every retry
+	FixedBackoff = "fixed"
+	// ExponentialBackoff doubles the wait before each retry, starting from RetryDelay seconds
+	ExponentialBackoff = "exponential"
+)
+
+// maxRetryDelay is the longest time that a task waits before being retried
+const maxRetryDelay = time.Hour
+
+// ValidateRetries returns an error if the retry settings are not valid
+func (config *DAGConfig) ValidateRetries() error {
+	if config.Retries < 0 {
+		return fmt.Errorf("Retries must not be negative, found %d", config.Retries)
+	}
+	if config.RetryDelay < 0 {
+		return fmt.Errorf("RetryDelay must not be negative, found %d", config.RetryDelay)
+	}
+	switch config.RetryBackoff {
+	case "", FixedBackoff, ExponentialBackoff:
+		return nil
+	}
+	return fmt.Errorf(
+		"RetryBackoff must be \"%s\" or \"%s\", found \"%s\"",
+		FixedBackoff,
+		ExponentialBackoff,
+		config.RetryBackoff,
+	)
+}
+
+// RetryDelayAfter returns how long to wait before retrying a task whose given attempt failed
+func (config *DAGConfig) RetryDelayAfter(failedAttempt int) time.Duration {
+	delay := time.Duration(config.RetryDelay) * time.Second
+	if config.RetryBackoff != ExponentialBackoff {
+		return delay
+	}
+	for i := 1; i < failedAttempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		return maxRetryDelay
+	}
+	return delay
+}
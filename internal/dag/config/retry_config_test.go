@@ -0,0 +1,59 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryDelayAfter(t *testing.T) {
+	cases := []struct {
+		backoff       string
+		failedAttempt int
+		expectedDelay time.Duration
+	}{
+		{"", 3, 10 * time.Second},
+		{FixedBackoff, 1, 10 * time.Second},
+		{FixedBackoff, 4, 10 * time.Second},
+		{ExponentialBackoff, 1, 10 * time.Second},
+		{ExponentialBackoff, 2, 20 * time.Second},
+		{ExponentialBackoff, 4, 80 * time.Second},
+		{ExponentialBackoff, 100, maxRetryDelay},
+	}
+	for _, testCase := range cases {
+		config := DAGConfig{RetryDelay: 10, RetryBackoff: testCase.backoff}
+		delay := config.RetryDelayAfter(testCase.failedAttempt)
+		if delay != testCase.expectedDelay {
+			t.Errorf(
+				"Expected %s backoff after attempt %d to wait %s, found %s",
+				testCase.backoff,
+				testCase.failedAttempt,
+				testCase.expectedDelay,
+				delay,
+			)
+		}
+	}
+}
+
+func TestValidateRetries(t *testing.T) {
+	cases := []struct {
+		config      DAGConfig
+		expectError bool
+	}{
+		{DAGConfig{Retries: 2, RetryDelay: 5, RetryBackoff: ExponentialBackoff}, false},
+		{DAGConfig{}, false},
+		{DAGConfig{Retries: -1}, true},
+		{DAGConfig{RetryDelay: -1}, true},
+		{DAGConfig{RetryBackoff: "linear"}, true},
+	}
+	for _, testCase := range cases {
+		err := testCase.config.ValidateRetries()
+		if (err != nil) != testCase.expectError {
+			t.Errorf(
+				"Expected error %t for config %s, found %v",
+				testCase.expectError,
+				testCase.config.JSON(),
+				err,
+			)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+
+	core "k8s.io/api/core/v1"
+)
+
+// namespacePattern matches kubernetes namespace names, which are DNS-1123 labels
+const namespacePattern = "^[a-z0-9]([-a-z0-9]*[a-z0-9])?$"
+
+// schemaObject is a JSON Schema, or one of the properties within it
+type schemaObject map[string]interface{}
+
+// schemaFields adds constraints to the schemas of fields, keyed by struct and field name, beyond
+// what the field's type gives
+var schemaFields = map[string]schemaObject{
+	"DAGConfig.Name":          {"pattern": validNameRegexString},
+	"DAGConfig.Namespace":     {"pattern": namespacePattern, "maxLength": 63},
+	"DAGConfig.DockerImage":   {"pattern": imageReferenceRegex.String()},
+	"DAGConfig.ExecutionMode": {"enum": []string{"", PodExecution, JobExecution}},
+	"DAGConfig.RetryBackoff":  {"enum": []string{"", FixedBackoff, ExponentialBackoff}},
+	"DAGConfig.MaxActiveRuns": {"minimum": 0},
+	"DAGConfig.Retries":       {"minimum": 0},
+	"DAGConfig.RetryDelay":    {"minimum": 0},
+	"TaskConfig.Name":         {"pattern": validNameRegexString},
+	"TaskConfig.DockerImage":  {"pattern": imageReferenceRegex.String()},
+	"SensorConfig.Type":       {"enum": []string{FileSensor, HTTPSensor, SQLSensor, DAGSensor}},
+}
+
+// schemaRequired lists the fields that must be given, keyed by struct name
+var schemaRequired = map[string][]string{
+	"DAGConfig":    {"Name"},
+	"TaskConfig":   {"Name"},
+	"SensorConfig": {"Type"},
+	"SecretKeyRef": {"Secret", "Key"},
+}
+
+// JSONSchema returns the JSON Schema of DAG configs, which editors and CI can check DAG files
+// against. Keys are given as they are named in DAGConfig, though goflow reads them regardless of
+// case.
+func JSONSchema() []byte {
+	definitions := schemaObject{}
+	schema := structSchema(reflect.TypeOf(DAGConfig{}), definitions)
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = "GoFlow DAG"
+	schema["definitions"] = definitions
+	schemaBytes, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	return append(schemaBytes, '\n')
+}
+
+// structSchema returns the schema of an object with the fields of the struct type
+func structSchema(structType reflect.Type, definitions schemaObject) schemaObject {
+	properties := schemaObject{}
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		property := typeSchema(field.Type, definitions)
+		for key, value := range schemaFields[structType.Name()+"."+field.Name] {
+			property[key] = value
+		}
+		properties[field.Name] = property
+	}
+	schema := schemaObject{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if required, ok := schemaRequired[structType.Name()]; ok {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// typeSchema returns the schema of a value of the type. Structs other than DAGConfig are added
+// to the definitions and referred to.
+func typeSchema(valueType reflect.Type, definitions schemaObject) schemaObject {
+	switch valueType {
+	case reflect.TypeOf(core.RestartPolicy("")):
+		return schemaObject{"type": "string", "enum": []core.RestartPolicy{
+			"",
+			core.RestartPolicyAlways,
+			core.RestartPolicyOnFailure,
+			core.RestartPolicyNever,
+		}}
+	case reflect.TypeOf(core.PodSpec{}):
+		return schemaObject{
+			"type":        "object",
+			"description": "A kubernetes PodSpec, merged over the pod of each task",
+		}
+	}
+	switch valueType.Kind() {
+	case reflect.Ptr:
+		schema := typeSchema(valueType.Elem(), definitions)
+		if _, isRef := schema["$ref"]; isRef {
+			return schemaObject{"oneOf": []schemaObject{schema, {"type": "null"}}}
+		}
+		schema["type"] = []interface{}{schema["type"], "null"}
+		return schema
+	case reflect.String:
+		return schemaObject{"type": "string"}
+	case reflect.Bool:
+		return schemaObject{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return schemaObject{"type": "integer"}
+	case reflect.Slice:
+		return schemaObject{"type": "array", "items": typeSchema(valueType.Elem(), definitions)}
+	case reflect.Map:
+		return schemaObject{
+			"type":                 "object",
+			"additionalProperties": typeSchema(valueType.Elem(), definitions),
+		}
+	case reflect.Struct:
+		if _, defined := definitions[valueType.Name()]; !defined {
+			definitions[valueType.Name()] = structSchema(valueType, definitions)
+		}
+		return schemaObject{"$ref": "#/definitions/" + valueType.Name()}
+	}
+	panic("no JSON Schema for DAG config fields of type " + valueType.String())
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestJSONSchemaIsPublished(t *testing.T) {
+	published, err := ioutil.ReadFile(filepath.Join("..", "..", "..", "schema", "dag.schema.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(published, JSONSchema()) {
+		t.Error("schema/dag.schema.json does not match JSONSchema(), write it out again")
+	}
+}
+
+func TestJSONSchemaCoversFields(t *testing.T) {
+	schema := struct {
+		Properties           map[string]interface{}
+		AdditionalProperties bool
+		Definitions          map[string]struct{ Properties map[string]interface{} }
+	}{}
+	err := json.Unmarshal(JSONSchema(), &schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema.AdditionalProperties {
+		t.Error("Expected the schema to disallow unknown keys")
+	}
+	structs := map[string]map[string]interface{}{
+		"DAGConfig":  schema.Properties,
+		"TaskConfig": schema.Definitions["TaskConfig"].Properties,
+	}
+	for structName, properties := range structs {
+		structType := reflect.TypeOf(DAGConfig{})
+		if structName == "TaskConfig" {
+			structType = reflect.TypeOf(TaskConfig{})
+		}
+		for i := 0; i < structType.NumField(); i++ {
+			if _, ok := properties[structType.Field(i).Name]; !ok {
+				t.Errorf("Expected %s.%s in the schema", structName, structType.Field(i).Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// RedactedValue replaces the values of literal environment variables when a DAG is served
+const RedactedValue = "<redacted>"
+
+// SecretKeyRef refers to a single key of a Kubernetes Secret in the DAG's namespace
+type SecretKeyRef struct {
+	Secret string
+	Key    string
+}
+
+// EnvFromSources returns the Secrets and ConfigMaps whose keys are all passed to each task's
+// container as environment variables
+func (config *DAGConfig) EnvFromSources() []core.EnvFromSource {
+	if len(config.EnvFromSecrets) == 0 && len(config.EnvFromConfigMaps) == 0 {
+		return nil
+	}
+	sources := make(
+		[]core.EnvFromSource,
+		0,
+		len(config.EnvFromSecrets)+len(config.EnvFromConfigMaps),
+	)
+	for _, name := range config.EnvFromConfigMaps {
+		sources = append(sources, core.EnvFromSource{
+			ConfigMapRef: &core.ConfigMapEnvSource{
+				LocalObjectReference: core.LocalObjectReference{Name: name},
+			},
+		})
+	}
+	for _, name := range config.EnvFromSecrets {
+		sources = append(sources, core.EnvFromSource{
+			SecretRef: &core.SecretEnvSource{
+				LocalObjectReference: core.LocalObjectReference{Name: name},
+			},
+		})
+	}
+	return sources
+}
+
+// SecretEnvVars returns the environment variables that are read from a key of a Secret, ordered
+// by name
+func (config *DAGConfig) SecretEnvVars() []core.EnvVar {
+	names := make([]string, 0, len(config.SecretEnv))
+	for name := range config.SecretEnv {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	envVars := make([]core.EnvVar, 0, len(names))
+	for _, name := range names {
+		ref := config.SecretEnv[name]
+		envVars = append(envVars, core.EnvVar{
+			Name: name,
+			ValueFrom: &core.EnvVarSource{
+				SecretKeyRef: &core.SecretKeySelector{
+					LocalObjectReference: core.LocalObjectReference{Name: ref.Secret},
+					Key:                  ref.Key,
+				},
+			},
+		})
+	}
+	return envVars
+}
+
+// ValidateSecrets returns an error if a Secret, ConfigMap, key or variable name is not valid, or
+// if a SecretEnv variable is also given a literal value in Env
+func (config *DAGConfig) ValidateSecrets() error {
+	objectNames := append(append([]string{}, config.EnvFromSecrets...), config.EnvFromConfigMaps...)
+	for _, name := range objectNames {
+		err := validationError("name", name, validation.IsDNS1123Subdomain(name))
+		if err != nil {
+			return config.secretError(err)
+		}
+	}
+	literalNames := make(map[string]bool)
+	for _, task := range config.TaskConfigs() {
+		for name := range task.Env {
+			literalNames[name] = true
+		}
+	}
+	for name, ref := range config.SecretEnv {
+		err := validationError("variable name", name, validation.IsEnvVarName(name))
+		if err == nil {
+			msgs := validation.IsDNS1123Subdomain(ref.Secret)
+			err = validationError("Secret name", ref.Secret, msgs)
+		}
+		if err == nil {
+			err = validationError("Secret key", ref.Key, validation.IsConfigMapKey(ref.Key))
+		}
+		if err == nil && literalNames[name] {
+			err = fmt.Errorf("variable %s is set in both SecretEnv and Env", name)
+		}
+		if err != nil {
+			return config.secretError(err)
+		}
+	}
+	return nil
+}
+
+func (config *DAGConfig) secretError(err error) error {
+	return fmt.Errorf("DAG %s has an invalid secret reference: %s", config.Name, err.Error())
+}
+
+// validationError returns an error built from the messages of a Kubernetes validation function
+func validationError(kind string, value string, msgs []string) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s \"%s\" is not valid: %s", kind, value, strings.Join(msgs, ", "))
+}
+
+// RedactEnv returns a copy of the environment with every value replaced
+func RedactEnv(env map[string]string) map[string]string {
+	if env == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(env))
+	for name := range env {
+		redacted[name] = RedactedValue
+	}
+	return redacted
+}
+
+// redactContainers replaces the literal env values of each container
+func redactContainers(containers []core.Container) {
+	for i := range containers {
+		for j := range containers[i].Env {
+			if containers[i].Env[j].Value != "" {
+				containers[i].Env[j].Value = RedactedValue
+			}
+		}
+	}
+}
+
+// Redacted returns a copy of the TaskConfig with the values of its Env, and the DSN of its sensor,
+// replaced
+func (task TaskConfig) Redacted() TaskConfig {
+	redacted := task.Copy()
+	redacted.Env = RedactEnv(task.Env)
+	if redacted.Sensor != nil && redacted.Sensor.DSN != "" {
+		redacted.Sensor.DSN = RedactedValue
+	}
+	return redacted
+}
+
+// Redacted returns a copy of the DAGConfig with the values of all literal environment variables
+// replaced, so that it can be served without exposing credentials
+func (config DAGConfig) Redacted() DAGConfig {
+	redacted := config.Copy()
+	redacted.Env = RedactEnv(config.Env)
+	for i := range redacted.Tasks {
+		redacted.Tasks[i] = redacted.Tasks[i].Redacted()
+	}
+	if redacted.PodTemplate != nil {
+		redactContainers(redacted.PodTemplate.InitContainers)
+		redactContainers(redacted.PodTemplate.Containers)
+	}
+	return redacted
+}
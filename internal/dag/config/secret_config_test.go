@@ -0,0 +1,102 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	core "k8s.io/api/core/v1"
+)
+
+func TestSecretEnvVars(t *testing.T) {
+	config := DAGConfig{
+		EnvFromSecrets:    []string{"db-credentials"},
+		EnvFromConfigMaps: []string{"settings"},
+		SecretEnv: map[string]SecretKeyRef{
+			"PASSWORD": {Secret: "db-credentials", Key: "password"},
+			"API_KEY":  {Secret: "api", Key: "key"},
+		},
+	}
+	sources := config.EnvFromSources()
+	if len(sources) != 2 || sources[0].ConfigMapRef.Name != "settings" ||
+		sources[1].SecretRef.Name != "db-credentials" {
+		t.Errorf("Expected a ConfigMap and a Secret source, found %v", sources)
+	}
+	envVars := config.SecretEnvVars()
+	if len(envVars) != 2 || envVars[0].Name != "API_KEY" || envVars[1].Name != "PASSWORD" {
+		t.Fatalf("Expected API_KEY and PASSWORD, found %v", envVars)
+	}
+	selector := envVars[1].ValueFrom.SecretKeyRef
+	if selector.Name != "db-credentials" || selector.Key != "password" {
+		t.Errorf("Expected a reference to db-credentials/password, found %v", selector)
+	}
+}
+
+func TestValidateSecrets(t *testing.T) {
+	cases := []struct {
+		name      string
+		config    DAGConfig
+		expectErr bool
+	}{
+		{
+			"Valid",
+			DAGConfig{
+				EnvFromSecrets: []string{"db-credentials"},
+				SecretEnv:      map[string]SecretKeyRef{"PASSWORD": {"db-credentials", "password"}},
+			},
+			false,
+		},
+		{"Invalid Secret", DAGConfig{EnvFromSecrets: []string{"DB Credentials"}}, true},
+		{"Invalid ConfigMap", DAGConfig{EnvFromConfigMaps: []string{"settings/"}}, true},
+		{
+			"Invalid Variable",
+			DAGConfig{SecretEnv: map[string]SecretKeyRef{"PASS=WORD": {"db", "password"}}},
+			true,
+		},
+		{
+			"Invalid Key",
+			DAGConfig{SecretEnv: map[string]SecretKeyRef{"PASSWORD": {"db", "pass word"}}},
+			true,
+		},
+		{
+			"Also In Env",
+			DAGConfig{
+				Env:       map[string]string{"PASSWORD": "hunter2"},
+				SecretEnv: map[string]SecretKeyRef{"PASSWORD": {"db", "password"}},
+			},
+			true,
+		},
+	}
+	for _, testCase := range cases {
+		err := testCase.config.ValidateSecrets()
+		if (err != nil) != testCase.expectErr {
+			t.Errorf("%s: expected error %t, found %v", testCase.name, testCase.expectErr, err)
+		}
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	config := DAGConfig{
+		Name:      "test-config",
+		Env:       map[string]string{"PASSWORD": "hunter2"},
+		Tasks:     []TaskConfig{{Name: "first", Env: map[string]string{"TOKEN": "abc123"}}},
+		SecretEnv: map[string]SecretKeyRef{"API_KEY": {"api", "key"}},
+		PodTemplate: &core.PodSpec{
+			Containers: []core.Container{{Env: []core.EnvVar{{Name: "SECRET", Value: "s3cr3t"}}}},
+		},
+	}
+	redactedJSON := config.Redacted().JSON()
+	for _, value := range []string{"hunter2", "abc123", "s3cr3t"} {
+		if strings.Contains(redactedJSON, value) {
+			t.Errorf("Expected %s to be redacted, found %s", value, redactedJSON)
+		}
+	}
+	for _, name := range []string{"PASSWORD", "TOKEN", "SECRET", "API_KEY"} {
+		if !strings.Contains(redactedJSON, name) {
+			t.Errorf("Expected variable %s to still be listed, found %s", name, redactedJSON)
+		}
+	}
+	if config.Env["PASSWORD"] != "hunter2" ||
+		config.PodTemplate.Containers[0].Env[0].Value != "s3cr3t" {
+		t.Error("Redacting should not change the original config")
+	}
+}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3" // Default driver of sql sensors, which is always accepted
+)
+
+const (
+	// FileSensor waits until a file matching Path exists
+	FileSensor = "file"
+	// HTTPSensor waits until a GET request to URL returns 200 OK
+	HTTPSensor = "http"
+	// SQLSensor waits until Query returns at least one row
+	SQLSensor = "sql"
+	// DAGSensor waits until the run of DAG for the same execution date has succeeded
+	DAGSensor = "dag"
+)
+
+// Defaults for the timing of sensors, in seconds
+const (
+	defaultPokeInterval  = 60
+	defaultSensorTimeout = 24 * 60 * 60
+)
+
+// SensorConfig is a struct storing the configurable values of a sensor task, which waits on an
+// external condition inside goflow rather than running a pod. Path, URL and Query may be templates.
+type SensorConfig struct {
+	Type         string
+	Path         string // File path or glob pattern, for file sensors
+	URL          string // For http sensors
+	Driver       string // database/sql driver of sql sensors, defaults to "sqlite3"
+	DSN          string // Data source name of sql sensors, only for DSNs without credentials
+	DSNEnv       string // SecretEnv variable of the DAG whose Secret key holds the DSN instead
+	Query        string
+	DAG          string // Name of the DAG waited on by dag sensors
+	PokeInterval int64  // Seconds between checks of the condition
+	Timeout      int64  // Seconds after which the sensor stops waiting
+	SoftFail     bool   // Skip rather than fail the task when the sensor times out
+}
+
+// PokeIntervalDuration returns how long the sensor waits between checks of its condition
+func (sensor SensorConfig) PokeIntervalDuration() time.Duration {
+	if sensor.PokeInterval == 0 {
+		return defaultPokeInterval * time.Second
+	}
+	return time.Duration(sensor.PokeInterval) * time.Second
+}
+
+// TimeoutDuration returns how long the sensor waits for its condition before giving up
+func (sensor SensorConfig) TimeoutDuration() time.Duration {
+	if sensor.Timeout == 0 {
+		return defaultSensorTimeout * time.Second
+	}
+	return time.Duration(sensor.Timeout) * time.Second
+}
+
+// Templates returns the sensor's fields that may be templates
+func (sensor SensorConfig) Templates() []string {
+	return []string{sensor.Path, sensor.URL, sensor.Query}
+}
+
+// isRegisteredDriver returns true if goflow has the database/sql driver with the given name
+func isRegisteredDriver(driver string) bool {
+	for _, registered := range sql.Drivers() {
+		if driver == registered {
+			return true
+		}
+	}
+	return false
+}
+
+// validate returns an error if the sensor is missing the fields needed by its type, if the driver
+// of a sql sensor is not one that goflow has, or if the DAG waited on by a dag sensor does not have
+// a valid name
+func (sensor SensorConfig) validate() error {
+	if sensor.PokeInterval < 0 {
+		return fmt.Errorf("PokeInterval must not be negative, found %d", sensor.PokeInterval)
+	}
+	if sensor.Timeout < 0 {
+		return fmt.Errorf("Timeout must not be negative, found %d", sensor.Timeout)
+	}
+	required := map[string]struct {
+		name  string
+		value string
+	}{
+		FileSensor: {"Path", sensor.Path},
+		HTTPSensor: {"URL", sensor.URL},
+		SQLSensor:  {"Query", sensor.Query},
+		DAGSensor:  {"DAG", sensor.DAG},
+	}
+	field, ok := required[sensor.Type]
+	if !ok {
+		return fmt.Errorf(
+			"Type must be one of \"%s\", \"%s\", \"%s\" or \"%s\", found \"%s\"",
+			FileSensor,
+			HTTPSensor,
+			SQLSensor,
+			DAGSensor,
+			sensor.Type,
+		)
+	}
+	if field.value == "" {
+		return fmt.Errorf("%s sensors must set %s", sensor.Type, field.name)
+	}
+	if sensor.Type == SQLSensor && sensor.Driver != "" && !isRegisteredDriver(sensor.Driver) {
+		return fmt.Errorf(
+			"Driver must be one of \"%s\", found \"%s\"",
+			strings.Join(sql.Drivers(), "\", \""),
+			sensor.Driver,
+		)
+	}
+	if sensor.Type == DAGSensor && !validNameRegex.MatchString(sensor.DAG) {
+		return fmt.Errorf(
+			"DAG \"%s\" must match the pattern \"%s\"",
+			sensor.DAG,
+			validNameRegexString,
+		)
+	}
+	return nil
+}
+
+// validateDSN returns an error if the sensor sets both DSN and DSNEnv, or if DSNEnv does not name
+// one of the DAG's SecretEnv variables
+func (sensor SensorConfig) validateDSN(secretEnv map[string]SecretKeyRef) error {
+	if sensor.DSNEnv == "" {
+		return nil
+	}
+	if sensor.DSN != "" {
+		return fmt.Errorf("only one of DSN and DSNEnv may be set")
+	}
+	if _, ok := secretEnv[sensor.DSNEnv]; !ok {
+		return fmt.Errorf("DSNEnv %s must be a SecretEnv variable of the DAG", sensor.DSNEnv)
+	}
+	return nil
+}
+
+// ValidateSensors returns an error if any sensor task is not valid or also has a Command
+func (config *DAGConfig) ValidateSensors() error {
+	for _, task := range config.Tasks {
+		if task.Sensor == nil {
+			continue
+		}
+		if len(task.Command) != 0 || len(task.Args) != 0 {
+			return fmt.Errorf(
+				"sensor task \"%s\" in DAG %s must not have a Command or Args",
+				task.Name,
+				config.Name,
+			)
+		}
+		err := task.Sensor.validate()
+		if err == nil {
+			err = task.Sensor.validateDSN(config.SecretEnv)
+		}
+		if err != nil {
+			return fmt.Errorf(
+				"sensor task \"%s\" in DAG %s is invalid: %s",
+				task.Name,
+				config.Name,
+				err.Error(),
+			)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateSensors(t *testing.T) {
+	cases := []struct {
+		name        string
+		task        TaskConfig
+		expectError bool
+	}{
+		{"file", TaskConfig{Sensor: &SensorConfig{Type: FileSensor, Path: "/data/*.csv"}}, false},
+		{"http", TaskConfig{Sensor: &SensorConfig{Type: HTTPSensor, URL: "http://api"}}, false},
+		{"sql", TaskConfig{Sensor: &SensorConfig{Type: SQLSensor, Query: "SELECT 1"}}, false},
+		{"dag", TaskConfig{Sensor: &SensorConfig{Type: DAGSensor, DAG: "upstream"}}, false},
+		{
+			"sqlite driver",
+			TaskConfig{
+				Sensor: &SensorConfig{Type: SQLSensor, Driver: "sqlite3", Query: "SELECT 1"},
+			},
+			false,
+		},
+		{
+			"unknown driver",
+			TaskConfig{
+				Sensor: &SensorConfig{Type: SQLSensor, Driver: "postgres", Query: "SELECT 1"},
+			},
+			true,
+		},
+		{"invalid dag", TaskConfig{Sensor: &SensorConfig{Type: DAGSensor, DAG: "up'stream"}}, true},
+		{"unknown type", TaskConfig{Sensor: &SensorConfig{Type: "s3", Path: "a"}}, true},
+		{"missing field", TaskConfig{Sensor: &SensorConfig{Type: HTTPSensor}}, true},
+		{
+			"negative interval",
+			TaskConfig{Sensor: &SensorConfig{Type: FileSensor, Path: "a", PokeInterval: -1}},
+			true,
+		},
+		{
+			"dsn from secret",
+			TaskConfig{Sensor: &SensorConfig{Type: SQLSensor, DSNEnv: "DB_DSN", Query: "SELECT 1"}},
+			false,
+		},
+		{
+			"dsn from unknown variable",
+			TaskConfig{Sensor: &SensorConfig{Type: SQLSensor, DSNEnv: "DSN", Query: "SELECT 1"}},
+			true,
+		},
+		{
+			"dsn and dsn from secret",
+			TaskConfig{
+				Sensor: &SensorConfig{
+					Type:   SQLSensor,
+					DSN:    "file:test.db",
+					DSNEnv: "DB_DSN",
+					Query:  "SELECT 1",
+				},
+			},
+			true,
+		},
+		{
+			"with command",
+			TaskConfig{
+				Command: []string{"echo"},
+				Sensor:  &SensorConfig{Type: FileSensor, Path: "a"},
+			},
+			true,
+		},
+	}
+	for _, testCase := range cases {
+		testCase.task.Name = "sensor"
+		config := DAGConfig{
+			Name:      "test",
+			Tasks:     []TaskConfig{testCase.task},
+			SecretEnv: map[string]SecretKeyRef{"DB_DSN": {Secret: "db", Key: "dsn"}},
+		}
+		err := config.ValidateSensors()
+		if (err != nil) != testCase.expectError {
+			t.Errorf(
+				"Case %s: expected error %t, found %v",
+				testCase.name,
+				testCase.expectError,
+				err,
+			)
+		}
+	}
+}
+
+func TestSensorDurations(t *testing.T) {
+	sensor := SensorConfig{}
+	if sensor.PokeIntervalDuration() != time.Minute || sensor.TimeoutDuration() != 24*time.Hour {
+		t.Errorf(
+			"Expected default poke interval and timeout, found %s and %s",
+			sensor.PokeIntervalDuration(),
+			sensor.TimeoutDuration(),
+		)
+	}
+	sensor = SensorConfig{PokeInterval: 5, Timeout: 30}
+	if sensor.PokeIntervalDuration() != 5*time.Second ||
+		sensor.TimeoutDuration() != 30*time.Second {
+		t.Errorf(
+			"Expected poke interval 5s and timeout 30s, found %s and %s",
+			sensor.PokeIntervalDuration(),
+			sensor.TimeoutDuration(),
+		)
+	}
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"fmt"
+)
+
+// DefaultTaskName is the name given to the single task of a DAG that defines no Tasks
+const DefaultTaskName = "task"
+
+// TaskConfig is a struct storing the configurable values for a single task within a DAG
+type TaskConfig struct {
+	Name        string
+	DockerImage string
+	Command     []string
+	Args        []string
+	Env         map[string]string
+	DependsOn   []string
+	Sensor      *SensorConfig // Makes the task wait on a condition instead of running a pod
+	Produces    []string      // Datasets updated when the task succeeds
+}
+
+// Copy returns a copy of the TaskConfig
+func (task TaskConfig) Copy() TaskConfig {
+	taskCopy := task
+	taskCopy.Command = makeStrSliceCopy(task.Command)
+	taskCopy.Args = makeStrSliceCopy(task.Args)
+	taskCopy.Env = makeStrMapCopy(task.Env)
+	taskCopy.DependsOn = makeStrSliceCopy(task.DependsOn)
+	taskCopy.Produces = makeStrSliceCopy(task.Produces)
+	if task.Sensor != nil {
+		sensor := *task.Sensor
+		taskCopy.Sensor = &sensor
+	}
+	return taskCopy
+}
+
+func makeStrSliceCopy(src []string) []string {
+	if src == nil {
+		return nil
+	}
+	cpy := make([]string, len(src))
+	copy(cpy, src)
+	return cpy
+}
+
+// TaskConfigs returns the tasks of the DAG. A DAG without any Tasks is treated as having a
+// single task built from its DockerImage, Command, Args and Env. Tasks without a DockerImage
+// inherit the DAG's DockerImage, and every task inherits the DAG's Env unless it sets the same
+// variable itself.
+func (config *DAGConfig) TaskConfigs() []TaskConfig {
+	if len(config.Tasks) == 0 {
+		return []TaskConfig{
+			{
+				Name:        DefaultTaskName,
+				DockerImage: config.DockerImage,
+				Command:     config.Command,
+				Args:        config.Args,
+				Env:         config.Env,
+			},
+		}
+	}
+	tasks := make([]TaskConfig, 0, len(config.Tasks))
+	for _, task := range config.Tasks {
+		if task.DockerImage == "" {
+			task.DockerImage = config.DockerImage
+		}
+		task.Env = mergeEnv(config.Env, task.Env)
+		tasks = append(tasks, task)
+	}
+	return tasks
+}
+
+// mergeEnv returns the variables of both environments, preferring those of the override
+func mergeEnv(base map[string]string, override map[string]string) map[string]string {
+	if len(base) == 0 {
+		return override
+	}
+	merged := makeStrMapCopy(base)
+	for name, value := range override {
+		merged[name] = value
+	}
+	return merged
+}
+
+// ValidateTasks returns an error if the tasks have invalid or duplicate names, depend on tasks
+// that do not exist, or contain a dependency cycle
+func (config *DAGConfig) ValidateTasks() error {
+	taskMap := make(map[string]TaskConfig)
+	for _, task := range config.Tasks {
+		if !validNameRegex.MatchString(task.Name) {
+			return fmt.Errorf(
+				"task name \"%s\" in DAG %s must match the pattern \"%s\"",
+				task.Name,
+				config.Name,
+				validNameRegexString,
+			)
+		}
+		if _, ok := taskMap[task.Name]; ok {
+			return fmt.Errorf("DAG %s has more than one task named \"%s\"", config.Name, task.Name)
+		}
+		taskMap[task.Name] = task
+	}
+	for _, task := range config.Tasks {
+		for _, upstream := range task.DependsOn {
+			if _, ok := taskMap[upstream]; !ok {
+				return fmt.Errorf(
+					"task \"%s\" in DAG %s depends on missing task \"%s\"",
+					task.Name,
+					config.Name,
+					upstream,
+				)
+			}
+		}
+	}
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	states := make(map[string]int)
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		path = append(path, name)
+		switch states[name] {
+		case visiting:
+			return fmt.Errorf("DAG %s has a dependency cycle: %v", config.Name, path)
+		case visited:
+			return nil
+		}
+		states[name] = visiting
+		for _, upstream := range taskMap[name].DependsOn {
+			err := visit(upstream, path)
+			if err != nil {
+				return err
+			}
+		}
+		states[name] = visited
+		return nil
+	}
+	for _, task := range config.Tasks {
+		err := visit(task.Name, []string{})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTaskConfigsDefaultTask(t *testing.T) {
+	dagConfig := DAGConfig{
+		Name:        "test-config",
+		DockerImage: "busybox",
+		Command:     []string{"echo", "test"},
+	}
+	tasks := dagConfig.TaskConfigs()
+	if len(tasks) != 1 {
+		t.Fatalf("Expected 1 task, found %d", len(tasks))
+	}
+	if tasks[0].Name != DefaultTaskName || tasks[0].DockerImage != dagConfig.DockerImage {
+		t.Errorf("Expected default task built from DAG config, found %v", tasks[0])
+	}
+}
+
+func TestTaskConfigsInheritImage(t *testing.T) {
+	dagConfig := DAGConfig{
+		Name:        "test-config",
+		DockerImage: "busybox",
+		Tasks: []TaskConfig{
+			{Name: "first"},
+			{Name: "second", DockerImage: "alpine"},
+		},
+	}
+	tasks := dagConfig.TaskConfigs()
+	if tasks[0].DockerImage != "busybox" {
+		t.Errorf("Expected task to inherit image busybox, found %s", tasks[0].DockerImage)
+	}
+	if tasks[1].DockerImage != "alpine" {
+		t.Errorf("Expected task to keep image alpine, found %s", tasks[1].DockerImage)
+	}
+}
+
+func TestTaskConfigsInheritEnv(t *testing.T) {
+	dagConfig := DAGConfig{
+		Name: "test-config",
+		Env:  map[string]string{"REGION": "us", "TABLE": "users"},
+		Tasks: []TaskConfig{
+			{Name: "first"},
+			{Name: "second", Env: map[string]string{"TABLE": "orders"}},
+		},
+	}
+	tasks := dagConfig.TaskConfigs()
+	if tasks[0].Env["TABLE"] != "users" || tasks[1].Env["TABLE"] != "orders" {
+		t.Errorf(
+			"Expected tasks to override the DAG's Env, found %v and %v",
+			tasks[0].Env,
+			tasks[1].Env,
+		)
+	}
+	if tasks[1].Env["REGION"] != "us" {
+		t.Errorf("Expected task to inherit REGION from the DAG's Env, found %v", tasks[1].Env)
+	}
+	if dagConfig.Env["TABLE"] != "users" {
+		t.Error("Merging task Env should not change the DAG's Env")
+	}
+}
+
+func TestValidateTasks(t *testing.T) {
+	cases := []struct {
+		name          string
+		tasks         []TaskConfig
+		expectedError string
+	}{
+		{"No tasks", nil, ""},
+		{
+			"Valid graph",
+			[]TaskConfig{
+				{Name: "first"},
+				{Name: "second", DependsOn: []string{"first"}},
+				{Name: "third", DependsOn: []string{"first", "second"}},
+			},
+			"",
+		},
+		{"Invalid name", []TaskConfig{{Name: "bad name"}}, "must match the pattern"},
+		{"Duplicate name", []TaskConfig{{Name: "first"}, {Name: "first"}}, "more than one task"},
+		{
+			"Missing dependency",
+			[]TaskConfig{{Name: "first", DependsOn: []string{"second"}}},
+			"depends on missing task",
+		},
+		{
+			"Self cycle",
+			[]TaskConfig{{Name: "first", DependsOn: []string{"first"}}},
+			"dependency cycle",
+		},
+		{
+			"Cycle",
+			[]TaskConfig{
+				{Name: "first", DependsOn: []string{"third"}},
+				{Name: "second", DependsOn: []string{"first"}},
+				{Name: "third", DependsOn: []string{"second"}},
+			},
+			"dependency cycle",
+		},
+	}
+	for _, testCase := range cases {
+		dagConfig := DAGConfig{Name: "test-config", Tasks: testCase.tasks}
+		err := dagConfig.ValidateTasks()
+		switch {
+		case testCase.expectedError == "" && err != nil:
+			t.Errorf("%s: expected no error, found %s", testCase.name, err)
+		case testCase.expectedError != "" && err == nil:
+			t.Errorf("%s: expected error containing \"%s\"", testCase.name, testCase.expectedError)
+		case err != nil && !strings.Contains(err.Error(), testCase.expectedError):
+			t.Errorf(
+				"%s: expected error containing \"%s\", found %s",
+				testCase.name,
+				testCase.expectedError,
+				err,
+			)
+		}
+	}
+}
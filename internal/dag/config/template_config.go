@@ -0,0 +1,33 @@
+package config
+
+import (
+	"fmt"
+	"goflow/internal/dag/templating"
+)
+
+// ValidateTemplates returns an error if the Command, Args or Env of any task, or the templated
+// fields of any sensor, is not a valid template
+func (config *DAGConfig) ValidateTemplates() error {
+	for _, task := range config.TaskConfigs() {
+		texts := append(append([]string{}, task.Command...), task.Args...)
+		for _, value := range task.Env {
+			texts = append(texts, value)
+		}
+		if task.Sensor != nil {
+			texts = append(texts, task.Sensor.Templates()...)
+		}
+		for _, text := range texts {
+			_, err := templating.Parse(text)
+			if err != nil {
+				return fmt.Errorf(
+					"task \"%s\" in DAG %s has an invalid template \"%s\": %s",
+					task.Name,
+					config.Name,
+					text,
+					err.Error(),
+				)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package config
+
+import "testing"
+
+func TestValidateTemplates(t *testing.T) {
+	cases := []struct {
+		name      string
+		config    DAGConfig
+		expectErr bool
+	}{
+		{
+			"Valid",
+			DAGConfig{
+				Command: []string{"echo", `{{ .ExecutionDate | date "2006-01-02" }}`},
+				Args:    []string{"--dag={{ .DAGName }}"},
+				Env:     map[string]string{"ATTEMPT": "{{ .Attempt }}"},
+			},
+			false,
+		},
+		{"Invalid Command", DAGConfig{Command: []string{"echo", "{{ .ExecutionDate"}}, true},
+		{"Invalid Env", DAGConfig{Env: map[string]string{"DATE": "{{ date }"}}, true},
+		{
+			"Invalid Task Args",
+			DAGConfig{Tasks: []TaskConfig{{Name: "first", Args: []string{"{{ unknown }}"}}}},
+			true,
+		},
+	}
+	for _, testCase := range cases {
+		err := testCase.config.ValidateTemplates()
+		if (err != nil) != testCase.expectErr {
+			t.Errorf("%s: expected error %t, found %v", testCase.name, testCase.expectErr, err)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"goflow/internal/dateutils"
+	"time"
+)
+
+// Location returns the time zone named by the DAG's Timezone, which is UTC by default
+func (config *DAGConfig) Location() (*time.Location, error) {
+	if config.Timezone == "" {
+		return time.UTC, nil
+	}
+	location, err := time.LoadLocation(config.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"DAG %s has an unknown Timezone \"%s\": %s",
+			config.Name,
+			config.Timezone,
+			err.Error(),
+		)
+	}
+	return location, nil
+}
+
+// ValidateTimezone returns an error if the DAG's Timezone is not a known IANA time zone name
+func (config *DAGConfig) ValidateTimezone() error {
+	_, err := config.Location()
+	return err
+}
+
+// ValidateDates returns an error if the DAG's StartDateTime is missing, if either of its dates
+// cannot be read in its time zone, or if its EndDateTime is not after its StartDateTime
+func (config *DAGConfig) ValidateDates() error {
+	location, err := config.Location()
+	if err != nil {
+		return err
+	}
+	if config.StartDateTime == "" {
+		return fmt.Errorf("DAG %s must have a StartDateTime", config.Name)
+	}
+	start, err := dateutils.ParseDateTime(config.StartDateTime, location)
+	if err != nil {
+		return fmt.Errorf("DAG %s has an invalid StartDateTime: %s", config.Name, err)
+	}
+	if config.EndDateTime == "" {
+		return nil
+	}
+	end, err := dateutils.ParseDateTime(config.EndDateTime, location)
+	if err != nil {
+		return fmt.Errorf("DAG %s has an invalid EndDateTime: %s", config.Name, err)
+	}
+	if !end.After(start) {
+		return fmt.Errorf(
+			"DAG %s must have an EndDateTime after its StartDateTime, found %s and %s",
+			config.Name,
+			config.EndDateTime,
+			config.StartDateTime,
+		)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLocation(t *testing.T) {
+	location, err := (&DAGConfig{}).Location()
+	if err != nil || location != time.UTC {
+		t.Errorf("Expected a DAG without a Timezone to be in UTC, found %v, %v", location, err)
+	}
+	location, err = (&DAGConfig{Timezone: "Europe/Berlin"}).Location()
+	if err != nil || location.String() != "Europe/Berlin" {
+		t.Errorf("Expected the Europe/Berlin time zone, found %v, %v", location, err)
+	}
+	err = (&DAGConfig{Name: "test", Timezone: "Mars/Olympus_Mons"}).ValidateTimezone()
+	if err == nil {
+		t.Error("Expected an error for an unknown time zone")
+	}
+}
+
+func TestValidateDates(t *testing.T) {
+	cases := []struct {
+		start       string
+		end         string
+		expectError bool
+	}{
+		{"2019-01-01", "", false},
+		{"2019-01-01", "2019-01-01T12:00:00", false},
+		{"", "", true},
+		{"01/01/2019", "", true},
+		{"2019-01-01", "2019-01-01", true},
+		{"2019-01-02", "2019-01-01", true},
+	}
+	for _, testCase := range cases {
+		config := DAGConfig{Name: "test", StartDateTime: testCase.start, EndDateTime: testCase.end}
+		err := config.ValidateDates()
+		if (err != nil) != testCase.expectError {
+			t.Errorf(
+				"Expected error %t for dates %s and %s, found %v",
+				testCase.expectError,
+				testCase.start,
+				testCase.end,
+				err,
+			)
+		}
+	}
+}
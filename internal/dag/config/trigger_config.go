@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"goflow/internal/stringutils"
+)
+
+// TriggerConfig names an upstream DAG, or a dataset produced by another DAG, whose successful
+// run for an execution date triggers a run of the DAG for the same execution date
+type TriggerConfig struct {
+	DAG     string
+	Dataset string
+}
+
+// IsTriggered returns true if the DAG is run by its upstream DAGs and datasets rather than on its
+// schedule
+func (config *DAGConfig) IsTriggered() bool {
+	return len(config.TriggeredBy) != 0
+}
+
+// TriggersOn returns true if a successful run of the named DAG, which produced the given datasets,
+// is one of the DAG's triggers
+func (config *DAGConfig) TriggersOn(dagName string, datasets []string) bool {
+	datasetSet := stringutils.NewStringSet(datasets)
+	for _, trigger := range config.TriggeredBy {
+		if trigger.DAG != "" && trigger.DAG == dagName {
+			return true
+		}
+		if trigger.Dataset != "" && datasetSet.Contains(trigger.Dataset) {
+			return true
+		}
+	}
+	return false
+}
+
+// Datasets returns every dataset that the DAG or any of its tasks produce
+func (config *DAGConfig) Datasets() []string {
+	datasets := make([]string, 0, len(config.Produces))
+	datasetSet := stringutils.NewStringSet(nil)
+	for _, task := range append([]TaskConfig{{Produces: config.Produces}}, config.Tasks...) {
+		for _, dataset := range task.Produces {
+			if !datasetSet.Contains(dataset) {
+				datasetSet.Add(dataset)
+				datasets = append(datasets, dataset)
+			}
+		}
+	}
+	return datasets
+}
+
+// ValidateTriggers returns an error if a trigger does not name exactly one of an upstream DAG or a
+// dataset, if an upstream DAG's name is not valid, or if the DAG is triggered by itself
+func (config *DAGConfig) ValidateTriggers() error {
+	for _, trigger := range config.TriggeredBy {
+		if (trigger.DAG == "") == (trigger.Dataset == "") {
+			return fmt.Errorf(
+				"each trigger of DAG %s must set exactly one of DAG or Dataset",
+				config.Name,
+			)
+		}
+		if trigger.DAG != "" && !validNameRegex.MatchString(trigger.DAG) {
+			return fmt.Errorf(
+				"upstream DAG \"%s\" of DAG %s must match the pattern \"%s\"",
+				trigger.DAG,
+				config.Name,
+				validNameRegexString,
+			)
+		}
+		if trigger.DAG == config.Name {
+			return fmt.Errorf("DAG %s cannot be triggered by itself", config.Name)
+		}
+	}
+	for _, dataset := range config.Datasets() {
+		if dataset == "" {
+			return fmt.Errorf("DAG %s produces a dataset with an empty name", config.Name)
+		}
+	}
+	return nil
+}
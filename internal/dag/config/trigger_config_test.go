@@ -0,0 +1,92 @@
+package config
+
+import "testing"
+
+func TestValidateTriggers(t *testing.T) {
+	cases := []struct {
+		name        string
+		config      DAGConfig
+		expectError bool
+	}{
+		{"no triggers", DAGConfig{Name: "test"}, false},
+		{
+			"dag and dataset triggers",
+			DAGConfig{
+				Name:        "test",
+				TriggeredBy: []TriggerConfig{{DAG: "upstream"}, {Dataset: "warehouse.users"}},
+			},
+			false,
+		},
+		{"empty trigger", DAGConfig{Name: "test", TriggeredBy: []TriggerConfig{{}}}, true},
+		{
+			"trigger with both",
+			DAGConfig{
+				Name:        "test",
+				TriggeredBy: []TriggerConfig{{DAG: "upstream", Dataset: "warehouse.users"}},
+			},
+			true,
+		},
+		{
+			"invalid upstream name",
+			DAGConfig{Name: "test", TriggeredBy: []TriggerConfig{{DAG: "up' OR '1'='1"}}},
+			true,
+		},
+		{
+			"self trigger",
+			DAGConfig{Name: "test", TriggeredBy: []TriggerConfig{{DAG: "test"}}},
+			true,
+		},
+		{
+			"empty dataset",
+			DAGConfig{Name: "test", Tasks: []TaskConfig{{Name: "load", Produces: []string{""}}}},
+			true,
+		},
+	}
+	for _, testCase := range cases {
+		err := testCase.config.ValidateTriggers()
+		if (err != nil) != testCase.expectError {
+			t.Errorf(
+				"Case %s: expected error %t, found %v",
+				testCase.name,
+				testCase.expectError,
+				err,
+			)
+		}
+	}
+}
+
+func TestTriggersOn(t *testing.T) {
+	config := DAGConfig{
+		Name:        "test",
+		TriggeredBy: []TriggerConfig{{DAG: "upstream"}, {Dataset: "warehouse.users"}},
+	}
+	if !config.TriggersOn("upstream", nil) {
+		t.Error("Expected the DAG to be triggered by its upstream DAG")
+	}
+	if !config.TriggersOn("other", []string{"warehouse.users"}) {
+		t.Error("Expected the DAG to be triggered by its upstream dataset")
+	}
+	if config.TriggersOn("other", []string{"warehouse.orders"}) {
+		t.Error("Expected the DAG not to be triggered by an unrelated DAG and dataset")
+	}
+}
+
+func TestDatasets(t *testing.T) {
+	config := DAGConfig{
+		Produces: []string{"users"},
+		Tasks: []TaskConfig{
+			{Name: "first", Produces: []string{"orders", "users"}},
+			{Name: "second", Produces: []string{"items"}},
+		},
+	}
+	datasets := config.Datasets()
+	expected := []string{"users", "orders", "items"}
+	if len(datasets) != len(expected) {
+		t.Fatalf("Expected datasets %v, found %v", expected, datasets)
+	}
+	for i := range expected {
+		if datasets[i] != expected[i] {
+			t.Errorf("Expected datasets %v, found %v", expected, datasets)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YAMLDocument is the DAG config read from one document of a YAML DAG file
+type YAMLDocument struct {
+	Config DAGConfig
+	Code   string // The text of the document, along with its comments
+	Line   int    // The line of the file that the document starts on
+}
+
+// ParseYAML reads the DAG config of each document of a YAML DAG file. The keys of each document
+// are the same as those of a JSON DAG file, and errors give the line of the file that they were
+// found on.
+func ParseYAML(data []byte) ([]YAMLDocument, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	documents := make([]YAMLDocument, 0)
+	for {
+		node := yaml.Node{}
+		err := decoder.Decode(&node)
+		if err == io.EOF {
+			return documents, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(node.Content) == 0 || node.Content[0].Tag == "!!null" {
+			continue
+		}
+		document, err := parseYAMLDocument(&node)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, document)
+	}
+}
+
+// parseYAMLDocument reads the DAG config of a YAML document node by converting it to JSON
+func parseYAMLDocument(node *yaml.Node) (YAMLDocument, error) {
+	root := node.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return YAMLDocument{}, fmt.Errorf(
+			"yaml: line %d: a DAG must be a mapping of keys",
+			root.Line,
+		)
+	}
+	var value interface{}
+	err := node.Decode(&value)
+	if err != nil {
+		return YAMLDocument{}, err
+	}
+	jsonBytes, err := json.Marshal(value)
+	if err != nil {
+		return YAMLDocument{}, fmt.Errorf("yaml: line %d: %s", root.Line, err)
+	}
+	config := DAGConfig{}
+	err = Unmarshal(jsonBytes, &config)
+	if field, unknown := UnknownField(err); unknown {
+		line := root.Line
+		if key := findKeyNamed(root, field); key != nil {
+			line = key.Line
+		}
+		return YAMLDocument{}, fmt.Errorf("yaml: line %d: unknown field %s", line, field)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return YAMLDocument{}, fmt.Errorf(
+			"yaml: line %d: cannot use %s as %s for %s",
+			fieldLine(root, typeErr.Field),
+			typeErr.Value,
+			typeErr.Type,
+			typeErr.Field,
+		)
+	}
+	if err != nil {
+		return YAMLDocument{}, fmt.Errorf("yaml: line %d: %s", root.Line, err)
+	}
+	code, err := yaml.Marshal(node)
+	if err != nil {
+		return YAMLDocument{}, err
+	}
+	return YAMLDocument{Config: config, Code: string(code), Line: root.Line}, nil
+}
+
+// fieldLine returns the line of the key for the field at the dotted path within the node, or the
+// line of the node itself if there is no such key
+func fieldLine(node *yaml.Node, fieldPath string) int {
+	if key := findKey(node, strings.Split(fieldPath, ".")); key != nil {
+		return key.Line
+	}
+	return node.Line
+}
+
+// findKey returns the first key for the field at the path within the node, searching each item of
+// the sequences along the path that the path does not give the index of. Keys are matched
+// regardless of case, as they are in JSON DAG files.
+func findKey(node *yaml.Node, path []string) *yaml.Node {
+	if len(path) == 0 {
+		return nil
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if !strings.EqualFold(key.Value, path[0]) {
+				continue
+			}
+			if len(path) == 1 {
+				return key
+			}
+			if found := findKey(value, path[1:]); found != nil {
+				return found
+			}
+		}
+	case yaml.SequenceNode:
+		if index, err := strconv.Atoi(path[0]); err == nil {
+			if index < 0 || index >= len(node.Content) {
+				return nil
+			}
+			if len(path) == 1 {
+				return node.Content[index]
+			}
+			return findKey(node.Content[index], path[1:])
+		}
+		for _, item := range node.Content {
+			if found := findKey(item, path); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+// findKeyNamed returns the first key with the given name anywhere within the node
+func findKeyNamed(node *yaml.Node, name string) *yaml.Node {
+	for i, child := range node.Content {
+		isKey := node.Kind == yaml.MappingNode && i%2 == 0
+		if isKey && child.Value == name {
+			return child
+		}
+		if found := findKeyNamed(child, name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
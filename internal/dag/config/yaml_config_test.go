@@ -0,0 +1,83 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+const multiDocumentYAML = `# Loads the warehouse
+Name: extract
+Schedule: "0 0 2 * * *"
+Command: [echo, extract]
+StartDateTime: "2019-01-01"
+---
+---
+# Reports on the warehouse once it is loaded
+Name: report
+TriggeredBy:
+  - DAG: extract
+Tasks:
+  - Name: summarize
+    Command: [echo, summarize]
+  - Name: publish
+    DependsOn: [summarize]
+`
+
+func TestParseYAML(t *testing.T) {
+	documents, err := ParseYAML([]byte(multiDocumentYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(documents) != 2 {
+		t.Fatalf("Expected 2 documents, found %d", len(documents))
+	}
+	extract, report := documents[0], documents[1]
+	if extract.Config.Name != "extract" || extract.Config.Schedule != "0 0 2 * * *" {
+		t.Errorf("Expected the extract DAG to be read, found %s", extract.Config.JSON())
+	}
+	if extract.Line != 2 || report.Line != 9 {
+		t.Errorf("Expected documents on lines 2 and 9, found %d and %d", extract.Line, report.Line)
+	}
+	if !strings.Contains(report.Code, "# Reports on the warehouse") {
+		t.Errorf("Expected the document's code to keep its comments, found %s", report.Code)
+	}
+	if len(report.Config.Tasks) != 2 || report.Config.Tasks[1].DependsOn[0] != "summarize" {
+		t.Errorf("Expected the tasks of the report DAG to be read, found %v", report.Config.Tasks)
+	}
+	if report.Config.TriggeredBy[0].DAG != "extract" {
+		t.Errorf("Expected the report DAG to be triggered by extract")
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	cases := []struct {
+		name         string
+		yaml         string
+		expectedLine string
+	}{
+		{"bad indentation", "Name: test\n  Schedule: \"* * * * * *\"\n", "line 2"},
+		{"not a mapping", "Name: test\n---\n- echo\n", "line 3"},
+		{"wrong type", "Name: test\nCommand: [echo]\nMaxActiveRuns: many\n", "line 3"},
+		{
+			"wrong type in task",
+			"Name: test\nTasks:\n  - Name: first\n  - Name: second\n    DependsOn: first\n",
+			"line 5",
+		},
+		{
+			"unknown field in task",
+			"Name: test\nTasks:\n  - Name: first\n    Comand: [echo]\n",
+			"line 4: unknown field Comand",
+		},
+	}
+	for _, testCase := range cases {
+		_, err := ParseYAML([]byte(testCase.yaml))
+		if err == nil || !strings.Contains(err.Error(), testCase.expectedLine) {
+			t.Errorf(
+				"Case %s: expected an error on %s, found %v",
+				testCase.name,
+				testCase.expectedLine,
+				err,
+			)
+		}
+	}
+}
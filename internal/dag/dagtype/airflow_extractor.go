@@ -0,0 +1,497 @@
+package dagtype
+
+// airflowExtractor is the Python program that reads the DAGs of an Airflow DAG file from standard
+// input and writes their goflow configs to standard output as JSON. The file is parsed rather than
+// run, and only the constructs that the program knows how to evaluate are accepted, so the DAG
+// file's code is never executed. Anything else is reported along with the line it was found on.
+const airflowExtractor = `import ast
+import datetime
+import json
+import re
+import sys
+
+KUBERNETES_POD_OPERATOR = "KubernetesPodOperator"
+BASH_OPERATOR = "BashOperator"
+
+# The names that each supported module provides
+MODULES = {
+    "airflow": {"DAG": "DAG"},
+    "airflow.models": {"DAG": "DAG"},
+    "airflow.models.dag": {"DAG": "DAG"},
+    "airflow.models.baseoperator": {"chain": "chain"},
+    "airflow.utils.helpers": {"chain": "chain"},
+    "airflow.operators.bash": {BASH_OPERATOR: BASH_OPERATOR},
+    "airflow.operators.bash_operator": {BASH_OPERATOR: BASH_OPERATOR},
+    "airflow.providers.cncf.kubernetes.operators.kubernetes_pod": {
+        KUBERNETES_POD_OPERATOR: KUBERNETES_POD_OPERATOR,
+    },
+    "airflow.providers.cncf.kubernetes.operators.pod": {
+        KUBERNETES_POD_OPERATOR: KUBERNETES_POD_OPERATOR,
+    },
+    "airflow.contrib.operators.kubernetes_pod_operator": {
+        KUBERNETES_POD_OPERATOR: KUBERNETES_POD_OPERATOR,
+    },
+    "datetime": {"datetime": "datetime", "timedelta": "timedelta"},
+    "pendulum": {"datetime": "pendulum.datetime", "duration": "timedelta"},
+}
+
+# Arguments that do not change how goflow runs a DAG or task
+IGNORED_DAG_ARGS = {"description", "tags", "doc_md", "owner_links"}
+IGNORED_DEFAULT_ARGS = {"owner", "email", "email_on_failure", "email_on_retry"}
+IGNORED_TASK_ARGS = {
+    "owner", "doc_md", "email", "email_on_failure", "email_on_retry", "name",
+    "is_delete_operator_pod", "in_cluster", "startup_timeout_seconds",
+}
+
+# Airflow template variables and the goflow templates that render the same value
+TEMPLATES = {
+    "ds": '{{ .ExecutionDate | date "2006-01-02" }}',
+    "ds_nodash": '{{ .ExecutionDate | date "20060102" }}',
+    "ts": '{{ .ExecutionDate | date "2006-01-02T15:04:05Z07:00" }}',
+    "data_interval_start": '{{ .DataIntervalStart | date "2006-01-02T15:04:05Z07:00" }}',
+    "data_interval_end": '{{ .DataIntervalEnd | date "2006-01-02T15:04:05Z07:00" }}',
+    "run_id": "{{ .RunID }}",
+    "dag.dag_id": "{{ .DAGName }}",
+    "task.task_id": "{{ .TaskName }}",
+}
+TEMPLATE_PATTERN = re.compile(r"{{(.*?)}}")
+
+
+class Unsupported(Exception):
+    """A construct of the DAG file that goflow cannot load"""
+
+    def __init__(self, node, message):
+        Exception.__init__(self, message)
+        self.line = getattr(node, "lineno", 0)
+        self.message = message
+
+
+class Module(object):
+    def __init__(self, name):
+        self.name = name
+
+
+class Function(object):
+    def __init__(self, name):
+        self.name = name
+
+
+class Date(object):
+    def __init__(self, value, timezone):
+        self.value = value
+        self.timezone = timezone
+
+
+class DAG(object):
+    def __init__(self, node, dag_id):
+        self.line = node.lineno
+        self.config = {"Name": dag_id, "Schedule": "@daily", "Tasks": []}
+        self.timezone = None
+        self.retries = None
+        self.tasks = []
+
+
+class Task(object):
+    def __init__(self, node, dag, task_id):
+        self.node = node
+        self.dag = dag
+        self.config = {"Name": task_id}
+        self.depends_on = []
+
+    def depend_on(self, node, upstream):
+        if upstream.dag is not self.dag:
+            raise Unsupported(node, "tasks of different DAGs cannot depend on each other")
+        if upstream.config["Name"] not in self.depends_on:
+            self.depends_on.append(upstream.config["Name"])
+
+
+def translate_templates(node, text):
+    """Replaces the Airflow templates in the text with goflow templates"""
+
+    def replace(match):
+        variable = match.group(1).strip()
+        if variable not in TEMPLATES:
+            raise Unsupported(node, "template {{ %s }} is not supported" % variable)
+        return TEMPLATES[variable]
+
+    return TEMPLATE_PATTERN.sub(replace, text)
+
+
+def expect(node, value, kind, name):
+    if not isinstance(value, kind):
+        raise Unsupported(node, "%s must be a %s" % (name, kind_name(kind)))
+    return value
+
+
+def kind_name(kind):
+    if isinstance(kind, tuple):
+        return " or ".join(kind_name(k) for k in kind)
+    return {str: "string", int: "number", bool: "boolean", dict: "dict", list: "list"}.get(
+        kind, getattr(kind, "__name__", str(kind))
+    )
+
+
+def string_map(node, value, name):
+    expect(node, value, dict, name)
+    result = {}
+    for key, item in value.items():
+        result[expect(node, key, str, name + " key")] = translate_templates(
+            node, expect(node, item, str, name + " value")
+        )
+    return result
+
+
+def string_list(node, value, name):
+    if isinstance(value, tuple):
+        value = list(value)
+    expect(node, value, list, name)
+    return [translate_templates(node, expect(node, item, str, name + " item")) for item in value]
+
+
+def format_date(node, value, name):
+    if isinstance(value, datetime.datetime):
+        value = Date(value, None)
+    expect(node, value, Date, name)
+    return value.value.strftime("%Y-%m-%dT%H:%M:%S")
+
+
+def seconds(node, value, name):
+    expect(node, value, datetime.timedelta, name)
+    return int(value.total_seconds())
+
+
+def schedule(node, value):
+    if value is None:
+        return ""
+    if isinstance(value, datetime.timedelta):
+        return "every %ds" % seconds(node, value, "schedule_interval")
+    expect(node, value, str, "schedule_interval")
+    if value.startswith("@"):
+        return value
+    if len(value.split()) != 5:
+        raise Unsupported(node, "schedule_interval \"%s\" is not a cron expression" % value)
+    return "0 " + value
+
+
+class Extractor(object):
+    """Reads the DAGs of an Airflow DAG file without running it, by evaluating the supported
+    subset of Python that declares them"""
+
+    def __init__(self):
+        self.names = {}
+        self.dags = []
+        self.dag_stack = []
+
+    def run(self, source):
+        try:
+            tree = ast.parse(source)
+        except SyntaxError as err:
+            raise Unsupported(err, "invalid syntax: %s" % err.msg)
+        for statement in tree.body:
+            self.statement(statement)
+
+    # Statements
+
+    def statement(self, node):
+        if isinstance(node, ast.Import):
+            for alias in node.names:
+                self.check_module(node, alias.name)
+                if alias.asname:
+                    self.names[alias.asname] = Module(alias.name)
+                else:
+                    root = alias.name.split(".")[0]
+                    self.names[root] = Module(root)
+        elif isinstance(node, ast.ImportFrom):
+            if node.level:
+                raise Unsupported(node, "relative imports are not supported")
+            self.check_module(node, node.module)
+            for alias in node.names:
+                full_name = node.module + "." + alias.name
+                if self.is_module(full_name):
+                    self.names[alias.asname or alias.name] = Module(full_name)
+                    continue
+                provided = MODULES.get(node.module, {}).get(alias.name)
+                if provided is None:
+                    raise Unsupported(
+                        node, "%s from %s is not supported" % (alias.name, node.module)
+                    )
+                self.names[alias.asname or alias.name] = Function(provided)
+        elif isinstance(node, ast.Assign):
+            value = self.expression(node.value)
+            for target in node.targets:
+                if not isinstance(target, ast.Name):
+                    raise Unsupported(node, "only assignments to names are supported")
+                self.names[target.id] = value
+        elif isinstance(node, ast.With):
+            self.with_statement(node)
+        elif isinstance(node, ast.Expr):
+            if isinstance(node.value, ast.Constant) and isinstance(node.value.value, str):
+                return
+            self.expression(node.value)
+        elif isinstance(node, ast.If) and self.is_main_check(node.test):
+            return
+        elif isinstance(node, ast.Pass):
+            return
+        else:
+            raise Unsupported(node, "%s statements are not supported" % type(node).__name__)
+
+    def check_module(self, node, module):
+        if not self.is_module(module):
+            raise Unsupported(node, "import of %s is not supported" % module)
+
+    def is_module(self, module):
+        return module in MODULES or any(name.startswith(module + ".") for name in MODULES)
+
+    def is_main_check(self, test):
+        return (
+            isinstance(test, ast.Compare)
+            and isinstance(test.left, ast.Name)
+            and test.left.id == "__name__"
+        )
+
+    def with_statement(self, node):
+        dags = []
+        for item in node.items:
+            value = self.expression(item.context_expr)
+            if not isinstance(value, DAG):
+                raise Unsupported(node, "with statements are only supported for DAGs")
+            if item.optional_vars is not None:
+                if not isinstance(item.optional_vars, ast.Name):
+                    raise Unsupported(node, "only assignments to names are supported")
+                self.names[item.optional_vars.id] = value
+            dags.append(value)
+        self.dag_stack.extend(dags)
+        for statement in node.body:
+            self.statement(statement)
+        del self.dag_stack[len(self.dag_stack) - len(dags):]
+
+    # Expressions
+
+    def expression(self, node):
+        if isinstance(node, ast.Constant):
+            return node.value
+        if isinstance(node, ast.Name):
+            if node.id not in self.names:
+                raise Unsupported(node, "name %s is not defined" % node.id)
+            return self.names[node.id]
+        if isinstance(node, (ast.List, ast.Tuple)):
+            return [self.expression(item) for item in node.elts]
+        if isinstance(node, ast.Dict):
+            if any(key is None for key in node.keys):
+                raise Unsupported(node, "** in dicts is not supported")
+            return {
+                self.expression(key): self.expression(value)
+                for key, value in zip(node.keys, node.values)
+            }
+        if isinstance(node, ast.Attribute):
+            value = self.expression(node.value)
+            if isinstance(value, Module):
+                full_name = value.name + "." + node.attr
+                if self.is_module(full_name):
+                    return Module(full_name)
+                provided = MODULES.get(value.name, {}).get(node.attr)
+                if provided is not None:
+                    return Function(provided)
+            raise Unsupported(node, "attribute %s is not supported" % node.attr)
+        if isinstance(node, ast.BinOp):
+            return self.binary_operation(node)
+        if isinstance(node, ast.Call):
+            return self.call(node)
+        raise Unsupported(node, "%s expressions are not supported" % type(node).__name__)
+
+    def binary_operation(self, node):
+        left, right = self.expression(node.left), self.expression(node.right)
+        if isinstance(node.op, ast.RShift):
+            self.set_dependencies(node, left, right)
+            return right
+        if isinstance(node.op, ast.LShift):
+            self.set_dependencies(node, right, left)
+            return right
+        if isinstance(node.op, ast.Add) and type(left) == type(right) and \
+                isinstance(left, (str, list, int)):
+            return left + right
+        if isinstance(node.op, ast.Mult) and isinstance(left, datetime.timedelta) and \
+                isinstance(right, int):
+            return left * right
+        raise Unsupported(node, "%s operations are not supported" % type(node.op).__name__)
+
+    def set_dependencies(self, node, upstream, downstream):
+        upstream_tasks = upstream if isinstance(upstream, list) else [upstream]
+        downstream_tasks = downstream if isinstance(downstream, list) else [downstream]
+        for task in upstream_tasks + downstream_tasks:
+            if not isinstance(task, Task):
+                raise Unsupported(node, ">> and << are only supported between tasks")
+        for downstream_task in downstream_tasks:
+            for upstream_task in upstream_tasks:
+                downstream_task.depend_on(node, upstream_task)
+
+    def call(self, node):
+        function = self.expression(node.func)
+        if not isinstance(function, Function):
+            raise Unsupported(node, "only calls of DAG, operators, datetime and timedelta "
+                                    "are supported")
+        args = [self.expression(arg) for arg in node.args]
+        kwargs = {}
+        for keyword in node.keywords:
+            if keyword.arg is None:
+                raise Unsupported(node, "** arguments are not supported")
+            kwargs[keyword.arg] = self.expression(keyword.value)
+        try:
+            return self.call_function(node, function, args, kwargs)
+        except (TypeError, ValueError, OverflowError) as err:
+            raise Unsupported(node, str(err))
+
+    def call_function(self, node, function, args, kwargs):
+        if function.name == "DAG":
+            return self.dag(node, args, kwargs)
+        if function.name in (BASH_OPERATOR, KUBERNETES_POD_OPERATOR):
+            return self.task(node, function.name, args, kwargs)
+        if function.name == "chain":
+            for upstream, downstream in zip(args, args[1:]):
+                self.set_dependencies(node, upstream, downstream)
+            return None
+        if function.name == "timedelta":
+            return datetime.timedelta(*args, **kwargs)
+        if function.name == "datetime":
+            if "tzinfo" in kwargs or len(args) > 7:
+                raise Unsupported(node, "tzinfo is not supported, use pendulum.datetime with tz")
+            return Date(datetime.datetime(*args, **kwargs), None)
+        if function.name == "pendulum.datetime":
+            timezone = kwargs.pop("tz", "UTC")
+            return Date(datetime.datetime(*args, **kwargs), expect(node, timezone, str, "tz"))
+        raise Unsupported(node, "%s is not supported" % function.name)
+
+    def dag(self, node, args, kwargs):
+        if args:
+            kwargs["dag_id"] = args[0]
+        if "dag_id" not in kwargs:
+            raise Unsupported(node, "DAG must have a dag_id")
+        dag = DAG(node, expect(node, kwargs.pop("dag_id"), str, "dag_id"))
+        default_args = expect(node, kwargs.pop("default_args", {}), dict, "default_args")
+        for name, value in default_args.items():
+            if name in IGNORED_DEFAULT_ARGS:
+                continue
+            if name == "depends_on_past" and value is False:
+                continue
+            if name in ("start_date", "end_date", "retries", "retry_delay"):
+                kwargs.setdefault(name, value)
+                continue
+            raise Unsupported(node, "default_args %s is not supported" % name)
+        for name, value in kwargs.items():
+            if name in IGNORED_DAG_ARGS:
+                continue
+            if name in ("schedule_interval", "schedule"):
+                dag.config["Schedule"] = schedule(node, value)
+            elif name in ("start_date", "end_date"):
+                key = "StartDateTime" if name == "start_date" else "EndDateTime"
+                dag.config[key] = format_date(node, value, name)
+                self.set_timezone(node, dag, value)
+            elif name == "catchup":
+                dag.config["Catchup"] = expect(node, value, bool, name)
+            elif name == "max_active_runs":
+                dag.config["MaxActiveRuns"] = expect(node, value, int, name)
+            elif name == "retries":
+                dag.retries = expect(node, value, int, name)
+                dag.config["Retries"] = dag.retries
+            elif name == "retry_delay":
+                dag.config["RetryDelay"] = seconds(node, value, name)
+            else:
+                raise Unsupported(node, "DAG argument %s is not supported" % name)
+        self.dags.append(dag)
+        return dag
+
+    def set_timezone(self, node, dag, value):
+        timezone = value.timezone if isinstance(value, Date) else None
+        if timezone is None:
+            return
+        if dag.timezone is not None and dag.timezone != timezone:
+            raise Unsupported(node, "start_date and end_date must be in the same time zone")
+        dag.timezone = timezone
+        dag.config["Timezone"] = timezone
+
+    def task(self, node, operator, args, kwargs):
+        if args:
+            kwargs["task_id"] = args[0]
+        if "task_id" not in kwargs:
+            raise Unsupported(node, "%s must have a task_id" % operator)
+        dag = kwargs.pop("dag", self.dag_stack[-1] if self.dag_stack else None)
+        if not isinstance(dag, DAG):
+            raise Unsupported(node, "%s must be given a dag or be created within one" % operator)
+        task = Task(node, dag, expect(node, kwargs.pop("task_id"), str, "task_id"))
+        for name, value in kwargs.items():
+            if name in IGNORED_TASK_ARGS:
+                continue
+            if name == "depends_on_past" and value is False:
+                continue
+            if name == "trigger_rule" and value == "all_success":
+                continue
+            if name == "retries":
+                if value != dag.retries:
+                    raise Unsupported(
+                        node, "retries can only be set for the whole DAG, in its default_args"
+                    )
+                continue
+            if operator == BASH_OPERATOR and name == "bash_command":
+                command = translate_templates(node, expect(node, value, str, name))
+                task.config["Command"] = ["sh", "-c", command]
+            elif operator == BASH_OPERATOR and name == "env":
+                task.config["Env"] = string_map(node, value, name)
+            elif operator == KUBERNETES_POD_OPERATOR and name == "image":
+                task.config["DockerImage"] = expect(node, value, str, name)
+            elif operator == KUBERNETES_POD_OPERATOR and name == "cmds":
+                task.config["Command"] = string_list(node, value, name)
+            elif operator == KUBERNETES_POD_OPERATOR and name == "arguments":
+                task.config["Args"] = string_list(node, value, name)
+            elif operator == KUBERNETES_POD_OPERATOR and name == "env_vars":
+                task.config["Env"] = string_map(node, value, name)
+            elif operator == KUBERNETES_POD_OPERATOR and name == "namespace":
+                self.set_dag_value(node, dag, "Namespace", expect(node, value, str, name))
+            elif operator == KUBERNETES_POD_OPERATOR and name == "labels":
+                for key, label in string_map(node, value, name).items():
+                    labels = dag.config.setdefault("Labels", {})
+                    if labels.get(key, label) != label:
+                        raise Unsupported(node, "tasks of a DAG must have the same labels")
+                    labels[key] = label
+            elif operator == KUBERNETES_POD_OPERATOR and name == "get_logs":
+                if expect(node, value, bool, name):
+                    dag.config["WithLogs"] = True
+            else:
+                raise Unsupported(node, "%s argument %s is not supported" % (operator, name))
+        if operator == BASH_OPERATOR and "Command" not in task.config:
+            raise Unsupported(node, "BashOperator must have a bash_command")
+        if operator == KUBERNETES_POD_OPERATOR and "DockerImage" not in task.config:
+            raise Unsupported(node, "KubernetesPodOperator must have an image")
+        if any(other.config["Name"] == task.config["Name"] for other in dag.tasks):
+            raise Unsupported(node, "DAG %s has more than one task with task_id %s" % (
+                dag.config["Name"], task.config["Name"]))
+        dag.tasks.append(task)
+        return task
+
+    def set_dag_value(self, node, dag, key, value):
+        if dag.config.get(key, value) != value:
+            raise Unsupported(node, "tasks of a DAG must have the same %s" % key.lower())
+        dag.config[key] = value
+
+    def output(self):
+        dags = []
+        for dag in self.dags:
+            for task in dag.tasks:
+                if task.depends_on:
+                    task.config["DependsOn"] = task.depends_on
+                dag.config["Tasks"].append(task.config)
+            dags.append({"Line": dag.line, "Config": dag.config})
+        return {"DAGs": dags}
+
+
+def main():
+    extractor = Extractor()
+    try:
+        extractor.run(sys.stdin.read())
+        result = extractor.output()
+    except Unsupported as err:
+        result = {"Line": err.line, "Error": err.message}
+    json.dump(result, sys.stdout)
+
+
+main()
+`
@@ -8,6 +8,8 @@ import (
 	dagconfig "goflow/internal/dag/config"
 	"goflow/internal/dag/metrics"
 	dagrun "goflow/internal/dag/run"
+	"goflow/internal/dateutils"
+	"goflow/internal/executor"
 	"goflow/internal/jsonpanic"
 	"goflow/internal/k8s/pod/event/holder"
 	"goflow/internal/logs"
@@ -22,11 +24,19 @@ import (
 
 	dagtable "goflow/internal/dag/sql/dag"
 	dagruntable "goflow/internal/dag/sql/dagrun"
+	taskinstancetable "goflow/internal/dag/sql/taskinstance"
 
 	"github.com/robfig/cron"
+	k8sapi "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
+// maxBackfillRuns is the largest number of runs that a single backfill may start
+const maxBackfillRuns = 10000
+
+// backfillPollInterval is how long a backfill waits before checking again for a free run slot
+const backfillPollInterval = 100 * time.Millisecond
+
 // ScheduleCache is a map from string to cron schedule
 type ScheduleCache map[string]cron.Schedule
 
@@ -39,13 +49,18 @@ type DAG struct {
 	EndDateTime         time.Time
 	DAGRuns             []*dagrun.DAGRun
 	kubeClient          kubernetes.Interface
+	executor            executor.Executor
+	runFinished         dagrun.FinishHandler
 	ActiveRuns          *activeruns.ActiveRuns
 	MostRecentExecution time.Time
 	timeLock            *sync.Mutex
+	location            *time.Location // The time zone of the DAG's schedule and dates
+	waitingStop         chan struct{}  // Closed to stop the runs waiting for a free run slot
 	schedules           ScheduleCache
 	*dagtable.TableClient
 	filePath          string
 	dagRunTableClient *dagruntable.TableClient
+	taskTableClient   *taskinstancetable.TableClient
 	ID                int
 	IsOn              bool
 	LastUpdated       time.Time
@@ -59,15 +74,12 @@ func readDAGFile(dagFilePath string) ([]byte, error) {
 	return dat, nil
 }
 
-func getDateFromString(dateStr string) time.Time {
-	time, err := time.Parse("2006-01-02", dateStr)
-	if err != nil {
-		panic(err)
-	}
-	return time
+func getDateFromString(dateStr string, location *time.Location) (time.Time, error) {
+	return dateutils.ParseDateTime(dateStr, location)
 }
 
-// CreateDAG returns a dag using the configuration passed and stores the code string
+// CreateDAG returns a dag using the configuration passed and stores the code string. An error is
+// returned, without storing the DAG, if its time zone, dates or MaxActiveRuns are not valid.
 func CreateDAG(
 	config *dagconfig.DAGConfig,
 	code string,
@@ -76,15 +88,15 @@ func CreateDAG(
 	tableClient *dagtable.TableClient,
 	filePath string,
 	dagRunTableClient *dagruntable.TableClient,
+	taskTableClient *taskinstancetable.TableClient,
 	defaultIsOn bool,
-) DAG {
+) (DAG, error) {
 	if config.Annotations == nil {
 		config.Annotations = make(map[string]string)
 	}
 	if config.Labels == nil {
 		config.Labels = make(map[string]string)
 	}
-	// TODO: Restore these from database table on restart
 	dag := DAG{
 		Config:            config,
 		Code:              code,
@@ -96,26 +108,46 @@ func CreateDAG(
 		TableClient:       tableClient,
 		filePath:          filePath,
 		dagRunTableClient: dagRunTableClient,
+		taskTableClient:   taskTableClient,
 		IsOn:              defaultIsOn,
+		waitingStop:       make(chan struct{}),
+	}
+	location, err := config.Location()
+	if err != nil {
+		return DAG{}, err
+	}
+	dag.location = location
+	dag.StartDateTime, err = getDateFromString(dag.Config.StartDateTime, location)
+	if err != nil {
+		return DAG{}, fmt.Errorf("DAG %s has an invalid StartDateTime: %s", config.Name, err)
 	}
-	dag.StartDateTime = getDateFromString(dag.Config.StartDateTime)
 	if dag.Config.EndDateTime != "" {
-		dag.EndDateTime = getDateFromString(dag.Config.EndDateTime)
+		dag.EndDateTime, err = getDateFromString(dag.Config.EndDateTime, location)
+		if err != nil {
+			return DAG{}, fmt.Errorf("DAG %s has an invalid EndDateTime: %s", config.Name, err)
+		}
 	}
 	if dag.Config.MaxActiveRuns < 1 {
-		panic("MaxActiveRuns must be greater than 0!")
+		return DAG{}, fmt.Errorf(
+			"DAG %s must have a MaxActiveRuns greater than 0, found %d",
+			config.Name,
+			dag.Config.MaxActiveRuns,
+		)
+	}
+	if dag.IsDagPresent(config.Name, config.Namespace) {
+		dag.IsOn = dag.GetDagRecord(config.Name, config.Namespace).IsOn
 	}
 	row := dag.UpsertDAG(
 		newDagRow(&dag),
 	)
 	dag.ID = row.ID
-	return dag
+	return dag, nil
 }
 
 func newDagRow(dag *DAG) dagtable.Row {
 	return dagtable.NewRow(
 		0,
-		false,
+		dag.IsOn,
 		dag.Config.Name,
 		dag.Config.Namespace,
 		"dag.Config.Version",
@@ -124,6 +156,92 @@ func newDagRow(dag *DAG) dagtable.Row {
 	)
 }
 
+// validateDAGConfig returns an error if a DAG config read from a DAG file is not valid
+func validateDAGConfig(dagConfig *dagconfig.DAGConfig) error {
+	// Validate name and namespace
+	err := dagConfig.ValidateName()
+	if err != nil {
+		return err
+	}
+	err = dagConfig.ValidateNamespace()
+	if err != nil {
+		return err
+	}
+
+	// Validate schedule
+	_, err = parseSchedule(dagConfig.Schedule, time.Time{}, time.UTC)
+	if err != nil {
+		return fmt.Errorf("DAG %s has an invalid schedule: %s", dagConfig.Name, err)
+	}
+
+	// Validate time zone and dates
+	err = dagConfig.ValidateTimezone()
+	if err != nil {
+		return err
+	}
+	err = dagConfig.ValidateDates()
+	if err != nil {
+		return err
+	}
+	err = dagConfig.ValidateMaxActiveRuns()
+	if err != nil {
+		return err
+	}
+
+	// Validate images and restart policy
+	err = dagConfig.ValidateImages()
+	if err != nil {
+		return err
+	}
+	err = dagConfig.ValidateRestartPolicy()
+	if err != nil {
+		return err
+	}
+
+	// Validate task dependencies
+	err = dagConfig.ValidateTasks()
+	if err != nil {
+		return err
+	}
+
+	// Validate retry settings
+	err = dagConfig.ValidateRetries()
+	if err != nil {
+		return err
+	}
+
+	// Validate execution mode
+	err = dagConfig.ValidateExecutionMode()
+	if err != nil {
+		return err
+	}
+	err = dagConfig.ValidateSensors()
+	if err != nil {
+		return err
+	}
+
+	// Validate triggers
+	err = dagConfig.ValidateTriggers()
+	if err != nil {
+		return err
+	}
+
+	// Validate templates
+	err = dagConfig.ValidateTemplates()
+	if err != nil {
+		return err
+	}
+
+	// Validate pod template
+	err = dagConfig.ValidatePodTemplate()
+	if err != nil {
+		return err
+	}
+
+	// Validate secret references
+	return dagConfig.ValidateSecrets()
+}
+
 func createDAGFromJSONBytes(
 	dagBytes []byte,
 	client kubernetes.Interface,
@@ -132,27 +250,21 @@ func createDAGFromJSONBytes(
 	tableClient *dagtable.TableClient,
 	filePath string,
 	dagRunTableClient *dagruntable.TableClient,
+	taskTableClient *taskinstancetable.TableClient,
 ) (DAG, error) {
 	dagConfigStruct := dagconfig.DAGConfig{}
-	err := json.Unmarshal(dagBytes, &dagConfigStruct)
+	err := dagconfig.Unmarshal(dagBytes, &dagConfigStruct)
 	dagConfigStruct.SetDefaults(goflowConfig)
 	if err != nil {
 		return DAG{}, err
 	}
 
-	// Validate schedule
-	_, err = cron.Parse(dagConfigStruct.Schedule)
-	if err != nil {
-		panic(
-			fmt.Sprintf(
-				"DAG %s has a scheduling err with schedule \"%s\": ",
-				dagConfigStruct.Name,
-				dagConfigStruct.Schedule,
-			) + err.Error(),
-		)
+	err = validateDAGConfig(&dagConfigStruct)
+	if err != nil {
+		return DAG{}, err
 	}
 
-	dag := CreateDAG(
+	return CreateDAG(
 		&dagConfigStruct,
 		string(dagBytes),
 		client,
@@ -160,49 +272,187 @@ func createDAGFromJSONBytes(
 		tableClient,
 		filePath,
 		dagRunTableClient,
+		taskTableClient,
 		goflowConfig.DAGsOn,
 	)
-	return dag, nil
 }
 
-// getDAGFromJSON creates a new dag struct from a dag file
-func getDAGFromJSON(
+// declaredDAG is the config of one of the DAGs declared by a DAG file
+type declaredDAG struct {
+	config   dagconfig.DAGConfig
+	code     string
+	position string // Where the DAG is declared in a file that declares several, if it does
+}
+
+// wrap starts the error with where the DAG is declared in its file
+func (declaration *declaredDAG) wrap(err error) error {
+	if declaration.position == "" {
+		return err
+	}
+	return fmt.Errorf("%s: %s", declaration.position, err)
+}
+
+// readJSONDAG reads the DAG declared by a JSON dag file
+func readJSONDAG(dagFilePath string) ([]declaredDAG, error) {
+	dagBytes, err := readDAGFile(dagFilePath)
+	if err != nil {
+		return nil, err
+	}
+	config := dagconfig.DAGConfig{}
+	err = dagconfig.Unmarshal(dagBytes, &config)
+	if err != nil {
+		return nil, err
+	}
+	return []declaredDAG{{config: config, code: string(dagBytes)}}, nil
+}
+
+// readYAMLDAGs reads the DAG declared by each document of a YAML dag file
+func readYAMLDAGs(dagFilePath string) ([]declaredDAG, error) {
+	dagBytes, err := readDAGFile(dagFilePath)
+	if err != nil {
+		return nil, err
+	}
+	documents, err := dagconfig.ParseYAML(dagBytes)
+	if err != nil {
+		return nil, err
+	}
+	declared := make([]declaredDAG, 0, len(documents))
+	for _, document := range documents {
+		declared = append(declared, declaredDAG{
+			config:   document.Config,
+			code:     document.Code,
+			position: fmt.Sprintf("yaml: line %d", document.Line),
+		})
+	}
+	return declared, nil
+}
+
+// readDeclaredDAGs reads the DAGs declared by the DAG file at the given path, without checking
+// whether they are valid
+func readDeclaredDAGs(
+	dagFilePath string,
+	goflowConfig goflowconfig.GoFlowConfig,
+) ([]declaredDAG, error) {
+	if !IsDAGFile(dagFilePath) {
+		return nil, fmt.Errorf("%s is not a DAG file that can be loaded", dagFilePath)
+	}
+	switch dagFileFormat(dagFilePath) {
+	case "py":
+		return readPythonDAGs(dagFilePath, goflowConfig.PythonCommand)
+	case "go":
+		return readGoDAGs(dagFilePath, goflowConfig.GoDAGModulePath)
+	case "yaml", "yml":
+		return readYAMLDAGs(dagFilePath)
+	}
+	return readJSONDAG(dagFilePath)
+}
+
+// validateDeclaredDAGs sets the defaults of the DAGs declared by a DAG file, and returns an error
+// if the file declares no DAGs, declares a DAG twice, or declares a DAG that is not valid
+func validateDeclaredDAGs(
+	declared []declaredDAG,
+	dagFilePath string,
+	goflowConfig goflowconfig.GoFlowConfig,
+) error {
+	if len(declared) == 0 {
+		return fmt.Errorf("%s does not declare any DAGs", dagFilePath)
+	}
+	names := make(map[string]bool, len(declared))
+	for i := range declared {
+		declaration := &declared[i]
+		declaration.config.SetDefaults(goflowConfig)
+		if names[declaration.config.Name] {
+			return declaration.wrap(
+				fmt.Errorf("DAG %s is declared more than once", declaration.config.Name),
+			)
+		}
+		names[declaration.config.Name] = true
+		err := validateDAGConfig(&declaration.config)
+		if err != nil {
+			return declaration.wrap(err)
+		}
+	}
+	return nil
+}
+
+// ValidateDAGFile reads the DAGs declared by the DAG file at the given path and checks that they
+// are valid, without storing them, returning their configs with the goflow config's defaults set
+func ValidateDAGFile(
+	dagFilePath string,
+	goflowConfig goflowconfig.GoFlowConfig,
+) ([]dagconfig.DAGConfig, error) {
+	declared, err := readDeclaredDAGs(dagFilePath, goflowConfig)
+	if err != nil {
+		return nil, err
+	}
+	err = validateDeclaredDAGs(declared, dagFilePath, goflowConfig)
+	if err != nil {
+		return nil, err
+	}
+	configs := make([]dagconfig.DAGConfig, 0, len(declared))
+	for _, declaration := range declared {
+		configs = append(configs, declaration.config)
+	}
+	return configs, nil
+}
+
+// createDeclaredDAGs creates a new dag struct for each of the DAGs declared by a DAG file. The
+// DAGs are only created if every one of them is valid.
+func createDeclaredDAGs(
+	declared []declaredDAG,
 	dagFilePath string,
 	client kubernetes.Interface,
-	metricsClient *metrics.DAGMetricsClient,
 	goflowConfig goflowconfig.GoFlowConfig,
 	scheduleCache ScheduleCache,
 	tableClient *dagtable.TableClient,
 	dagRunTableClient *dagruntable.TableClient,
-) (DAG, error) {
-	dagBytes, err := readDAGFile(dagFilePath)
+	taskTableClient *taskinstancetable.TableClient,
+) ([]*DAG, error) {
+	err := validateDeclaredDAGs(declared, dagFilePath, goflowConfig)
 	if err != nil {
-		return DAG{}, err
+		return nil, err
 	}
-	dagJSON, err := createDAGFromJSONBytes(
-		dagBytes,
-		client,
-		goflowConfig,
-		scheduleCache,
-		tableClient,
-		dagFilePath,
-		dagRunTableClient,
-	)
-	if err != nil {
-		logs.ErrorLogger.Printf("Error parsing dag file %s", dagFilePath)
-		return DAG{}, err
+	dags := make([]*DAG, 0, len(declared))
+	for i := range declared {
+		declaration := &declared[i]
+		dag, err := CreateDAG(
+			&declaration.config,
+			declaration.code,
+			client,
+			scheduleCache,
+			tableClient,
+			dagFilePath,
+			dagRunTableClient,
+			taskTableClient,
+			goflowConfig.DAGsOn,
+		)
+		if err != nil {
+			return nil, declaration.wrap(err)
+		}
+		dags = append(dags, &dag)
 	}
-	dagJSON.Code = string(dagBytes)
-	return dagJSON, nil
+	return dags, nil
 }
 
-// getDirSliceRecur recursively retrieves all file names from the directory
-func getDirSliceRecur(directory string) []string {
+// ImportErrors maps the path of each DAG file that could not be loaded to the reason why
+type ImportErrors map[string]error
+
+// dagFileRegex matches the paths of DAG files, whose names must contain "_dag"
+var dagFileRegex = regexp.MustCompile(".*_dag.*\\.(go|json|py|yaml|yml)")
+
+// getDirSliceRecur recursively retrieves all file names from the directory, along with an error
+// for each path within it that could not be read
+func getDirSliceRecur(directory string) ([]string, ImportErrors) {
 	files := []string{}
-	dagFileRegex := regexp.MustCompile(".*_dag.*\\.(go|json|py)")
+	walkErrors := make(ImportErrors)
 	appendToFiles := func(path string, info os.FileInfo, err error) error {
 		if err != nil {
-			return err
+			if path == directory {
+				return err
+			}
+			logs.With(logs.Fields{logs.FileField: path}).Errorf("Could not read %s: %s", path, err)
+			walkErrors[path] = err
+			return nil
 		}
 		if dagFileRegex.Match([]byte(path)) {
 			files = append(files, path)
@@ -211,19 +461,98 @@ func getDirSliceRecur(directory string) []string {
 	}
 	err := filepath.Walk(directory, appendToFiles)
 	if os.IsNotExist(err) {
-		logs.WarningLogger.Printf("Directory \"%s\" not found", directory)
-		return files
+		logs.Warningf("Directory \"%s\" not found", directory)
+		return files, walkErrors
 	}
 	if err != nil {
-		logs.ErrorLogger.Println(err)
-		panic(err)
+		logs.With(logs.Fields{logs.FileField: directory}).Errorf(
+			"Could not read %s: %s",
+			directory,
+			err,
+		)
+		walkErrors[directory] = err
+	}
+	return files, walkErrors
+}
+
+// dagFileFormat returns the format of a DAG file, as given by its extension
+func dagFileFormat(filePath string) string {
+	return strings.TrimPrefix(strings.ToLower(filepath.Ext(filePath)), ".")
+}
+
+// IsDAGFile returns true if the path is that of a DAG file in a format that can be loaded
+func IsDAGFile(filePath string) bool {
+	if !dagFileRegex.MatchString(filePath) {
+		return false
+	}
+	switch dagFileFormat(filePath) {
+	case "json", "yaml", "yml", "go", "py":
+		return true
+	}
+	return false
+}
+
+// DAGFiles returns the paths of the DAG files within the folder that can be loaded, along with an
+// error for each path within it that could not be read
+func DAGFiles(folder string) ([]string, ImportErrors) {
+	paths, walkErrors := getDirSliceRecur(folder)
+	files := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if IsDAGFile(path) {
+			files = append(files, path)
+		}
 	}
-	return files
+	return files, walkErrors
 }
 
-// GetDAGSFromFolder returns a slice of DAG structs, one for each DAG file
+// GetDAGsFromFile returns the DAGs declared by the DAG file at the given path. A JSON file
+// declares a single DAG, each document of a YAML file declares a DAG, a Go file declares the DAGs
+// that it emits when it is run, and a Python file declares the DAGs of an Airflow DAG file. The
+// DAGs are only stored if every one of them is valid.
+func GetDAGsFromFile(
+	file string,
+	client kubernetes.Interface,
+	metricsClient *metrics.DAGMetricsClient,
+	goflowConfig goflowconfig.GoFlowConfig,
+	schedules ScheduleCache,
+	tableClient *dagtable.TableClient,
+	dagRunTableClient *dagruntable.TableClient,
+	taskTableClient *taskinstancetable.TableClient,
+) ([]*DAG, error) {
+	declared, err := readDeclaredDAGs(file, goflowConfig)
+	if err != nil {
+		logs.With(logs.Fields{logs.FileField: file}).Errorf(
+			"Error reading dag file %s: %s",
+			file,
+			err,
+		)
+		return nil, err
+	}
+	dags, err := createDeclaredDAGs(
+		declared,
+		file,
+		client,
+		goflowConfig,
+		schedules,
+		tableClient,
+		dagRunTableClient,
+		taskTableClient,
+	)
+	if err != nil {
+		logs.With(logs.Fields{logs.FileField: file}).Errorf(
+			"Error loading dag file %s: %s",
+			file,
+			err,
+		)
+		return nil, err
+	}
+	return dags, nil
+}
+
+// GetDAGSFromFolder returns a slice of DAG structs, one for each DAG declared by a DAG file, along
+// with the errors that kept any of the DAG files from being loaded
 // Each file must have the "dag" suffix
-// E.g., my_dag.py, some_dag.json
+// E.g., my_dag.yaml, some_dag.json, other_dag.go, airflow_dag.py
 func GetDAGSFromFolder(
 	folder string,
 	client kubernetes.Interface,
@@ -232,32 +561,36 @@ func GetDAGSFromFolder(
 	schedules ScheduleCache,
 	tableClient *dagtable.TableClient,
 	dagRunTableClient *dagruntable.TableClient,
-) []*DAG {
-	files := getDirSliceRecur(folder)
+	taskTableClient *taskinstancetable.TableClient,
+) ([]*DAG, ImportErrors) {
+	files, importErrors := DAGFiles(folder)
 	dags := make([]*DAG, 0, len(files))
 	for _, file := range files {
-		if strings.ToLower(filepath.Ext(file)) == ".json" {
-			dag, err := getDAGFromJSON(
-				file,
-				client,
-				metricsClient,
-				goflowConfig,
-				schedules,
-				tableClient,
-				dagRunTableClient,
-			)
-			if os.ErrNotExist == err {
-				logs.ErrorLogger.Printf("File %s no longer exists", file)
-			}
-			if err == nil {
-				dags = append(dags, &dag)
-			}
+		fileDAGs, err := GetDAGsFromFile(
+			file,
+			client,
+			metricsClient,
+			goflowConfig,
+			schedules,
+			tableClient,
+			dagRunTableClient,
+			taskTableClient,
+		)
+		if os.IsNotExist(err) {
+			logs.With(logs.Fields{logs.FileField: file}).Errorf("File %s no longer exists", file)
+			continue
+		}
+		if err != nil {
+			importErrors[file] = err
+			continue
 		}
+		dags = append(dags, fileDAGs...)
 	}
-	return dags
+	return dags, importErrors
 }
 
-// AddDagRun adds a DagRun for a scheduled point to the orchestrators set of dags
+// AddDagRun adds a DagRun for a scheduled point to the orchestrators set of dags. The run's data
+// interval ends at the next scheduled time after its execution date.
 func (dag *DAG) AddDagRun(
 	executionDate time.Time,
 	withLogs bool,
@@ -271,85 +604,521 @@ func (dag *DAG) AddDagRun(
 		holder,
 		dag.ActiveRuns,
 		dag.dagRunTableClient,
+		dag.taskTableClient,
 		dag.ID,
 	)
+	if schedule := dag.getSchedule(); schedule != nil && !schedule.Next(executionDate).IsZero() {
+		dagRun.DataIntervalEnd = k8sapi.Time{Time: schedule.Next(executionDate)}
+	}
+	if dag.executor != nil {
+		dagRun.SetExecutor(dag.executor)
+	}
+	dagRun.SetFinishHandler(dag.runFinished)
 	dag.DAGRuns = append(dag.DAGRuns, dagRun)
 	return dagRun
 }
 
-// getSchedule parses and caches or returns the stored schedule
+// Location returns the time zone of the DAG's schedule and dates
+func (dag *DAG) Location() *time.Location {
+	return dag.location
+}
+
+// Update replaces the DAG's config, and the code, time zone and dates read from it, with those of
+// the updated DAG. Its runs and the time of its most recent run are kept.
+func (dag *DAG) Update(updated *DAG) {
+	dag.timeLock.Lock()
+	defer dag.timeLock.Unlock()
+	dag.Config = updated.Config
+	dag.Code = updated.Code
+	dag.location = updated.location
+	dag.StartDateTime = updated.StartDateTime
+	dag.EndDateTime = updated.EndDateTime
+}
+
+// SetExecutor sets the executor that launches the tasks of the DAG's runs
+func (dag *DAG) SetExecutor(taskExecutor executor.Executor) {
+	dag.executor = taskExecutor
+}
+
+// SetRunFinishedHandler sets the function called once each of the DAG's runs has finished
+func (dag *DAG) SetRunFinishedHandler(handler dagrun.FinishHandler) {
+	dag.runFinished = handler
+}
+
+// getSchedule parses and caches or returns the stored schedule, which is evaluated in the DAG's
+// time zone
 func (dag *DAG) getSchedule() cron.Schedule {
-	schedule, ok := dag.schedules[dag.Config.Schedule]
+	key := fmt.Sprintf(
+		"%s %s %s",
+		dag.Config.Schedule,
+		dag.location,
+		dag.StartDateTime.Format(time.RFC3339),
+	)
+	schedule, ok := dag.schedules[key]
 	if ok {
 		return schedule
 	}
-	schedule, _ = cron.Parse(dag.Config.Schedule)
-	dag.schedules[dag.Config.Schedule] = schedule
+	schedule, _ = parseSchedule(dag.Config.Schedule, dag.StartDateTime, dag.location)
+	dag.schedules[key] = schedule
 	return schedule
 }
 
+// log returns a log entry about the DAG
+func (dag *DAG) log() logs.Entry {
+	return logs.With(logs.Fields{logs.DAGField: dag.Config.Name})
+}
+
 // getNextTime returns the next time according to the cron schedule
 func (dag *DAG) getNextTime(lastTime time.Time) time.Time {
 	schedule := dag.getSchedule()
 	next := schedule.Next(lastTime)
-	logs.InfoLogger.Println("Next:", next)
+	dag.log().Debugf("Next run at %s", next)
 	return next
 }
 
-// AddNextDagRunIfReady adds the next dag run if ready for it, returns true if added, else false
+// RestoreRuns recovers the execution date of the DAG's most recent scheduled run from the database
+// and resumes the runs that were still in progress when goflow stopped
+func (dag *DAG) RestoreRuns(holder *holder.ChannelHolder) {
+	dag.timeLock.Lock()
+	defer dag.timeLock.Unlock()
+	if lastRun, ok := dag.dagRunTableClient.GetLastScheduledRunForDagID(dag.ID); ok {
+		dag.MostRecentExecution = lastRun.ExecutionDate.In(dag.location)
+	}
+	runRows := dag.dagRunTableClient.GetRunsForDagIDWithStatus(dag.ID, string(dagrun.RunRunning))
+	for _, runRow := range runRows {
+		executionDate := runRow.ExecutionDate.In(dag.location)
+		dag.log().Infof(
+			"Restoring run of dag %s for execution date %s",
+			dag.Config.Name,
+			executionDate,
+		)
+		dagRun := dag.AddDagRun(executionDate, dag.Config.WithLogs, holder)
+		dag.ActiveRuns.Inc()
+		go dagRun.Resume(
+			runRow,
+			dag.taskTableClient.GetTaskInstancesForDagRun(dag.ID, runRow.ExecutionDate),
+		)
+	}
+}
+
+// latestScheduledTime returns the most recent execution time that is not after the given time.
+// Execution times begin at the DAG's start time and follow its schedule from there.
+func (dag *DAG) latestScheduledTime(until time.Time) time.Time {
+	schedule := dag.getSchedule()
+	until = until.In(dag.location)
+	for lookback := time.Minute; ; lookback *= 2 {
+		from := until.Add(-lookback)
+		if !from.After(dag.StartDateTime) {
+			from = dag.StartDateTime
+		}
+		latest := time.Time{}
+		for next := schedule.Next(from); !next.IsZero() && !next.After(until); {
+			latest = next
+			next = schedule.Next(next)
+		}
+		if !latest.IsZero() {
+			return latest
+		}
+		if from.Equal(dag.StartDateTime) {
+			return dag.StartDateTime
+		}
+	}
+}
+
+// scheduledTimesBetween returns the times on the DAG's schedule from start to end, inclusive
+func (dag *DAG) scheduledTimesBetween(start time.Time, end time.Time) ([]time.Time, error) {
+	schedule := dag.getSchedule()
+	if schedule == nil {
+		return nil, fmt.Errorf("dag %s does not have a schedule", dag.Config.Name)
+	}
+	times := make([]time.Time, 0)
+	next := schedule.Next(start.Add(-time.Nanosecond))
+	for !next.IsZero() && !next.After(end) {
+		if len(times) == maxBackfillRuns {
+			return nil, fmt.Errorf(
+				"there are more than %d scheduled times between %s and %s",
+				maxBackfillRuns,
+				start,
+				end,
+			)
+		}
+		times = append(times, next)
+		next = schedule.Next(next)
+	}
+	return times, nil
+}
+
+// AddNextDagRunIfReady adds the next dag run if ready for it, returns true if added, else false.
+// A run is only added once its execution date has come, and never after the DAG's end date. If
+// catchup is disabled, any intervals missed since the last run are skipped.
 func (dag *DAG) AddNextDagRunIfReady(holder *holder.ChannelHolder) (ready bool) {
 	ready = dag.Ready()
 	if ready {
 		dag.timeLock.Lock()
-		switch {
-		case dag.MostRecentExecution.IsZero():
-			dag.MostRecentExecution = dag.StartDateTime
-		default:
-			dag.MostRecentExecution = dag.getNextTime(dag.MostRecentExecution)
+		if !dag.ActiveRuns.IncIfBelow(dag.Config.MaxActiveRuns) {
+			dag.timeLock.Unlock()
+			return false
+		}
+		next := dag.nextExecutionDate()
+		if next.IsZero() || next.After(time.Now()) {
+			dag.ActiveRuns.Dec()
+			dag.timeLock.Unlock()
+			return false
+		}
+		dag.MostRecentExecution = next
+		if !dag.Config.CatchupEnabled() {
+			until := time.Now()
+			if !dag.EndDateTime.IsZero() && until.After(dag.EndDateTime) {
+				until = dag.EndDateTime
+			}
+			latest := dag.latestScheduledTime(until)
+			if latest.After(dag.MostRecentExecution) {
+				dag.log().Infof(
+					"dag %s is not catching up, skipping ahead to %s",
+					dag.Config.Name,
+					latest,
+				)
+				dag.MostRecentExecution = latest
+			}
 		}
 		dagRun := dag.AddDagRun(dag.MostRecentExecution, dag.Config.WithLogs, holder)
 		dag.timeLock.Unlock()
-		dag.ActiveRuns.Inc()
 		go dagRun.Start()
 	}
 	return
 }
 
-// TerminateAndDeleteRuns removes all active DAG runs and their associated pods
-func (dag *DAG) TerminateAndDeleteRuns() {
+// nextExecutionDate returns the execution date of the DAG's next scheduled run, which is its start
+// date if it has not run yet, or the zero time if its schedule has no more runs before its end date
+func (dag *DAG) nextExecutionDate() time.Time {
+	next := dag.StartDateTime
+	if !dag.MostRecentExecution.IsZero() {
+		next = dag.getNextTime(dag.MostRecentExecution)
+	}
+	if !dag.EndDateTime.IsZero() && next.After(dag.EndDateTime) {
+		return time.Time{}
+	}
+	return next
+}
+
+// hasRunInProgress returns true if the DAG has a run for the execution date that has not finished
+func (dag *DAG) hasRunInProgress(executionDate time.Time) bool {
+	for _, run := range dag.DAGRuns {
+		if run.ExecutionDate.Time.Equal(executionDate) && run.EndTime.IsZero() {
+			return true
+		}
+	}
+	return false
+}
+
+// Backfill runs the DAG for every scheduled time from start to end, inclusive, and returns those
+// execution dates. Runs are started in order as soon as the DAG has fewer than MaxActiveRuns
+// active runs, an execution date that already has a run in progress is skipped. The backfill stops
+// once the DAG is paused or deleted, or its runs are terminated.
+func (dag *DAG) Backfill(
+	start time.Time,
+	end time.Time,
+	holder *holder.ChannelHolder,
+) ([]time.Time, error) {
+	if !dag.isOn() {
+		return nil, fmt.Errorf("dag %s is paused", dag.Config.Name)
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("backfill start %s is after backfill end %s", start, end)
+	}
+	executionDates, err := dag.scheduledTimesBetween(start, end)
+	if err != nil {
+		return nil, err
+	}
+	dag.log().Infof(
+		"Backfilling %d runs of dag %s from %s to %s",
+		len(executionDates),
+		dag.Config.Name,
+		start,
+		end,
+	)
+	go dag.runBackfill(executionDates, dag.waitingRunsStop(), holder)
+	return executionDates, nil
+}
+
+// runBackfill starts a run for each execution date, waiting for a free run slot before each one,
+// until the stop channel is closed
+func (dag *DAG) runBackfill(
+	executionDates []time.Time,
+	stop chan struct{},
+	holder *holder.ChannelHolder,
+) {
+	for i, executionDate := range executionDates {
+		if !dag.startRunWhenFree(executionDate, dagrun.RunBackfill, "", stop, holder) {
+			dag.log().Infof(
+				"Stopping backfill of dag %s, %d runs from %s were not started",
+				dag.Config.Name,
+				len(executionDates)-i,
+				executionDate,
+			)
+			return
+		}
+	}
+}
+
+// startRunWhenFree waits for a free run slot and then starts a run of the given type for the
+// execution date, unless the DAG already has a run in progress for it. Returns false without
+// starting the run if the stop channel is closed or the DAG is paused before the run can start.
+func (dag *DAG) startRunWhenFree(
+	executionDate time.Time,
+	runType dagrun.RunType,
+	triggeredBy string,
+	stop chan struct{},
+	holder *holder.ChannelHolder,
+) bool {
+	for !dag.ActiveRuns.IncIfBelow(dag.Config.MaxActiveRuns) {
+		select {
+		case <-stop:
+			return false
+		case <-time.After(backfillPollInterval):
+		}
+	}
+	dag.timeLock.Lock()
+	if isClosed(stop) || !dag.IsOn {
+		dag.timeLock.Unlock()
+		dag.ActiveRuns.Dec()
+		return false
+	}
+	if dag.hasRunInProgress(executionDate) {
+		dag.timeLock.Unlock()
+		dag.ActiveRuns.Dec()
+		dag.log().Warningf(
+			"dag %s already has a run in progress for %s, skipping %s run",
+			dag.Config.Name,
+			executionDate,
+			runType,
+		)
+		return true
+	}
+	dagRun := dag.AddDagRun(executionDate, dag.Config.WithLogs, holder)
+	dagRun.RunType = runType
+	dagRun.TriggeredBy = triggeredBy
+	dag.timeLock.Unlock()
+	go dagRun.Start()
+	return true
+}
+
+// isClosed returns true if the channel has been closed
+func isClosed(channel chan struct{}) bool {
+	select {
+	case <-channel:
+		return true
+	default:
+		return false
+	}
+}
+
+// waitingRunsStop returns the channel that is closed to stop the backfills and upstream triggers
+// that are waiting to start runs of the DAG
+func (dag *DAG) waitingRunsStop() chan struct{} {
+	dag.timeLock.Lock()
+	defer dag.timeLock.Unlock()
+	if dag.waitingStop == nil {
+		dag.waitingStop = make(chan struct{})
+	}
+	return dag.waitingStop
+}
+
+// stopWaitingRuns stops the backfills and upstream triggers that are waiting to start runs of the
+// DAG, while later ones may still start runs. The caller must hold the timeLock.
+func (dag *DAG) stopWaitingRuns() {
+	if dag.waitingStop != nil {
+		close(dag.waitingStop)
+	}
+	dag.waitingStop = make(chan struct{})
+}
+
+// isOn returns true if the DAG is switched on
+func (dag *DAG) isOn() bool {
+	dag.timeLock.Lock()
+	defer dag.timeLock.Unlock()
+	return dag.IsOn
+}
+
+// TriggerFromUpstream starts a run of the DAG for the execution date of a successful run of the
+// named upstream DAG, once the DAG has fewer than MaxActiveRuns active runs
+func (dag *DAG) TriggerFromUpstream(
+	executionDate time.Time,
+	upstream string,
+	holder *holder.ChannelHolder,
+) {
+	dag.log().Infof(
+		"Triggering run of dag %s for %s after a successful run of dag %s",
+		dag.Config.Name,
+		executionDate,
+		upstream,
+	)
+	go dag.startRunWhenFree(
+		executionDate,
+		dagrun.RunTriggered,
+		upstream,
+		dag.waitingRunsStop(),
+		holder,
+	)
+}
+
+// Trigger starts a manual run of the DAG for the given execution date, whether or not the DAG is
+// on, passing conf to the run's pods as environment variables. An error is returned if the DAG
+// already has MaxActiveRuns active runs or a run in progress for the execution date.
+func (dag *DAG) Trigger(
+	executionDate time.Time,
+	conf map[string]string,
+	holder *holder.ChannelHolder,
+) (*dagrun.DAGRun, error) {
+	if !dag.ActiveRuns.IncIfBelow(dag.Config.MaxActiveRuns) {
+		return nil, fmt.Errorf(
+			"dag %s already has the maximum of %d active runs",
+			dag.Config.Name,
+			dag.Config.MaxActiveRuns,
+		)
+	}
+	dag.timeLock.Lock()
+	defer dag.timeLock.Unlock()
+	if dag.hasRunInProgress(executionDate) {
+		dag.ActiveRuns.Dec()
+		return nil, fmt.Errorf(
+			"dag %s already has a run in progress for %s",
+			dag.Config.Name,
+			executionDate,
+		)
+	}
+	dag.log().Infof(
+		"Triggering manual run of dag %s for %s",
+		dag.Config.Name,
+		executionDate,
+	)
+	dagRun := dag.AddDagRun(executionDate, dag.Config.WithLogs, holder)
+	dagRun.RunType = dagrun.RunManual
+	dagRun.Conf = conf
+	go dagRun.Start()
+	return dagRun, nil
+}
+
+// TerminateRuns terminates the DAG's runs, deleting the pods of their unfinished tasks, and stops
+// the runs waiting to start. The records of the runs are kept.
+func (dag *DAG) TerminateRuns() {
+	dag.timeLock.Lock()
+	dag.stopWaitingRuns()
+	dag.timeLock.Unlock()
 	for _, run := range dag.DAGRuns {
-		run.DeletePod()
+		run.Terminate()
 	}
 }
 
-// Ready returns true if the DAG is ready for another DAG Run to be created
+// Ready returns true if the DAG is ready for another DAG Run to be created, which is once the
+// execution date of its next scheduled run has come. DAGs without a schedule, whose schedule has no
+// more runs, or that are triggered by upstream DAGs or datasets are never ready to run on their
+// schedule.
 func (dag *DAG) Ready() bool {
-	currentTime := time.Now()
-	scheduleReady := (dag.MostRecentExecution.Before(currentTime) ||
-		dag.MostRecentExecution.Equal(currentTime) && dag.MostRecentExecution.Before(dag.EndDateTime))
-	logs.InfoLogger.Printf("dag %s is ready: %v\n", dag.Config.Name, scheduleReady)
+	if dag.Config.IsTriggered() || dag.getSchedule() == nil {
+		return false
+	}
+	dag.timeLock.Lock()
+	next := dag.nextExecutionDate()
+	dag.timeLock.Unlock()
+	scheduleReady := !next.IsZero() && !next.After(time.Now())
+	dag.log().Debugf("dag %s is ready: %v", dag.Config.Name, scheduleReady)
 	return (dag.ActiveRuns.Get() < dag.Config.MaxActiveRuns) && scheduleReady && dag.IsOn
 }
 
-// Marshal returns the JSON byte slice representation of the DAG
+// hasRunConf returns true if any of the DAG's runs was given a conf
+func (dag *DAG) hasRunConf() bool {
+	for _, run := range dag.DAGRuns {
+		if len(run.Conf) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// redacted returns a copy of the DAG whose config, the code it was read from and the conf of its
+// runs do not contain the values of literal environment variables
+func (dag *DAG) redacted() *DAG {
+	if dag.Config == nil {
+		return dag
+	}
+	redactedConfig := dag.Config.Redacted()
+	configRedacted := redactedConfig.JSON() != dag.Config.Copy().JSON()
+	if !configRedacted && !dag.hasRunConf() {
+		return dag
+	}
+	redactedDAG := *dag
+	if configRedacted {
+		redactedDAG.Config = &redactedConfig
+		redactedDAG.Code = redactedConfig.String()
+	}
+	redactedDAG.DAGRuns = make([]*dagrun.DAGRun, 0, len(dag.DAGRuns))
+	for _, run := range dag.DAGRuns {
+		redactedDAG.DAGRuns = append(redactedDAG.DAGRuns, run.Redacted())
+	}
+	return &redactedDAG
+}
+
+// Marshal returns the JSON byte slice representation of the DAG, with literal environment
+// variable values redacted
 func (dag *DAG) Marshal() []byte {
-	jsonString, err := json.Marshal(dag)
+	jsonString, err := json.Marshal(dag.redacted())
 	if err != nil {
 		panic(err)
 	}
 	return jsonString
 }
 
-// ToggleOnOff switches the internal on/off state of the DAG
+// ToggleOnOff switches the internal on/off state of the DAG, stopping the runs waiting to start
+// when the DAG is paused
 func (dag *DAG) ToggleOnOff() {
 	dag.timeLock.Lock()
 	dag.IsOn = !dag.IsOn
 	dag.UpdateDAGToggle(dag.ID, dag.IsOn)
+	if !dag.IsOn {
+		dag.stopWaitingRuns()
+	}
+	dag.timeLock.Unlock()
+}
+
+// SetOnOff sets the internal on/off state of the DAG, so that a DAG that is already paused stays
+// paused rather than being switched on, stopping the runs waiting to start when it is paused
+func (dag *DAG) SetOnOff(isOn bool) {
+	dag.timeLock.Lock()
+	dag.IsOn = isOn
+	dag.UpdateDAGToggle(dag.ID, dag.IsOn)
+	if !dag.IsOn {
+		dag.stopWaitingRuns()
+	}
+	dag.timeLock.Unlock()
+}
+
+// GetRun returns the run of the DAG with the given name, or nil if the DAG has no such run
+func (dag *DAG) GetRun(runName string) *dagrun.DAGRun {
+	for _, run := range dag.DAGRuns {
+		if run.Name == runName {
+			return run
+		}
+	}
+	return nil
+}
+
+// Deactivate records that the DAG's file has been removed from the DAG folder, and stops the runs
+// waiting to start
+func (dag *DAG) Deactivate() {
+	dag.UpdateDAGActive(dag.ID, false)
+	dag.timeLock.Lock()
+	dag.stopWaitingRuns()
 	dag.timeLock.Unlock()
 }
 
+// FilePath returns the path of the file that the DAG was read from
+func (dag *DAG) FilePath() string {
+	return dag.filePath
+}
+
 func (dag *DAG) String() string {
-	return jsonpanic.JSONPanicFormat(dag)
+	return jsonpanic.JSONPanicFormat(dag.redacted())
 }
 
 // DAGList is a list of dags
@@ -11,8 +11,11 @@ import (
 	k8sclient "goflow/internal/k8s/client"
 	"goflow/internal/k8s/pod/event/holder"
 	"goflow/internal/testutils"
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -21,6 +24,7 @@ import (
 
 	dagtable "goflow/internal/dag/sql/dag"
 	dagruntable "goflow/internal/dag/sql/dagrun"
+	taskinstancetable "goflow/internal/dag/sql/taskinstance"
 
 	core "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -32,6 +36,7 @@ var DAGPATH string
 var TABLECLIENT *dagtable.TableClient
 var SQLCLIENT *database.SQLClient
 var RUNTABLECLIENT *dagruntable.TableClient
+var TASKTABLECLIENT *taskinstancetable.TableClient
 var METRICSCLIENT metrics.DAGMetricsClient
 
 func setUpNamespaces(client kubernetes.Interface) {
@@ -58,6 +63,7 @@ func TestMain(m *testing.M) {
 	SQLCLIENT = database.NewSQLiteClient(testutils.GetSQLiteLocation())
 	TABLECLIENT = dagtable.NewTableClient(SQLCLIENT)
 	RUNTABLECLIENT = dagruntable.NewTableClient(SQLCLIENT)
+	TASKTABLECLIENT = taskinstancetable.NewTableClient(SQLCLIENT)
 	m.Run()
 }
 
@@ -87,6 +93,7 @@ func (stringMap StringMap) Bytes() []byte {
 func setUpDatabase() {
 	TABLECLIENT.CreateTable()
 	RUNTABLECLIENT.CreateTable()
+	TASKTABLECLIENT.CreateTable()
 }
 
 func TestDAGFromJSONBytes(t *testing.T) {
@@ -107,6 +114,7 @@ func TestDAGFromJSONBytes(t *testing.T) {
 		Labels:        map[string]string{"test": "test-label"},
 		Annotations:   map[string]string{"anno": "value"},
 		MaxActiveRuns: 1,
+		Catchup:       new(bool),
 	}
 	formattedJSONString := string(config.Marshal())
 	expectedDAG := DAG{
@@ -130,6 +138,7 @@ func TestDAGFromJSONBytes(t *testing.T) {
 		TABLECLIENT,
 		"path",
 		RUNTABLECLIENT,
+		TASKTABLECLIENT,
 	)
 	if err != nil {
 		panic(err)
@@ -143,9 +152,14 @@ func TestDAGFromJSONBytes(t *testing.T) {
 }
 
 func TestReadFiles(t *testing.T) {
-	expectedFiles := []string{"my_json_dag.json", "my_json_dag2.json", "my_python_dag.py"}
+	expectedFiles := []string{
+		"my_json_dag.json", "my_json_dag2.json", "my_python_dag.py", "my_yaml_dag.yaml",
+	}
 	sort.Strings(expectedFiles)
-	foundFilePaths := getDirSliceRecur(DAGPATH)
+	foundFilePaths, walkErrors := getDirSliceRecur(DAGPATH)
+	if len(walkErrors) != 0 {
+		t.Errorf("Expected no errors reading the DAG folder, found %v", walkErrors)
+	}
 	for i, filePath := range foundFilePaths {
 		_, foundFilePaths[i] = filepath.Split(filePath)
 	}
@@ -166,7 +180,7 @@ func TestReadFiles(t *testing.T) {
 }
 
 func getTestDAG(client kubernetes.Interface) *DAG {
-	dag := CreateDAG(&dagconfig.DAGConfig{
+	dag, err := CreateDAG(&dagconfig.DAGConfig{
 		Name:          "test",
 		Namespace:     "default",
 		Schedule:      "* * * * *",
@@ -177,7 +191,10 @@ func getTestDAG(client kubernetes.Interface) *DAG {
 		MaxActiveRuns: 1,
 		StartDateTime: "2019-01-01",
 		EndDateTime:   "",
-	}, "", client, make(ScheduleCache), TABLECLIENT, "path", RUNTABLECLIENT, false)
+	}, "", client, make(ScheduleCache), TABLECLIENT, "path", RUNTABLECLIENT, TASKTABLECLIENT, false)
+	if err != nil {
+		panic(err)
+	}
 	return &dag
 }
 
@@ -232,6 +249,19 @@ func TestToggleOnOff(t *testing.T) {
 	}
 }
 
+func TestSetOnOff(t *testing.T) {
+	defer database.PurgeDB(SQLCLIENT)
+	setUpDatabase()
+	testDAG := getTestDAGFakeClient(getNewTestClient())
+
+	for _, isOn := range []bool{true, true, false, false} {
+		testDAG.SetOnOff(isOn)
+		if testDAG.IsOn != isOn || getDAGRecordDAG(testDAG).IsOn != isOn {
+			t.Errorf("DAG and its record should be on: %t", isOn)
+		}
+	}
+}
+
 func TestAddDagRun(t *testing.T) {
 	defer database.PurgeDB(SQLCLIENT)
 	setUpDatabase()
@@ -241,6 +271,35 @@ func TestAddDagRun(t *testing.T) {
 	reportErrorCounts(t, len(testDAG.DAGRuns), 1, testDAG)
 }
 
+func TestAddDagRunDataInterval(t *testing.T) {
+	defer database.PurgeDB(SQLCLIENT)
+	setUpDatabase()
+	testDAG := getDailyTestDAG(getNewTestClient(), false)
+	executionDate := time.Date(2019, 1, 5, 0, 0, 0, 0, time.UTC)
+	dagRun := testDAG.AddDagRun(executionDate, testDAG.Config.WithLogs, holder.New())
+	if !dagRun.DataIntervalStart.Time.Equal(executionDate) ||
+		!dagRun.DataIntervalEnd.Time.Equal(executionDate.AddDate(0, 0, 1)) {
+		t.Errorf(
+			"Expected data interval from %s to the next day, found %s to %s",
+			executionDate,
+			dagRun.DataIntervalStart,
+			dagRun.DataIntervalEnd,
+		)
+	}
+}
+
+// waitForRunsQueued waits until every task of the dag's runs has requested its pod, after which
+// the runs stop writing to the database until their pods change phase
+func waitForRunsQueued(dag *DAG) {
+	for _, run := range dag.DAGRuns {
+		for _, task := range run.Tasks {
+			for task.GetState() != dagrun.TaskQueued {
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}
+}
+
 func TestAddDagRunIfReady(t *testing.T) {
 	actionCases := []struct {
 		actionFunc   func(dag *DAG)
@@ -262,12 +321,15 @@ func TestAddDagRunIfReady(t *testing.T) {
 			client := getNewTestClient()
 			testDAG := getTestDAGFakeClient(client)
 			testDAG.IsOn = true // Turn on DAG
+			// Catching up from the start date, so that the second run is already due
+			catchup := true
+			testDAG.Config.Catchup = &catchup
 			channelHolder := holder.New()
 			testDAG.AddNextDagRunIfReady(channelHolder)
 			action.actionFunc(testDAG)
 			testDAG.AddNextDagRunIfReady(channelHolder)
 			reportErrorCounts(t, len(testDAG.DAGRuns), action.expectedRuns, testDAG)
-			time.Sleep(10000)
+			waitForRunsQueued(testDAG)
 			// Make sure there are no more active dagruns before test terminates
 			if testDAG.ActiveRuns.Get() != 0 {
 				testDAG.ActiveRuns.Dec()
@@ -279,3 +341,810 @@ func TestAddDagRunIfReady(t *testing.T) {
 	}
 	database.PurgeDB(SQLCLIENT)
 }
+
+func TestAddNextDagRunIfReadyPresets(t *testing.T) {
+	cases := []struct {
+		schedule     string
+		expectedRuns int
+	}{
+		{"@once", 1},
+		{"", 0},
+	}
+	for _, testCase := range cases {
+		func() {
+			defer database.PurgeDB(SQLCLIENT)
+			setUpDatabase()
+			testDAG := getTestDAGFakeClient(getNewTestClient())
+			testDAG.Config.Schedule = testCase.schedule
+			testDAG.IsOn = true
+			channelHolder := holder.New()
+			testDAG.AddNextDagRunIfReady(channelHolder)
+			waitForActiveRunsToEnd(testDAG)
+			testDAG.AddNextDagRunIfReady(channelHolder)
+			reportErrorCounts(t, len(testDAG.DAGRuns), testCase.expectedRuns, testDAG)
+			if testDAG.Ready() {
+				t.Errorf("Schedule \"%s\" should not be ready for more runs", testCase.schedule)
+			}
+			waitForActiveRunsToEnd(testDAG)
+		}()
+	}
+}
+
+func TestDAGFromJSONBytesWithInvalidSchedule(t *testing.T) {
+	defer database.PurgeDB(SQLCLIENT)
+	setUpDatabase()
+	config := dagconfig.DAGConfig{
+		Name:          "test-invalid-schedule",
+		Schedule:      "every fortnight",
+		StartDateTime: "2019-01-01",
+		MaxActiveRuns: 1,
+	}
+	_, err := createDAGFromJSONBytes(
+		config.Marshal(),
+		fake.NewSimpleClientset(),
+		goflowconfig.GoFlowConfig{},
+		make(ScheduleCache),
+		TABLECLIENT,
+		"path",
+		RUNTABLECLIENT,
+		TASKTABLECLIENT,
+	)
+	if err == nil {
+		t.Error("Expected an error for a DAG with an invalid schedule")
+	}
+}
+
+func TestCreateDAGWithInvalidConfig(t *testing.T) {
+	defer database.PurgeDB(SQLCLIENT)
+	setUpDatabase()
+	configs := map[string]dagconfig.DAGConfig{
+		"no active runs": {
+			Name:          "test-no-active-runs",
+			StartDateTime: "2019-01-01",
+			MaxActiveRuns: 0,
+		},
+		"bad start date": {
+			Name:          "test-bad-start-date",
+			StartDateTime: "the first of january",
+			MaxActiveRuns: 1,
+		},
+		"bad end date": {
+			Name:          "test-bad-end-date",
+			StartDateTime: "2019-01-01",
+			EndDateTime:   "2019-13-01",
+			MaxActiveRuns: 1,
+		},
+		"bad timezone": {
+			Name:          "test-bad-timezone",
+			StartDateTime: "2019-01-01",
+			Timezone:      "Mars/Olympus_Mons",
+			MaxActiveRuns: 1,
+		},
+	}
+	for description, config := range configs {
+		config := config
+		_, err := CreateDAG(
+			&config,
+			"",
+			fake.NewSimpleClientset(),
+			make(ScheduleCache),
+			TABLECLIENT,
+			"path",
+			RUNTABLECLIENT,
+			TASKTABLECLIENT,
+			false,
+		)
+		if err == nil {
+			t.Errorf("Expected an error for a DAG with a %s", description)
+		}
+		if TABLECLIENT.IsDagPresent(config.Name, config.Namespace) {
+			t.Errorf("DAG with a %s should not have been stored", description)
+		}
+	}
+}
+
+func TestGetDAGsFromFolderWithImportErrors(t *testing.T) {
+	defer database.PurgeDB(SQLCLIENT)
+	setUpDatabase()
+	folder, err := ioutil.TempDir("", "goflow-dags")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(folder)
+	files := map[string]string{
+		"good_dag.json": string(dagconfig.DAGConfig{
+			Name:          "test-good",
+			Schedule:      "* * * * *",
+			StartDateTime: "2019-01-01",
+			MaxActiveRuns: 1,
+		}.Marshal()),
+		"bad_json_dag.json":     `{"Name": "test-bad-json",`,
+		"bad_schedule_dag.json": `{"Name": "test-bad-schedule", "Schedule": "sometimes"}`,
+		"bad_yaml_dag.yaml":     "Name: test-bad-yaml\n  Schedule: \"* * * * *\"\n",
+	}
+	for name, content := range files {
+		err = ioutil.WriteFile(filepath.Join(folder, name), []byte(content), 0644)
+		if err != nil {
+			panic(err)
+		}
+	}
+	dags, importErrors := GetDAGSFromFolder(
+		folder,
+		fake.NewSimpleClientset(),
+		metrics.NewDAGMetricsClient(fake.NewSimpleClientset(), false),
+		goflowconfig.GoFlowConfig{},
+		make(ScheduleCache),
+		TABLECLIENT,
+		RUNTABLECLIENT,
+		TASKTABLECLIENT,
+	)
+	if len(dags) != 1 || dags[0].Config.Name != "test-good" {
+		t.Errorf("Expected only DAG test-good to be loaded, found %d DAGs", len(dags))
+	}
+	badFiles := []string{"bad_json_dag.json", "bad_schedule_dag.json", "bad_yaml_dag.yaml"}
+	for _, name := range badFiles {
+		if importErrors[filepath.Join(folder, name)] == nil {
+			t.Errorf("Expected an import error for %s, found %v", name, importErrors)
+		}
+	}
+	if len(importErrors) != len(badFiles) {
+		t.Errorf("Expected %d import errors, found %d", len(badFiles), len(importErrors))
+	}
+	yamlError := importErrors[filepath.Join(folder, "bad_yaml_dag.yaml")]
+	if yamlError != nil && !strings.Contains(yamlError.Error(), "line 2") {
+		t.Errorf("Expected the YAML import error to give its line, found %s", yamlError)
+	}
+}
+
+func TestGetDAGsFromYAMLFile(t *testing.T) {
+	defer database.PurgeDB(SQLCLIENT)
+	setUpDatabase()
+	dags, err := GetDAGsFromFile(
+		filepath.Join(DAGPATH, "my_yaml_dag.yaml"),
+		fake.NewSimpleClientset(),
+		metrics.NewDAGMetricsClient(fake.NewSimpleClientset(), false),
+		goflowconfig.GoFlowConfig{DefaultNamespace: "default", MaxActiveRuns: 1},
+		make(ScheduleCache),
+		TABLECLIENT,
+		RUNTABLECLIENT,
+		TASKTABLECLIENT,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dags) != 2 {
+		t.Fatalf("Expected a DAG for each of the 2 documents, found %d", len(dags))
+	}
+	if dags[0].Config.Name != "test-yaml-dag" || dags[1].Config.Name != "test-yaml-dag-2" {
+		t.Errorf("Expected DAGs test-yaml-dag and test-yaml-dag-2")
+	}
+	if strings.Contains(dags[1].Code, "test-yaml-dag\n") {
+		t.Errorf("Expected the code of each DAG to be its own document, found %s", dags[1].Code)
+	}
+	if dependsOn := dags[1].Config.Tasks[1].DependsOn; len(dependsOn) != 1 {
+		t.Errorf("Expected task second to depend on task first, found %v", dependsOn)
+	}
+	for _, dag := range dags {
+		if !TABLECLIENT.IsDagPresent(dag.Config.Name, dag.Config.Namespace) {
+			t.Errorf("Expected DAG %s to be stored", dag.Config.Name)
+		}
+	}
+}
+
+func TestGetDAGsFromYAMLFileWithInvalidDocument(t *testing.T) {
+	defer database.PurgeDB(SQLCLIENT)
+	setUpDatabase()
+	folder, err := ioutil.TempDir("", "goflow-dags")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(folder)
+	dagFile := filepath.Join(folder, "partly_valid_dag.yml")
+	yamlDAGs := "Name: test-valid\nStartDateTime: \"2019-01-01\"\n" +
+		"---\nName: test-invalid\nSchedule: sometimes\n"
+	if err := ioutil.WriteFile(dagFile, []byte(yamlDAGs), 0644); err != nil {
+		panic(err)
+	}
+	_, err = GetDAGsFromFile(
+		dagFile,
+		fake.NewSimpleClientset(),
+		metrics.NewDAGMetricsClient(fake.NewSimpleClientset(), false),
+		goflowconfig.GoFlowConfig{DefaultNamespace: "default", MaxActiveRuns: 1},
+		make(ScheduleCache),
+		TABLECLIENT,
+		RUNTABLECLIENT,
+		TASKTABLECLIENT,
+	)
+	if err == nil || !strings.Contains(err.Error(), "line 4") {
+		t.Errorf("Expected an error for the document on line 4, found %v", err)
+	}
+	if TABLECLIENT.IsDagPresent("test-valid", "default") {
+		t.Error("Expected no DAGs to be stored from a file with an invalid document")
+	}
+}
+
+func TestDAGFromJSONBytesWithCycle(t *testing.T) {
+	defer database.PurgeDB(SQLCLIENT)
+	setUpDatabase()
+	config := dagconfig.DAGConfig{
+		Name:          "test-cycle",
+		Schedule:      "* * * * *",
+		StartDateTime: "2019-01-01",
+		MaxActiveRuns: 1,
+		Tasks: []dagconfig.TaskConfig{
+			{Name: "first", DependsOn: []string{"second"}},
+			{Name: "second", DependsOn: []string{"first"}},
+		},
+	}
+	_, err := createDAGFromJSONBytes(
+		config.Marshal(),
+		fake.NewSimpleClientset(),
+		goflowconfig.GoFlowConfig{},
+		make(ScheduleCache),
+		TABLECLIENT,
+		"path",
+		RUNTABLECLIENT,
+		TASKTABLECLIENT,
+	)
+	if err == nil {
+		t.Error("Expected an error for a DAG with a dependency cycle")
+	}
+}
+
+func TestDAGFromJSONBytesTriggeredWithoutSchedule(t *testing.T) {
+	defer database.PurgeDB(SQLCLIENT)
+	setUpDatabase()
+	config := dagconfig.DAGConfig{
+		Name:          "test-triggered",
+		StartDateTime: "2019-01-01",
+		MaxActiveRuns: 1,
+		TriggeredBy:   []dagconfig.TriggerConfig{{DAG: "upstream"}},
+	}
+	dag, err := createDAGFromJSONBytes(
+		config.Marshal(),
+		fake.NewSimpleClientset(),
+		goflowconfig.GoFlowConfig{},
+		make(ScheduleCache),
+		TABLECLIENT,
+		"path",
+		RUNTABLECLIENT,
+		TASKTABLECLIENT,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dag.IsOn = true
+	if dag.Ready() {
+		t.Error("A DAG that is triggered by another should not run on a schedule")
+	}
+	start := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, err = dag.Backfill(start, start, nil)
+	if err == nil {
+		t.Error("Expected an error backfilling a DAG without a schedule")
+	}
+}
+
+func TestDAGFromJSONBytesWithInvalidRetryBackoff(t *testing.T) {
+	defer database.PurgeDB(SQLCLIENT)
+	setUpDatabase()
+	config := dagconfig.DAGConfig{
+		Name:          "test-invalid-retries",
+		Schedule:      "* * * * *",
+		StartDateTime: "2019-01-01",
+		MaxActiveRuns: 1,
+		Retries:       1,
+		RetryBackoff:  "linear",
+	}
+	_, err := createDAGFromJSONBytes(
+		config.Marshal(),
+		fake.NewSimpleClientset(),
+		goflowconfig.GoFlowConfig{},
+		make(ScheduleCache),
+		TABLECLIENT,
+		"path",
+		RUNTABLECLIENT,
+		TASKTABLECLIENT,
+	)
+	if err == nil {
+		t.Error("Expected an error for a DAG with an unknown retry backoff")
+	}
+}
+
+func TestDAGFromJSONBytesWithInvalidTemplate(t *testing.T) {
+	defer database.PurgeDB(SQLCLIENT)
+	setUpDatabase()
+	config := dagconfig.DAGConfig{
+		Name:          "test-invalid-template",
+		Schedule:      "* * * * *",
+		StartDateTime: "2019-01-01",
+		MaxActiveRuns: 1,
+		Command:       []string{"echo", "{{ .ExecutionDate | date }"},
+	}
+	_, err := createDAGFromJSONBytes(
+		config.Marshal(),
+		fake.NewSimpleClientset(),
+		goflowconfig.GoFlowConfig{},
+		make(ScheduleCache),
+		TABLECLIENT,
+		"path",
+		RUNTABLECLIENT,
+		TASKTABLECLIENT,
+	)
+	if err == nil {
+		t.Error("Expected an error for a DAG with an invalid template")
+	}
+}
+
+// getDailyTestDAG returns a test DAG that runs every day at midnight starting from 2019-01-01
+func getDailyTestDAG(client kubernetes.Interface, catchup bool) *DAG {
+	dag := getTestDAGFakeClient(client)
+	dag.Config.Schedule = "0 0 0 * * *"
+	dag.Config.Catchup = &catchup
+	dag.IsOn = true
+	return dag
+}
+
+func TestLatestScheduledTime(t *testing.T) {
+	defer database.PurgeDB(SQLCLIENT)
+	setUpDatabase()
+	dag := getDailyTestDAG(getNewTestClient(), false)
+	start := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		until    time.Time
+		expected time.Time
+	}{
+		{start.AddDate(0, 0, -1), start},
+		{start.Add(6 * time.Hour), start},
+		{start.AddDate(0, 2, 4).Add(12 * time.Hour), start.AddDate(0, 2, 4)},
+		{start.AddDate(1, 0, 0), start.AddDate(1, 0, 0)},
+	}
+	for _, testCase := range cases {
+		latest := dag.latestScheduledTime(testCase.until)
+		if !latest.Equal(testCase.expected) {
+			t.Errorf(
+				"Expected latest time before %s to be %s, found %s",
+				testCase.until,
+				testCase.expected,
+				latest,
+			)
+		}
+	}
+}
+
+// waitForActiveRunsToEnd releases the run slot held by each of the dag's queued runs
+func waitForActiveRunsToEnd(dag *DAG) {
+	waitForRunsQueued(dag)
+	for dag.ActiveRuns.Get() != 0 {
+		dag.ActiveRuns.Dec()
+	}
+}
+
+func TestAddNextDagRunIfReadyCatchup(t *testing.T) {
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		catchup               bool
+		expectedExecutionDate time.Time
+	}{
+		{true, time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{false, today},
+	}
+	for _, testCase := range cases {
+		func() {
+			defer database.PurgeDB(SQLCLIENT)
+			setUpDatabase()
+			dag := getDailyTestDAG(getNewTestClient(), testCase.catchup)
+			dag.AddNextDagRunIfReady(holder.New())
+			defer waitForActiveRunsToEnd(dag)
+			reportErrorCounts(t, len(dag.DAGRuns), 1, dag)
+			executionDate := dag.DAGRuns[0].ExecutionDate.Time
+			if !executionDate.Equal(testCase.expectedExecutionDate) {
+				t.Errorf(
+					"With catchup %t expected the first run on %s, found %s",
+					testCase.catchup,
+					testCase.expectedExecutionDate,
+					executionDate,
+				)
+			}
+		}()
+	}
+}
+
+func TestAddNextDagRunIfReadyWaitsForSchedule(t *testing.T) {
+	defer database.PurgeDB(SQLCLIENT)
+	setUpDatabase()
+	dag := getDailyTestDAG(getNewTestClient(), false)
+	channelHolder := holder.New()
+	dag.AddNextDagRunIfReady(channelHolder)
+	waitForActiveRunsToEnd(dag)
+
+	if dag.Ready() {
+		t.Error("Expected the DAG not to be ready before its next scheduled time")
+	}
+	if dag.AddNextDagRunIfReady(channelHolder) {
+		t.Error("Expected no run to be added before its scheduled time")
+	}
+	reportErrorCounts(t, len(dag.DAGRuns), 1, dag)
+	for _, run := range dag.DAGRuns {
+		if run.ExecutionDate.Time.After(time.Now()) {
+			t.Errorf("Expected no run before its execution date, found %s", run.ExecutionDate)
+		}
+	}
+}
+
+func TestAddNextDagRunIfReadyStopsAtEndDate(t *testing.T) {
+	end := time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		catchup               bool
+		expectedExecutionDate time.Time
+	}{
+		{true, time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{false, end},
+	}
+	for _, testCase := range cases {
+		func() {
+			defer database.PurgeDB(SQLCLIENT)
+			setUpDatabase()
+			dag := getDailyTestDAG(getNewTestClient(), testCase.catchup)
+			dag.EndDateTime = end
+			dag.AddNextDagRunIfReady(holder.New())
+			defer waitForActiveRunsToEnd(dag)
+			reportErrorCounts(t, len(dag.DAGRuns), 1, dag)
+			executionDate := dag.DAGRuns[0].ExecutionDate.Time
+			if !executionDate.Equal(testCase.expectedExecutionDate) {
+				t.Errorf(
+					"With catchup %t expected the first run on %s, found %s",
+					testCase.catchup,
+					testCase.expectedExecutionDate,
+					executionDate,
+				)
+			}
+		}()
+	}
+
+	defer database.PurgeDB(SQLCLIENT)
+	setUpDatabase()
+	dag := getDailyTestDAG(getNewTestClient(), true)
+	dag.EndDateTime = end
+	dag.MostRecentExecution = end
+	if next := dag.nextExecutionDate(); !next.IsZero() {
+		t.Errorf("Expected no run after the end date, found %s", next)
+	}
+	if dag.Ready() {
+		t.Error("Expected the DAG not to be ready once its end date has passed")
+	}
+}
+
+func waitForRunCount(dag *DAG, count int) {
+	for {
+		dag.timeLock.Lock()
+		runCount := len(dag.DAGRuns)
+		dag.timeLock.Unlock()
+		if runCount >= count {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBackfill(t *testing.T) {
+	defer database.PurgeDB(SQLCLIENT)
+	setUpDatabase()
+	dag := getDailyTestDAG(getNewTestClient(), false)
+	start := time.Date(2019, 2, 1, 0, 0, 0, 0, time.UTC)
+	executionDates, err := dag.Backfill(start, start.AddDate(0, 0, 2), holder.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(executionDates) != 3 {
+		t.Fatalf("Expected 3 execution dates, found %d", len(executionDates))
+	}
+
+	waitForRunCount(dag, 1)
+	time.Sleep(3 * backfillPollInterval)
+	reportErrorCounts(t, len(dag.DAGRuns), 1, dag)
+	for i := range executionDates {
+		waitForRunCount(dag, i+1)
+		waitForRunsQueued(dag)
+		run := dag.DAGRuns[i]
+		if !run.ExecutionDate.Time.Equal(executionDates[i]) {
+			t.Errorf("Expected run on %s, found %s", executionDates[i], run.ExecutionDate)
+		}
+		// Free the run's slot so that the next backfill run can start
+		dag.ActiveRuns.Dec()
+	}
+}
+
+func TestBackfillStopsWhenPaused(t *testing.T) {
+	defer database.PurgeDB(SQLCLIENT)
+	setUpDatabase()
+	dag := getDailyTestDAG(getNewTestClient(), false)
+	start := time.Date(2019, 2, 1, 0, 0, 0, 0, time.UTC)
+	_, err := dag.Backfill(start, start.AddDate(0, 0, 2), holder.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForRunCount(dag, 1)
+	dag.SetOnOff(false)
+	dag.ActiveRuns.Dec()
+	time.Sleep(3 * backfillPollInterval)
+	dag.timeLock.Lock()
+	reportErrorCounts(t, len(dag.DAGRuns), 1, dag)
+	dag.timeLock.Unlock()
+
+	_, err = dag.Backfill(start, start.AddDate(0, 0, 2), holder.New())
+	if err == nil {
+		t.Error("Expected an error for a backfill of a paused DAG")
+	}
+}
+
+func TestBackfillInvalidRange(t *testing.T) {
+	defer database.PurgeDB(SQLCLIENT)
+	setUpDatabase()
+	dag := getDailyTestDAG(getNewTestClient(), false)
+	start := time.Date(2019, 2, 1, 0, 0, 0, 0, time.UTC)
+	_, err := dag.Backfill(start, start.AddDate(0, 0, -1), holder.New())
+	if err == nil {
+		t.Error("Expected an error for a backfill that ends before it starts")
+	}
+	dag.Config.Schedule = "* * * * * *"
+	_, err = dag.Backfill(start, start.AddDate(0, 0, 1), holder.New())
+	if err == nil {
+		t.Error("Expected an error for a backfill with too many runs")
+	}
+}
+
+func TestCreateDAGRestoresIsOn(t *testing.T) {
+	defer database.PurgeDB(SQLCLIENT)
+	setUpDatabase()
+	testDAG := getTestDAGFakeClient(getNewTestClient())
+	testDAG.ToggleOnOff()
+	reloadedDAG := getTestDAGFakeClient(getNewTestClient())
+	if !reloadedDAG.IsOn {
+		t.Error("DAG should still be on after being reloaded")
+	}
+	if !getDAGRecordDAG(reloadedDAG).IsOn {
+		t.Error("Reloading the DAG should not change its stored on/off state")
+	}
+}
+
+func TestRestoreRuns(t *testing.T) {
+	defer database.PurgeDB(SQLCLIENT)
+	setUpDatabase()
+	client := getNewTestClient()
+	testDAG := getDailyTestDAG(client, true)
+	firstDate := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	secondDate := firstDate.AddDate(0, 0, 1)
+	RUNTABLECLIENT.UpsertDagRun(
+		dagruntable.NewRow(testDAG.ID, string(dagrun.RunSuccess), firstDate),
+	)
+	RUNTABLECLIENT.UpsertDagRun(
+		dagruntable.NewRow(testDAG.ID, string(dagrun.RunRunning), secondDate),
+	)
+	// Manual runs, whatever their execution date, do not move the schedule
+	manualRow := dagruntable.NewRow(
+		testDAG.ID,
+		string(dagrun.RunSuccess),
+		firstDate.AddDate(1, 0, 0),
+	)
+	manualRow.RunType = string(dagrun.RunManual)
+	RUNTABLECLIENT.UpsertDagRun(manualRow)
+	TASKTABLECLIENT.UpsertTaskInstance(taskinstancetable.NewRow(
+		testDAG.ID,
+		dagconfig.DefaultTaskName,
+		secondDate,
+		string(dagrun.TaskQueued),
+		"",
+		time.Time{},
+		time.Time{},
+	))
+
+	restoredDAG := getDailyTestDAG(client, true)
+	restoredDAG.RestoreRuns(holder.New())
+	if !restoredDAG.MostRecentExecution.Equal(secondDate) {
+		t.Errorf(
+			"Expected most recent execution %s, found %s",
+			secondDate,
+			restoredDAG.MostRecentExecution,
+		)
+	}
+	reportErrorCounts(t, len(restoredDAG.DAGRuns), 1, restoredDAG)
+	for restoredDAG.ActiveRuns.Get() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	// The queued task's pod is not in the cluster, so the resumed run fails
+	rows := RUNTABLECLIENT.GetRunsForDagIDWithStatus(testDAG.ID, string(dagrun.RunFailed))
+	if len(rows) != 1 || !rows[0].ExecutionDate.Equal(secondDate) {
+		t.Errorf("Expected the resumed run for %s to have failed, found %v", secondDate, rows)
+	}
+}
+
+// waitForPods waits until the given number of pods exist in the default namespace
+func waitForPods(client kubernetes.Interface, count int) []core.Pod {
+	for {
+		podList, err := client.CoreV1().Pods("default").List(context.TODO(), v1.ListOptions{})
+		if err != nil {
+			panic(err)
+		}
+		if len(podList.Items) >= count {
+			return podList.Items
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestTrigger(t *testing.T) {
+	defer database.PurgeDB(SQLCLIENT)
+	setUpDatabase()
+	client := getNewTestClient()
+	dag := getTestDAGFakeClient(client)
+	executionDate := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	conf := map[string]string{"TABLE": "users", "LIMIT": "10"}
+	dagRun, err := dag.Trigger(executionDate, conf, holder.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer waitForActiveRunsToEnd(dag)
+	if dagRun.RunType != dagrun.RunManual {
+		t.Errorf("Expected a %s run, found %s", dagrun.RunManual, dagRun.RunType)
+	}
+	if !dag.MostRecentExecution.IsZero() {
+		t.Error("A manual run should not change the most recent scheduled execution")
+	}
+
+	pods := waitForPods(client, 1)
+	env := pods[0].Spec.Containers[0].Env
+	expectedEnv := []core.EnvVar{{Name: "LIMIT", Value: "10"}, {Name: "TABLE", Value: "users"}}
+	confEnv := env[len(env)-len(expectedEnv):]
+	if confEnv[0] != expectedEnv[0] || confEnv[1] != expectedEnv[1] {
+		t.Errorf("Expected pod environment %v, found %v", expectedEnv, env)
+	}
+
+	rows := RUNTABLECLIENT.GetLastNRunsForDagID(dag.ID, 1)
+	if len(rows) != 1 || rows[0].RunType != string(dagrun.RunManual) {
+		t.Errorf("Expected a manual run to be recorded, found %v", rows)
+	}
+
+	_, err = dag.Trigger(executionDate.AddDate(0, 0, 1), nil, holder.New())
+	if err == nil {
+		t.Error("Expected an error when the DAG already has MaxActiveRuns active runs")
+	}
+	dag.Config.MaxActiveRuns = 2
+	_, err = dag.Trigger(executionDate, nil, holder.New())
+	if err == nil {
+		t.Error("Expected an error for an execution date with a run in progress")
+	}
+	if dag.ActiveRuns.Get() != 1 {
+		t.Errorf("Rejected triggers should not hold a run slot, found %d", dag.ActiveRuns.Get())
+	}
+}
+
+func TestMarshalRedactsEnv(t *testing.T) {
+	defer database.PurgeDB(SQLCLIENT)
+	setUpDatabase()
+	config := dagconfig.DAGConfig{
+		Name:          "test-redacted",
+		Schedule:      "* * * * *",
+		StartDateTime: "2019-01-01",
+		MaxActiveRuns: 1,
+		Env:           map[string]string{"PASSWORD": "hunter2"},
+		Tasks: []dagconfig.TaskConfig{
+			{
+				Name: "wait",
+				Sensor: &dagconfig.SensorConfig{
+					Type:  dagconfig.SQLSensor,
+					DSN:   "file:/data/app.db?_auth_user=admin&_auth_pass=s3cret",
+					Query: "SELECT 1",
+				},
+			},
+		},
+	}
+	dag, err := createDAGFromJSONBytes(
+		config.Marshal(),
+		fake.NewSimpleClientset(),
+		goflowconfig.GoFlowConfig{},
+		make(ScheduleCache),
+		TABLECLIENT,
+		"path",
+		RUNTABLECLIENT,
+		TASKTABLECLIENT,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dag.AddDagRun(getTestDate(), false, holder.New())
+	for _, served := range []string{string(dag.Marshal()), dag.String(), dag.DAGRuns[0].String()} {
+		if strings.Contains(served, "hunter2") || strings.Contains(served, "s3cret") {
+			t.Errorf("Expected the env value and sensor DSN to be redacted, found %s", served)
+		}
+	}
+	if dag.Config.Env["PASSWORD"] != "hunter2" || !strings.Contains(dag.Code, "hunter2") {
+		t.Error("Serving the DAG should not change its config or code")
+	}
+}
+
+func TestMarshalRedactsRunConf(t *testing.T) {
+	defer database.PurgeDB(SQLCLIENT)
+	setUpDatabase()
+	config := dagconfig.DAGConfig{
+		Name:          "test-redacted-conf",
+		Schedule:      "* * * * *",
+		StartDateTime: "2019-01-01",
+		MaxActiveRuns: 1,
+	}
+	dag, err := createDAGFromJSONBytes(
+		config.Marshal(),
+		fake.NewSimpleClientset(),
+		goflowconfig.GoFlowConfig{},
+		make(ScheduleCache),
+		TABLECLIENT,
+		"path",
+		RUNTABLECLIENT,
+		TASKTABLECLIENT,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dagRun := dag.AddDagRun(getTestDate(), false, holder.New())
+	dagRun.Conf = map[string]string{"TOKEN": "hunter2"}
+	for _, served := range []string{string(dag.Marshal()), dag.String(), dagRun.String()} {
+		if strings.Contains(served, "hunter2") {
+			t.Errorf("Expected the conf value to be redacted, found %s", served)
+		}
+		if !strings.Contains(served, "TOKEN") {
+			t.Errorf("Expected the conf key to still be listed, found %s", served)
+		}
+	}
+	if dagRun.Conf["TOKEN"] != "hunter2" {
+		t.Error("Serving the DAG should not change the conf of its runs")
+	}
+}
+
+func TestValidateDAGFile(t *testing.T) {
+	defer database.PurgeDB(SQLCLIENT)
+	setUpDatabase()
+	goflowConfig := goflowconfig.GoFlowConfig{DefaultNamespace: "default", MaxActiveRuns: 1}
+	configs, err := ValidateDAGFile(filepath.Join(DAGPATH, "my_yaml_dag.yaml"), goflowConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configs) != 2 || configs[0].Namespace != "default" {
+		t.Errorf("Expected the 2 DAGs of the file with defaults set, found %v", configs)
+	}
+	if TABLECLIENT.IsDagPresent(configs[0].Name, configs[0].Namespace) {
+		t.Error("Expected validating a DAG file not to store its DAGs")
+	}
+
+	folder, err := ioutil.TempDir("", "goflow-dags")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(folder)
+	cases := map[string]string{
+		"unknown field \"Shedule\"": `{"Name": "test", "Shedule": "@daily"}`,
+		"must match the pattern":    `{"Name": "-test", "StartDateTime": "2019-01-01"}`,
+		"invalid Namespace": `{"Name": "test", "Namespace": "Not_Valid", ` +
+			`"StartDateTime": "2019-01-01"}`,
+		"EndDateTime after": `{"Name": "test", "StartDateTime": "2019-01-02", ` +
+			`"EndDateTime": "2019-01-01"}`,
+		"RetryPolicy must be": `{"Name": "test", "RetryPolicy": "Sometimes", ` +
+			`"StartDateTime": "2019-01-01"}`,
+		"invalid DockerImage": `{"Name": "test", "DockerImage": "Not An Image", ` +
+			`"StartDateTime": "2019-01-01"}`,
+	}
+	for expectedError, dagJSON := range cases {
+		dagFile := filepath.Join(folder, "invalid_dag.json")
+		if err := ioutil.WriteFile(dagFile, []byte(dagJSON), 0644); err != nil {
+			panic(err)
+		}
+		_, err := ValidateDAGFile(dagFile, goflowConfig)
+		if err == nil || !strings.Contains(err.Error(), expectedError) {
+			t.Errorf(
+				"Expected an error containing %s for %s, found %v",
+				expectedError,
+				dagJSON,
+				err,
+			)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package dagtype
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	dagconfig "goflow/internal/dag/config"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// goDAGTimeout is how long a Go DAG file may take to be built and run
+const goDAGTimeout = 2 * time.Minute
+
+// goBuildFolderPrefix starts the name of the temporary module that a Go DAG file is copied to and
+// run in
+const goBuildFolderPrefix = "goflow-dag-"
+
+// goDAGModuleFile is the go.mod of the temporary module that a Go DAG file is run in, which takes
+// goflow from the folder of its module
+const goDAGModuleFile = `module goflowdag
+
+go 1.15
+
+require goflow v0.0.0
+
+replace goflow => %s
+`
+
+// goDAGModule returns the absolute folder of goflow's module, whose pkg/dag Go DAG files are built
+// against, which must be set as GoDAGModulePath
+func goDAGModule(configuredPath string) (string, error) {
+	if configuredPath == "" {
+		return "", fmt.Errorf(
+			"Go DAG files are built against goflow's module, set GoDAGModulePath to its folder",
+		)
+	}
+	modulePath, err := filepath.Abs(configuredPath)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(filepath.Join(modulePath, "go.mod")); err != nil {
+		return "", fmt.Errorf("GoDAGModulePath %s is not a Go module: %s", configuredPath, err)
+	}
+	return modulePath, nil
+}
+
+// writeGoDAGModule writes the go.mod of the temporary module in the build folder, along with the
+// go.sum of goflow's module so that goflow's dependencies are verified in the same way
+func writeGoDAGModule(buildFolder string, modulePath string) error {
+	goMod := fmt.Sprintf(goDAGModuleFile, modulePath)
+	err := ioutil.WriteFile(filepath.Join(buildFolder, "go.mod"), []byte(goMod), 0644)
+	if err != nil {
+		return err
+	}
+	goSum, err := ioutil.ReadFile(filepath.Join(modulePath, "go.sum"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(buildFolder, "go.sum"), goSum, 0644)
+}
+
+// cleanGoOutput removes the package headers from the output of the go command and replaces the
+// path of the file's copy with the path of the file, so that errors point at the file itself
+func cleanGoOutput(output string, copyPath string, filePath string) string {
+	lines := make([]string, 0)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if strings.HasPrefix(line, "# ") {
+			continue
+		}
+		line = strings.ReplaceAll(line, copyPath, filePath)
+		line = strings.ReplaceAll(line, "./"+filepath.Base(copyPath), filePath)
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// runGoDAGFile builds and runs a Go DAG file in a temporary module of its own that requires
+// goflow's module, and returns the description of the DAGs that it writes to standard output. The
+// error of a file that does not compile holds the compiler's errors.
+func runGoDAGFile(dagFilePath string, modulePath string) ([]byte, error) {
+	code, err := readDAGFile(dagFilePath)
+	if err != nil {
+		return nil, err
+	}
+	buildFolder, err := ioutil.TempDir("", goBuildFolderPrefix)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(buildFolder)
+	err = writeGoDAGModule(buildFolder, modulePath)
+	if err != nil {
+		return nil, err
+	}
+	copyPath := filepath.Join(buildFolder, filepath.Base(dagFilePath))
+	err = ioutil.WriteFile(copyPath, code, 0644)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), goDAGTimeout)
+	defer cancel()
+	command := exec.CommandContext(ctx, "go", "run", filepath.Base(dagFilePath))
+	command.Dir = buildFolder
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	command.Stdout, command.Stderr = stdout, stderr
+	err = command.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("%s took longer than %s to build and run", dagFilePath, goDAGTimeout)
+	}
+	if err != nil {
+		output := cleanGoOutput(stderr.String(), copyPath, dagFilePath)
+		if output == "" {
+			output = err.Error()
+		}
+		return nil, fmt.Errorf("could not build and run %s:\n%s", dagFilePath, output)
+	}
+	return stdout.Bytes(), nil
+}
+
+// readGoDAGs reads the DAGs that a Go dag file emits when it is built and run against goflow's
+// module at the given path
+func readGoDAGs(dagFilePath string, modulePath string) ([]declaredDAG, error) {
+	code, err := readDAGFile(dagFilePath)
+	if err != nil {
+		return nil, err
+	}
+	modulePath, err = goDAGModule(modulePath)
+	if err != nil {
+		return nil, err
+	}
+	description, err := runGoDAGFile(dagFilePath, modulePath)
+	if err != nil {
+		return nil, err
+	}
+	configs := make([]dagconfig.DAGConfig, 0)
+	decoder := json.NewDecoder(bytes.NewReader(description))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&configs)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"%s must emit its DAGs with dag.Emit from goflow/pkg/dag: %s",
+			dagFilePath,
+			err,
+		)
+	}
+	declared := make([]declaredDAG, 0, len(configs))
+	for i, config := range configs {
+		declared = append(declared, declaredDAG{
+			config:   config,
+			code:     string(code),
+			position: fmt.Sprintf("go: DAG %d", i+1),
+		})
+	}
+	return declared, nil
+}
@@ -0,0 +1,111 @@
+package dagtype
+
+import (
+	goflowconfig "goflow/internal/config"
+	"goflow/internal/dag/metrics"
+	"goflow/internal/database"
+	"goflow/internal/testutils"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// goDAGConfig returns the GoFlow configuration for reading Go DAG files in tests
+func goDAGConfig() goflowconfig.GoFlowConfig {
+	return goflowconfig.GoFlowConfig{
+		DefaultNamespace: "default",
+		MaxActiveRuns:    1,
+		GoDAGModulePath:  testutils.GetModuleFolder(),
+	}
+}
+
+func TestGetDAGsFromGoFile(t *testing.T) {
+	defer database.PurgeDB(SQLCLIENT)
+	setUpDatabase()
+	dags, err := GetDAGsFromFile(
+		filepath.Join(testutils.GetTestFolder(), "test_go_dags", "my_go_dag.go"),
+		fake.NewSimpleClientset(),
+		metrics.NewDAGMetricsClient(fake.NewSimpleClientset(), false),
+		goDAGConfig(),
+		make(ScheduleCache),
+		TABLECLIENT,
+		RUNTABLECLIENT,
+		TASKTABLECLIENT,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dags) != 2 {
+		t.Fatalf("Expected the 2 DAGs emitted by the file, found %d", len(dags))
+	}
+	if dags[0].Config.Name != "test-go-dag" || dags[1].Config.Name != "test-go-dag-2" {
+		t.Errorf("Expected DAGs test-go-dag and test-go-dag-2")
+	}
+	if dependsOn := dags[0].Config.Tasks[1].DependsOn; len(dependsOn) != 1 {
+		t.Errorf("Expected task load to depend on task extract, found %v", dependsOn)
+	}
+	if !strings.Contains(dags[0].Code, "dag.Emit(etl, report)") {
+		t.Errorf("Expected the code of the DAG to be the Go file, found %s", dags[0].Code)
+	}
+	for _, dag := range dags {
+		if !TABLECLIENT.IsDagPresent(dag.Config.Name, dag.Config.Namespace) {
+			t.Errorf("Expected DAG %s to be stored", dag.Config.Name)
+		}
+	}
+}
+
+func TestGetDAGsFromGoFileWithCompileError(t *testing.T) {
+	defer database.PurgeDB(SQLCLIENT)
+	setUpDatabase()
+	folder, err := ioutil.TempDir("", "goflow-dags")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(folder)
+	dagFile := filepath.Join(folder, "broken_dag.go")
+	code := "package main\n\nfunc main() {\n\tundefinedFunction()\n}\n"
+	if err := ioutil.WriteFile(dagFile, []byte(code), 0644); err != nil {
+		panic(err)
+	}
+	_, err = GetDAGsFromFile(
+		dagFile,
+		fake.NewSimpleClientset(),
+		metrics.NewDAGMetricsClient(fake.NewSimpleClientset(), false),
+		goDAGConfig(),
+		make(ScheduleCache),
+		TABLECLIENT,
+		RUNTABLECLIENT,
+		TASKTABLECLIENT,
+	)
+	if err == nil || !strings.Contains(err.Error(), dagFile+":4:") {
+		t.Errorf("Expected the compile error on line 4 of %s, found %v", dagFile, err)
+	}
+	if !strings.Contains(err.Error(), "undefinedFunction") {
+		t.Errorf("Expected the error to hold the compiler's message, found %s", err)
+	}
+}
+
+func TestGetDAGsFromGoFileWithoutModule(t *testing.T) {
+	defer database.PurgeDB(SQLCLIENT)
+	setUpDatabase()
+	dagFile := filepath.Join(testutils.GetTestFolder(), "test_go_dags", "my_go_dag.go")
+	for _, modulePath := range []string{"", testutils.GetTestFolder()} {
+		_, err := GetDAGsFromFile(
+			dagFile,
+			fake.NewSimpleClientset(),
+			metrics.NewDAGMetricsClient(fake.NewSimpleClientset(), false),
+			goflowconfig.GoFlowConfig{DefaultNamespace: "default", GoDAGModulePath: modulePath},
+			make(ScheduleCache),
+			TABLECLIENT,
+			RUNTABLECLIENT,
+			TASKTABLECLIENT,
+		)
+		if err == nil || !strings.Contains(err.Error(), "GoDAGModulePath") {
+			t.Errorf("Expected an error about GoDAGModulePath %q, found %v", modulePath, err)
+		}
+	}
+}
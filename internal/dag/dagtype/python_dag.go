@@ -0,0 +1,94 @@
+package dagtype
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	dagconfig "goflow/internal/dag/config"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// defaultPythonCommand is the Python interpreter that reads Airflow DAG files when the goflow
+// config does not set one
+const defaultPythonCommand = "python3"
+
+// pythonDAGTimeout is how long the extractor may take to read an Airflow DAG file
+const pythonDAGTimeout = 30 * time.Second
+
+// airflowExtraction is what the extractor writes for an Airflow DAG file, which is either the
+// DAGs of the file or the construct that kept it from being read
+type airflowExtraction struct {
+	DAGs []struct {
+		Line   int
+		Config dagconfig.DAGConfig
+	}
+	Line  int
+	Error string
+}
+
+// runAirflowExtractor reads the DAGs of the Airflow DAG code with the extractor, which runs in
+// Python's isolated mode, without any environment variables and in the temporary folder. The code
+// is passed on standard input rather than being run.
+func runAirflowExtractor(code []byte, pythonCommand string) (airflowExtraction, error) {
+	if pythonCommand == "" {
+		pythonCommand = defaultPythonCommand
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), pythonDAGTimeout)
+	defer cancel()
+	command := exec.CommandContext(ctx, pythonCommand, "-I", "-S", "-c", airflowExtractor)
+	command.Dir = os.TempDir()
+	command.Env = []string{}
+	command.Stdin = bytes.NewReader(code)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	command.Stdout, command.Stderr = stdout, stderr
+	err := command.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return airflowExtraction{}, fmt.Errorf(
+			"python: took longer than %s to read the DAG file",
+			pythonDAGTimeout,
+		)
+	}
+	if err != nil {
+		output := strings.TrimSpace(stderr.String())
+		if output == "" {
+			output = err.Error()
+		}
+		return airflowExtraction{}, fmt.Errorf("python: could not read the DAG file: %s", output)
+	}
+	extraction := airflowExtraction{}
+	err = json.Unmarshal(stdout.Bytes(), &extraction)
+	if err != nil {
+		return airflowExtraction{}, fmt.Errorf("python: could not read the DAG file: %s", err)
+	}
+	return extraction, nil
+}
+
+// readPythonDAGs reads the DAGs declared by an Airflow dag file, using the given Python
+// interpreter. The file must use nothing but the supported subset of Airflow, and errors give the
+// line of the file that they were found on.
+func readPythonDAGs(dagFilePath string, pythonCommand string) ([]declaredDAG, error) {
+	code, err := readDAGFile(dagFilePath)
+	if err != nil {
+		return nil, err
+	}
+	extraction, err := runAirflowExtractor(code, pythonCommand)
+	if err != nil {
+		return nil, err
+	}
+	if extraction.Error != "" {
+		return nil, fmt.Errorf("python: line %d: %s", extraction.Line, extraction.Error)
+	}
+	declared := make([]declaredDAG, 0, len(extraction.DAGs))
+	for _, extracted := range extraction.DAGs {
+		declared = append(declared, declaredDAG{
+			config:   extracted.Config,
+			code:     string(code),
+			position: fmt.Sprintf("python: line %d", extracted.Line),
+		})
+	}
+	return declared, nil
+}
@@ -0,0 +1,145 @@
+package dagtype
+
+import (
+	"fmt"
+	goflowconfig "goflow/internal/config"
+	"goflow/internal/dag/metrics"
+	"goflow/internal/database"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGetDAGsFromPythonFile(t *testing.T) {
+	defer database.PurgeDB(SQLCLIENT)
+	setUpDatabase()
+	dags, err := GetDAGsFromFile(
+		filepath.Join(DAGPATH, "my_python_dag.py"),
+		fake.NewSimpleClientset(),
+		metrics.NewDAGMetricsClient(fake.NewSimpleClientset(), false),
+		goflowconfig.GoFlowConfig{DefaultNamespace: "default", Catchup: true},
+		make(ScheduleCache),
+		TABLECLIENT,
+		RUNTABLECLIENT,
+		TASKTABLECLIENT,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dags) != 1 {
+		t.Fatalf("Expected the DAG of the Airflow file, found %d DAGs", len(dags))
+	}
+	config := dags[0].Config
+	if config.Name != "test-python-dag" || config.Schedule != "0 */5 * * * *" {
+		t.Errorf("Expected the DAG's name and schedule to be read, found %s", config.JSON())
+	}
+	if config.Retries != 1 || config.RetryDelay != 10 || *config.Catchup {
+		t.Errorf("Expected the DAG's default_args and catchup to be read, found %s", config.JSON())
+	}
+	expectedCommand := []string{"sh", "-c", "echo {{ .ExecutionDate | date \"2006-01-02\" }}"}
+	if !reflect.DeepEqual(config.Tasks[0].Command, expectedCommand) {
+		t.Errorf("Expected command %v, found %v", expectedCommand, config.Tasks[0].Command)
+	}
+	load := config.Tasks[1]
+	if load.DockerImage != "busybox" || !reflect.DeepEqual(load.DependsOn, []string{"extract"}) {
+		t.Errorf("Expected task load to run busybox after extract, found %v", load)
+	}
+	if !TABLECLIENT.IsDagPresent(config.Name, config.Namespace) {
+		t.Errorf("Expected DAG %s to be stored", config.Name)
+	}
+}
+
+func TestGetDAGsFromPythonFileWithUnsupportedConstruct(t *testing.T) {
+	defer database.PurgeDB(SQLCLIENT)
+	setUpDatabase()
+	folder, err := ioutil.TempDir("", "goflow-dags")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(folder)
+	dagFile := filepath.Join(folder, "airflow_dag.py")
+	code := "from airflow import DAG\n" +
+		"from airflow.operators.python import PythonOperator\n"
+	if err := ioutil.WriteFile(dagFile, []byte(code), 0644); err != nil {
+		panic(err)
+	}
+	_, err = GetDAGsFromFile(
+		dagFile,
+		fake.NewSimpleClientset(),
+		metrics.NewDAGMetricsClient(fake.NewSimpleClientset(), false),
+		goflowconfig.GoFlowConfig{DefaultNamespace: "default", MaxActiveRuns: 1},
+		make(ScheduleCache),
+		TABLECLIENT,
+		RUNTABLECLIENT,
+		TASKTABLECLIENT,
+	)
+	expected := "python: line 2: import of airflow.operators.python is not supported"
+	if err == nil || !strings.Contains(err.Error(), expected) {
+		t.Errorf("Expected error \"%s\", found %v", expected, err)
+	}
+}
+
+func TestRunAirflowExtractor(t *testing.T) {
+	const header = "from datetime import timedelta\nfrom airflow import DAG\n" +
+		"from airflow.operators.bash import BashOperator\n"
+	cases := []struct {
+		name          string
+		code          string
+		expectedError string
+	}{
+		{
+			"fan out and in",
+			"with DAG('fan', schedule_interval=timedelta(hours=1)) as dag:\n" +
+				"    a = BashOperator(task_id='a', bash_command='echo a')\n" +
+				"    b = BashOperator(task_id='b', bash_command='echo b')\n" +
+				"    c = BashOperator(task_id='c', bash_command='echo c')\n" +
+				"    a >> [b, c]\n    c << b\n",
+			"",
+		},
+		{
+			"task without a DAG",
+			"BashOperator(task_id='a', bash_command='echo a')\n",
+			"line 4: BashOperator must be given a dag or be created within one",
+		},
+		{
+			"unsupported template",
+			"dag = DAG('t')\nBashOperator(task_id='a', bash_command='{{ params.x }}', dag=dag)\n",
+			"line 5: template {{ params.x }} is not supported",
+		},
+		{"loop", "for i in range(3):\n    pass\n", "line 4: For statements are not supported"},
+	}
+	for _, testCase := range cases {
+		extraction, err := runAirflowExtractor([]byte(header+testCase.code), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := ""
+		if extraction.Error != "" {
+			found = fmt.Sprintf("line %d: %s", extraction.Line, extraction.Error)
+		}
+		if found != testCase.expectedError {
+			t.Errorf(
+				"Case %s: expected error \"%s\", found \"%s\"",
+				testCase.name,
+				testCase.expectedError,
+				found,
+			)
+			continue
+		}
+		if testCase.expectedError != "" {
+			continue
+		}
+		config := extraction.DAGs[0].Config
+		if config.Schedule != "every 3600s" {
+			t.Errorf("Expected an interval schedule, found %s", config.Schedule)
+		}
+		if c := config.Tasks[2]; !reflect.DeepEqual(c.DependsOn, []string{"a", "b"}) {
+			t.Errorf("Expected task c to depend on a and b, found %v", c.DependsOn)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package dagtype
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron"
+)
+
+const (
+	// onceScheduleSpec runs the DAG a single time, at its start date
+	onceScheduleSpec = "@once"
+	// intervalPrefix starts a schedule that runs at a fixed interval from the DAG's start date,
+	// such as "every 15m", written as a Go duration
+	intervalPrefix = "every "
+)
+
+// parseSchedule returns the schedule for the spec, which may be a cron expression with seconds, a
+// preset such as "@daily", "@once", or an interval such as "every 15m". Cron expressions and
+// presets are evaluated in the given location, while "@once" and intervals are counted from the
+// start date. An empty spec has no schedule and returns nil, for DAGs that are only run manually
+// or by their triggers.
+func parseSchedule(spec string, start time.Time, location *time.Location) (cron.Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	switch {
+	case spec == "":
+		return nil, nil
+	case spec == onceScheduleSpec:
+		return onceSchedule{start}, nil
+	case strings.HasPrefix(spec, intervalPrefix), strings.HasPrefix(spec, "@"+intervalPrefix):
+		interval, err := time.ParseDuration(
+			strings.TrimSpace(spec[strings.Index(spec, intervalPrefix)+len(intervalPrefix):]),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("schedule \"%s\" has an invalid interval: %s", spec, err.Error())
+		}
+		if interval < time.Second {
+			return nil, fmt.Errorf("schedule \"%s\" must have an interval of at least 1s", spec)
+		}
+		return intervalSchedule{start, interval}, nil
+	}
+	schedule, err := cron.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("schedule \"%s\" is not valid: %s", spec, err.Error())
+	}
+	return zonedSchedule{schedule, location}, nil
+}
+
+// onceSchedule is activated only at the start time
+type onceSchedule struct {
+	start time.Time
+}
+
+// Next returns the start time if the given time is before it, and the zero time otherwise
+func (schedule onceSchedule) Next(t time.Time) time.Time {
+	if t.Before(schedule.start) {
+		return schedule.start
+	}
+	return time.Time{}
+}
+
+// intervalSchedule is activated at the start time and after every interval from then on
+type intervalSchedule struct {
+	start    time.Time
+	interval time.Duration
+}
+
+// Next returns the first time after the given time that is a whole number of intervals after
+// the start time
+func (schedule intervalSchedule) Next(t time.Time) time.Time {
+	if t.Before(schedule.start) {
+		return schedule.start
+	}
+	intervals := t.Sub(schedule.start)/schedule.interval + 1
+	return schedule.start.Add(intervals * schedule.interval)
+}
+
+// zonedSchedule evaluates a cron schedule against the wall clock of a time zone. The wall clock
+// is followed in UTC, where there are no daylight saving transitions, so that a time skipped when
+// clocks go forward runs at the first time that exists after it, and a time repeated when clocks
+// go back runs only once.
+type zonedSchedule struct {
+	schedule cron.Schedule
+	location *time.Location
+}
+
+// wallClock returns the time in UTC with the same date and clock reading as the given time
+func wallClock(t time.Time) time.Time {
+	return time.Date(
+		t.Year(),
+		t.Month(),
+		t.Day(),
+		t.Hour(),
+		t.Minute(),
+		t.Second(),
+		t.Nanosecond(),
+		time.UTC,
+	)
+}
+
+// Next returns the next time on the schedule after the given time, in the schedule's location
+func (schedule zonedSchedule) Next(t time.Time) time.Time {
+	t = t.In(schedule.location)
+	wall := wallClock(t)
+	for {
+		wall = schedule.schedule.Next(wall)
+		if wall.IsZero() {
+			return wall
+		}
+		next := time.Date(
+			wall.Year(),
+			wall.Month(),
+			wall.Day(),
+			wall.Hour(),
+			wall.Minute(),
+			wall.Second(),
+			0,
+			schedule.location,
+		)
+		if next.After(t) {
+			return next
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package dagtype
+
+import (
+	"testing"
+	"time"
+
+	dagconfig "goflow/internal/dag/config"
+	"goflow/internal/database"
+
+	"github.com/robfig/cron"
+)
+
+func TestZonedScheduleAcrossDaylightSaving(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		panic(err)
+	}
+	cases := []struct {
+		name     string
+		spec     string
+		from     time.Time
+		expected []time.Time
+	}{
+		{
+			"clocks go forward",
+			"0 0 2 * * *",
+			time.Date(2021, 3, 27, 0, 0, 0, 0, berlin),
+			[]time.Time{
+				time.Date(2021, 3, 27, 1, 0, 0, 0, time.UTC),
+				time.Date(2021, 3, 28, 1, 0, 0, 0, time.UTC),
+				time.Date(2021, 3, 29, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+	for _, testCase := range cases {
+		spec, err := cron.Parse(testCase.spec)
+		if err != nil {
+			panic(err)
+		}
+		schedule := zonedSchedule{spec, berlin}
+		next := testCase.from
+		for _, expected := range testCase.expected {
+			next = schedule.Next(next)
+			if !next.Equal(expected) {
+				t.Errorf("Case %s: expected %s, found %s", testCase.name, expected, next.UTC())
+			}
+			if next.Location() != berlin {
+				t.Errorf("Case %s: expected a time in %s, found %s", testCase.name, berlin, next)
+			}
+		}
+	}
+}
+
+func TestZonedScheduleRunsRepeatedTimeOnce(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		panic(err)
+	}
+	spec, err := cron.Parse("0 30 2 * * *")
+	if err != nil {
+		panic(err)
+	}
+	schedule := zonedSchedule{spec, berlin}
+	first := schedule.Next(time.Date(2021, 10, 31, 0, 0, 0, 0, berlin))
+	if first.Day() != 31 || first.Hour() != 2 || first.Minute() != 30 {
+		t.Errorf("Expected a run at 02:30 on the 31st, found %s", first)
+	}
+	second := schedule.Next(first)
+	expected := time.Date(2021, 11, 1, 1, 30, 0, 0, time.UTC)
+	if !second.Equal(expected) {
+		t.Errorf("Expected the run after %s at %s, found %s", first, expected, second.UTC())
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		spec        string
+		expectError bool
+		expected    []time.Time
+	}{
+		{"", false, nil},
+		{"@once", false, []time.Time{start, {}}},
+		{"@hourly", false, []time.Time{start.Add(time.Hour), start.Add(2 * time.Hour)}},
+		{"@daily", false, []time.Time{start.AddDate(0, 0, 1), start.AddDate(0, 0, 2)}},
+		{"@weekly", false, []time.Time{start.AddDate(0, 0, 2), start.AddDate(0, 0, 9)}},
+		{"@monthly", false, []time.Time{start.AddDate(0, 1, 0), start.AddDate(0, 2, 0)}},
+		{"@yearly", false, []time.Time{start.AddDate(1, 0, 0), start.AddDate(2, 0, 0)}},
+		{
+			"every 15m",
+			false,
+			[]time.Time{start.Add(15 * time.Minute), start.Add(30 * time.Minute)},
+		},
+		{"@every 1h30m", false, []time.Time{start.Add(90 * time.Minute), start.Add(3 * time.Hour)}},
+		{"every 15", true, nil},
+		{"every 0s", true, nil},
+		{"@fortnightly", true, nil},
+		{"0 0 25 * * *", true, nil},
+	}
+	for _, testCase := range cases {
+		schedule, err := parseSchedule(testCase.spec, start, time.UTC)
+		if (err != nil) != testCase.expectError {
+			t.Errorf(
+				"Schedule \"%s\": expected error %t, found %v",
+				testCase.spec,
+				testCase.expectError,
+				err,
+			)
+			continue
+		}
+		if testCase.expected == nil {
+			if schedule != nil && !testCase.expectError {
+				t.Errorf("Expected no schedule for \"%s\"", testCase.spec)
+			}
+			continue
+		}
+		next := start
+		if testCase.spec == "@once" {
+			next = start.Add(-time.Second)
+		}
+		for _, expected := range testCase.expected {
+			next = schedule.Next(next)
+			if !next.Equal(expected) {
+				t.Errorf("Schedule \"%s\": expected %s, found %s", testCase.spec, expected, next)
+			}
+		}
+	}
+}
+
+func TestIntervalScheduleIsCountedFromStart(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 5, 0, 0, time.UTC)
+	schedule := intervalSchedule{start, 15 * time.Minute}
+	next := schedule.Next(time.Date(2021, 1, 1, 1, 0, 0, 0, time.UTC))
+	expected := time.Date(2021, 1, 1, 1, 5, 0, 0, time.UTC)
+	if !next.Equal(expected) {
+		t.Errorf("Expected %s, found %s", expected, next)
+	}
+}
+
+func TestCreateDAGInTimezone(t *testing.T) {
+	defer database.PurgeDB(SQLCLIENT)
+	setUpDatabase()
+	dag, err := CreateDAG(&dagconfig.DAGConfig{
+		Name:          "test-timezone",
+		Namespace:     "default",
+		Schedule:      "0 0 2 * * *",
+		Timezone:      "Europe/Berlin",
+		MaxActiveRuns: 1,
+		StartDateTime: "2021-03-27",
+		EndDateTime:   "2021-04-01T00:00:00Z",
+	}, "", getNewTestClient(), make(ScheduleCache), TABLECLIENT, "path", RUNTABLECLIENT,
+		TASKTABLECLIENT, false)
+	if err != nil {
+		panic(err)
+	}
+	expectedStart := time.Date(2021, 3, 26, 23, 0, 0, 0, time.UTC)
+	if !dag.StartDateTime.Equal(expectedStart) {
+		t.Errorf("Expected start %s, found %s", expectedStart, dag.StartDateTime.UTC())
+	}
+	if dag.StartDateTime.Location().String() != "Europe/Berlin" {
+		t.Errorf("Expected start in Europe/Berlin, found %s", dag.StartDateTime.Location())
+	}
+	expectedEnd := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	if !dag.EndDateTime.Equal(expectedEnd) {
+		t.Errorf("Expected end %s, found %s", expectedEnd, dag.EndDateTime.UTC())
+	}
+	next := dag.getNextTime(dag.StartDateTime)
+	expectedNext := time.Date(2021, 3, 27, 1, 0, 0, 0, time.UTC)
+	if !next.Equal(expectedNext) {
+		t.Errorf("Expected next run at %s, found %s", expectedNext, next.UTC())
+	}
+}
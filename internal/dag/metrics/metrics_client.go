@@ -100,6 +100,7 @@ func (client *DAGMetricsClient) GetPodMetrics(
 	pod core.Pod,
 ) (PodMetrics, error) {
 	metrics := newPodMetrics(pod.Name)
+	podLog := logs.With(logs.Fields{logs.PodField: pod.Name, logs.NamespaceField: pod.Namespace})
 	hasActiveContainers := false
 	for _, containerStatus := range pod.Status.ContainerStatuses {
 		containerStarted := *containerStatus.Started
@@ -117,12 +118,20 @@ func (client *DAGMetricsClient) GetPodMetrics(
 
 		memory, err := getContainerMemory(options)
 		if err != nil {
-			logs.WarningLogger.Println("Error retrieving memory from container", err)
+			podLog.Warningf(
+				"Error retrieving memory from container %s: %s",
+				containerStatus.Name,
+				err,
+			)
 			continue
 		}
 		cpu, err := getContainerCPU(options)
 		if err != nil {
-			logs.WarningLogger.Println("Error retrieving CPU from container", err)
+			podLog.Warningf(
+				"Error retrieving CPU from container %s: %s",
+				containerStatus.Name,
+				err,
+			)
 			continue
 		}
 		metrics.Memory += memory
@@ -0,0 +1,223 @@
+package orchestrator
+
+import (
+	"crypto/sha256"
+	"fmt"
+	dagtype "goflow/internal/dag/dagtype"
+	"goflow/internal/logs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// dagFileDebounce is how long the DAG folder must go without changes before the changed files are
+// read, so that a file being written is read once it is complete
+const dagFileDebounce = 500 * time.Millisecond
+
+// dagFile is what was last read from a DAG file
+type dagFile struct {
+	hash     string   // The sha256 hash of the file's contents
+	dagNames []string // The DAGs declared by the file, which are empty if the file never loaded
+	err      error    // The error that kept the file from loading, if any
+}
+
+// fileHash returns the hex encoded sha256 hash of a file's contents
+func fileHash(contents []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(contents))
+}
+
+// collectFile collects the DAGs of the file at the given path if the file's contents changed since
+// it was last read. The DAGs of a file that fails to load are kept as they were, and the DAGs that
+// the file no longer declares are removed.
+func (orchestrator *Orchestrator) collectFile(filePath string) {
+	contents, err := ioutil.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		orchestrator.removeFile(filePath)
+		return
+	}
+	stored, tracked := orchestrator.dagFiles[filePath]
+	if err != nil {
+		stored.hash, stored.err = "", err
+		orchestrator.dagFiles[filePath] = stored
+		return
+	}
+	hash := fileHash(contents)
+	if tracked && stored.hash == hash {
+		return
+	}
+	dags, err := dagtype.GetDAGsFromFile(
+		filePath,
+		orchestrator.kubeClient,
+		orchestrator.metricsClient,
+		*orchestrator.config,
+		orchestrator.schedules,
+		orchestrator.dagTableClient,
+		orchestrator.dagrunTableClient,
+		orchestrator.taskTableClient,
+	)
+	if os.IsNotExist(err) {
+		orchestrator.removeFile(filePath)
+		return
+	}
+	stored.hash, stored.err = hash, err
+	if err != nil {
+		orchestrator.dagFiles[filePath] = stored
+		return
+	}
+	previousNames := stored.dagNames
+	stored.dagNames = make([]string, 0, len(dags))
+	for _, dag := range dags {
+		stored.dagNames = append(stored.dagNames, dag.Config.Name)
+	}
+	orchestrator.dagFiles[filePath] = stored
+	for _, previousName := range previousNames {
+		orchestrator.removeDAGOfFile(previousName)
+	}
+	for _, dag := range dags {
+		orchestrator.collectDAG(dag)
+	}
+}
+
+// removeFile stops tracking a DAG file that is gone, removing the DAGs that it declared
+func (orchestrator *Orchestrator) removeFile(filePath string) {
+	stored, tracked := orchestrator.dagFiles[filePath]
+	if !tracked {
+		return
+	}
+	delete(orchestrator.dagFiles, filePath)
+	for _, dagName := range stored.dagNames {
+		orchestrator.removeDAGOfFile(dagName)
+	}
+}
+
+// removeFilesUnder removes every tracked DAG file at or within the given path
+func (orchestrator *Orchestrator) removeFilesUnder(path string) {
+	for filePath := range orchestrator.dagFiles {
+		if filePath == path || strings.HasPrefix(filePath, path+string(filepath.Separator)) {
+			orchestrator.removeFile(filePath)
+		}
+	}
+}
+
+// removeDAGOfFile deletes a DAG that a file no longer declares, unless a file still declares it
+func (orchestrator *Orchestrator) removeDAGOfFile(dagName string) {
+	for _, stored := range orchestrator.dagFiles {
+		for _, declaredName := range stored.dagNames {
+			if declaredName == dagName {
+				return
+			}
+		}
+	}
+	dag := orchestrator.GetDag(dagName)
+	if dag == nil {
+		return
+	}
+	orchestrator.DeleteDAG(dagName, dag.Config.Namespace)
+}
+
+// currentImportErrors returns the errors of the DAG files that failed to load when they were last
+// read, along with those of the folders that could not be read
+func (orchestrator *Orchestrator) currentImportErrors() dagtype.ImportErrors {
+	importErrors := make(dagtype.ImportErrors, len(orchestrator.walkErrors))
+	for path, err := range orchestrator.walkErrors {
+		importErrors[path] = err
+	}
+	for filePath, stored := range orchestrator.dagFiles {
+		if stored.err != nil {
+			importErrors[filePath] = stored.err
+		}
+	}
+	return importErrors
+}
+
+// collectChangedPaths collects the DAGs of the changed DAG files at the given paths, reading all
+// the DAG files within any folder that was added, and removes the DAGs of the files that are gone
+func (orchestrator *Orchestrator) collectChangedPaths(watcher *fsnotify.Watcher, paths []string) {
+	orchestrator.collectLock.Lock()
+	defer orchestrator.collectLock.Unlock()
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		switch {
+		case os.IsNotExist(err):
+			orchestrator.removeFilesUnder(path)
+		case err == nil && info.IsDir():
+			addFolderWatches(watcher, path)
+			files, _ := dagtype.DAGFiles(path)
+			for _, filePath := range files {
+				orchestrator.collectFile(filePath)
+			}
+		case dagtype.IsDAGFile(path):
+			orchestrator.collectFile(path)
+		}
+	}
+	orchestrator.storeImportErrors(orchestrator.currentImportErrors())
+}
+
+// addFolderWatches watches the folder and every folder within it for changes
+func addFolderWatches(watcher *fsnotify.Watcher, folder string) {
+	filepath.Walk(folder, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+		if err := watcher.Add(path); err != nil {
+			logs.With(logs.Fields{logs.FileField: path}).Warningf("Could not watch folder: %s", err)
+		}
+		return nil
+	})
+}
+
+// watchDAGFolder collects the DAGs of the files in the DAG folder as they change, once the folder
+// has gone without changes for the debounce time, and reads the whole folder again every resync
+// interval in case a change was missed. The folder is read every cycle if it cannot be watched.
+func (orchestrator *Orchestrator) watchDAGFolder(cycleDuration time.Duration) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		logs.Errorf(
+			"Could not watch the DAG folder, reading it every %s instead: %s",
+			cycleDuration,
+			err,
+		)
+		cycleUntilChannelClose(
+			orchestrator.CollectDAGs,
+			orchestrator.closingChannel,
+			cycleDuration,
+			"Collect DAGs",
+		)
+		return
+	}
+	defer watcher.Close()
+	addFolderWatches(watcher, orchestrator.config.DAGPath)
+	orchestrator.CollectDAGs()
+	resync := time.NewTicker(orchestrator.config.DAGResyncDuration())
+	defer resync.Stop()
+	changed := make(map[string]bool)
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-orchestrator.closingChannel:
+			logs.Infof("Closing Watch DAG folder")
+			return
+		case event := <-watcher.Events:
+			changed[event.Name] = true
+			debounce = time.After(dagFileDebounce)
+		case err := <-watcher.Errors:
+			logs.Errorf("Error watching the DAG folder, reading it again: %s", err)
+			orchestrator.CollectDAGs()
+		case <-debounce:
+			paths := make([]string, 0, len(changed))
+			for path := range changed {
+				paths = append(paths, path)
+			}
+			orchestrator.collectChangedPaths(watcher, paths)
+			changed = make(map[string]bool)
+			debounce = nil
+		case <-resync.C:
+			addFolderWatches(watcher, orchestrator.config.DAGPath)
+			orchestrator.CollectDAGs()
+		}
+	}
+}
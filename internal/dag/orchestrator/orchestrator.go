@@ -8,11 +8,12 @@ import (
 	"goflow/internal/dag/metrics"
 	dagrun "goflow/internal/dag/run"
 	"goflow/internal/database"
-	"goflow/internal/jsonpanic"
+	"goflow/internal/executor"
 	"goflow/internal/logs"
 	"net/http"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"strings"
 	"sync"
@@ -21,7 +22,9 @@ import (
 	"goflow/internal/config"
 	dagtable "goflow/internal/dag/sql/dag"
 	dagruntable "goflow/internal/dag/sql/dagrun"
+	importerrortable "goflow/internal/dag/sql/importerror"
 	metricstable "goflow/internal/dag/sql/metrics"
+	taskinstancetable "goflow/internal/dag/sql/taskinstance"
 	k8sclient "goflow/internal/k8s/client"
 	"goflow/internal/k8s/pod/event/holder"
 	"goflow/internal/k8s/pod/inform"
@@ -46,8 +49,27 @@ type Orchestrator struct {
 	closingChannel     chan struct{}
 	dagTableClient     *dagtable.TableClient
 	dagrunTableClient  *dagruntable.TableClient
+	taskTableClient    *taskinstancetable.TableClient
 	metricsClient      *metrics.DAGMetricsClient
 	metricsTableClient *metricstable.TableClient
+	executor           executor.Executor
+	importErrorClient  *importerrortable.TableClient
+	importErrors       map[string]string  // The import error of each DAG file, as last stored
+	dagFiles           map[string]dagFile // What was last read from each DAG file, by path
+	walkErrors         dagtype.ImportErrors
+	collectLock        *sync.Mutex
+}
+
+// newExecutor returns the executor that launches tasks as set in the goflow config
+func newExecutor(
+	client kubernetes.Interface,
+	config *config.GoFlowConfig,
+	channelHolder *holder.ChannelHolder,
+) executor.Executor {
+	if config.UsesLocalExecutor() {
+		return executor.NewLocalExecutor(config.LocalContainerRuntime)
+	}
+	return executor.NewKubernetesExecutor(client, channelHolder)
 }
 
 // NewOrchestratorFromClientsAndConfig creates an orchestractor from a given k8s client and goflow config
@@ -57,18 +79,26 @@ func NewOrchestratorFromClientsAndConfig(
 	metricsClient *metrics.DAGMetricsClient,
 ) *Orchestrator {
 	sqlClient := database.NewSQLiteClient(config.DatabaseDNS)
+	channelHolder := holder.New()
 	return &Orchestrator{
 		&sync.RWMutex{},
 		make(map[string]*dagtype.DAG),
 		client,
 		config,
-		holder.New(),
+		channelHolder,
 		make(dagtype.ScheduleCache),
 		make(chan struct{}),
 		dagtable.NewTableClient(sqlClient),
 		dagruntable.NewTableClient(sqlClient),
+		taskinstancetable.NewTableClient(sqlClient),
 		metricsClient,
 		metricstable.NewTableClient(sqlClient),
+		newExecutor(client, config, channelHolder),
+		importerrortable.NewTableClient(sqlClient),
+		nil,
+		make(map[string]dagFile),
+		make(dagtype.ImportErrors),
+		&sync.Mutex{},
 	}
 }
 
@@ -84,31 +114,42 @@ func NewOrchestrator(configPath string) *Orchestrator {
 
 // AddDAG adds a DAG to the Orchestrator
 func (orchestrator *Orchestrator) AddDAG(dag *dagtype.DAG) {
-	logs.InfoLogger.Printf(
-		"Added DAG '%s' which will run in namespace '%s', with configuration: %s",
+	logs.With(logs.Fields{
+		logs.DAGField:       dag.Config.Name,
+		logs.NamespaceField: dag.Config.Namespace,
+		"config":            dag.Config.Redacted(),
+	}).Infof(
+		"Added DAG '%s' which will run in namespace '%s'",
 		dag.Config.Name,
 		dag.Config.Namespace,
-		jsonpanic.JSONPanicFormat(dag.Config),
 	)
 	dag.LastUpdated = time.Now()
+	orchestrator.attachDAG(dag)
 	orchestrator.dagMapLock.Lock()
 	orchestrator.dagMap[dag.Config.Name] = dag
 	orchestrator.dagMapLock.Unlock()
 }
 
-// UpdateDag updates a DAG to the most recently found configuration
+// attachDAG sets the executor that launches the DAG's tasks and the handler that triggers
+// downstream DAGs once its runs finish
+func (orchestrator *Orchestrator) attachDAG(dag *dagtype.DAG) {
+	dag.SetExecutor(orchestrator.executor)
+	dag.SetRunFinishedHandler(orchestrator.triggerDownstream)
+}
+
+// UpdateDag updates a DAG to the most recently found configuration, along with the code, time
+// zone and dates read from it
 func (orchestrator *Orchestrator) UpdateDag(dag *dagtype.DAG) {
 	orchestrator.dagMapLock.Lock()
 	dagRef := orchestrator.dagMap[dag.Config.Name]
 	dagRef.LastUpdated = time.Now()
-	logs.InfoLogger.Printf(
-		"Updating DAG '%s' from namespace '%s', from configuration %s to configuration %s",
-		dag.Config.Name,
-		dag.Config.Namespace,
-		dagRef.Config,
-		dag.Config,
-	)
-	dagRef.Config = dag.Config
+	logs.With(logs.Fields{
+		logs.DAGField:       dag.Config.Name,
+		logs.NamespaceField: dag.Config.Namespace,
+		"old_config":        dagRef.Config.Redacted(),
+		"config":            dag.Config.Redacted(),
+	}).Infof("Updating DAG '%s' from namespace '%s'", dag.Config.Name, dag.Config.Namespace)
+	dagRef.Update(dag)
 	orchestrator.dagMapLock.Unlock()
 }
 
@@ -124,11 +165,26 @@ func (orchestrator *Orchestrator) addDAGServiceAccount(dag *dagtype.DAG) {
 	serviceAccountHandler.Create()
 }
 
-// DeleteDAG removes a DAG from the orchestrator
+// DeleteDAG removes a DAG from the orchestrator so that it is no longer scheduled, and marks it as
+// inactive while keeping the records of its runs. Its runs in progress are left to finish, unless
+// the goflow config sets them to be terminated.
 func (orchestrator *Orchestrator) DeleteDAG(dagName string, namespace string) {
-	dag := orchestrator.dagMap[dagName]
-	dag.TerminateAndDeleteRuns()
+	orchestrator.dagMapLock.Lock()
+	dag, ok := orchestrator.dagMap[dagName]
 	delete(orchestrator.dagMap, dagName)
+	orchestrator.dagMapLock.Unlock()
+	if !ok {
+		return
+	}
+	logs.With(logs.Fields{logs.DAGField: dagName, logs.NamespaceField: namespace}).Infof(
+		"Removed DAG '%s' from namespace '%s'",
+		dagName,
+		namespace,
+	)
+	dag.Deactivate()
+	if orchestrator.config.TerminateRemovedDAGRuns {
+		dag.TerminateRuns()
+	}
 }
 
 // DAGs returns []DAGs with all DAGs present in the map
@@ -175,29 +231,118 @@ func (orchestrator *Orchestrator) collectDAG(dag *dagtype.DAG) {
 	dagPresent := orchestrator.isDagPresent(*dag)
 	if !dagPresent {
 		orchestrator.addDAGServiceAccount(dag)
+		orchestrator.attachDAG(dag)
+		dag.RestoreRuns(orchestrator.channelHolder)
 		orchestrator.AddDAG(dag)
 	} else if dagPresent && orchestrator.isStoredDagDifferent(*dag) {
-		logs.InfoLogger.Printf("Updating DAG %s which will run in namespace %s", dag.Config.Name, dag.Config.Namespace)
-		logs.InfoLogger.Printf("Old DAG code: %s\n", orchestrator.GetDag(dag.Config.Name).Code)
-		logs.InfoLogger.Printf("New DAG code: %s\n", dag.Code)
+		logs.With(logs.Fields{
+			logs.DAGField:       dag.Config.Name,
+			logs.NamespaceField: dag.Config.Namespace,
+			"old_code_hash":     fileHash([]byte(orchestrator.GetDag(dag.Config.Name).Code)),
+			"code_hash":         fileHash([]byte(dag.Code)),
+		}).Debugf("The code of DAG %s has changed", dag.Config.Name)
 		orchestrator.UpdateDag(dag)
 	}
 }
 
-// CollectDAGs fills up the dag map with existing dags
+// CollectDAGs reads the whole DAG folder, collecting the DAGs of the files that changed since they
+// were last read and removing the DAGs of the files that are gone, then stores the errors that
+// kept any DAG files from being loaded
 func (orchestrator *Orchestrator) CollectDAGs() {
-	dagSlice := dagtype.GetDAGSFromFolder(
-		orchestrator.config.DAGPath,
-		orchestrator.kubeClient,
-		orchestrator.metricsClient,
-		*orchestrator.config,
-		orchestrator.schedules,
-		orchestrator.dagTableClient,
-		orchestrator.dagrunTableClient,
-	)
-	for _, dag := range dagSlice {
-		orchestrator.collectDAG(dag)
+	orchestrator.collectLock.Lock()
+	defer orchestrator.collectLock.Unlock()
+	files, walkErrors := dagtype.DAGFiles(orchestrator.config.DAGPath)
+	orchestrator.walkErrors = walkErrors
+	found := make(map[string]bool, len(files))
+	for _, filePath := range files {
+		found[filePath] = true
+		orchestrator.collectFile(filePath)
+	}
+	if _, err := os.Stat(orchestrator.config.DAGPath); err == nil {
+		for filePath := range orchestrator.dagFiles {
+			if !found[filePath] && !failedToLoad(filePath, walkErrors) {
+				orchestrator.removeFile(filePath)
+			}
+		}
+	}
+	orchestrator.storeImportErrors(orchestrator.currentImportErrors())
+}
+
+// failedToLoad returns true if there is an import error for the file, or for a folder it is in
+func failedToLoad(filePath string, importErrors dagtype.ImportErrors) bool {
+	for errorPath := range importErrors {
+		if filePath == errorPath ||
+			strings.HasPrefix(filePath, errorPath+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// storeImportErrors replaces the stored import errors with the given ones, if they have changed
+// since they were last stored
+func (orchestrator *Orchestrator) storeImportErrors(importErrors dagtype.ImportErrors) {
+	messages := make(map[string]string, len(importErrors))
+	for filePath, err := range importErrors {
+		messages[filePath] = err.Error()
+	}
+	if reflect.DeepEqual(messages, orchestrator.importErrors) {
+		return
+	}
+	rows := make([]importerrortable.Row, 0, len(messages))
+	for filePath, message := range messages {
+		if orchestrator.importErrors[filePath] != message {
+			logs.With(logs.Fields{logs.FileField: filePath}).Errorf(
+				"DAG file %s could not be loaded: %s",
+				filePath,
+				message,
+			)
+		}
+		rows = append(rows, importerrortable.NewRow(filePath, message))
+	}
+	orchestrator.importErrorClient.ReplaceImportErrors(rows)
+	orchestrator.importErrors = messages
+}
+
+// ImportErrors returns the stored errors that kept DAG files from being loaded
+func (orchestrator *Orchestrator) ImportErrors() importerrortable.RowList {
+	return orchestrator.importErrorClient.GetImportErrors()
+}
+
+// Backfill starts runs of the given DAG for every scheduled time from start to end, inclusive,
+// returning the execution dates of the runs along with the matching http status
+func (orchestrator *Orchestrator) Backfill(
+	dagName string,
+	start time.Time,
+	end time.Time,
+) ([]time.Time, int, error) {
+	dag := orchestrator.GetDag(dagName)
+	if dag == nil {
+		return nil, http.StatusNotFound, fmt.Errorf("Given DAG not present")
+	}
+	executionDates, err := dag.Backfill(start, end, orchestrator.channelHolder)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
 	}
+	return executionDates, http.StatusOK, nil
+}
+
+// Trigger starts a manual run of the given DAG for the execution date, returning the run along
+// with the matching http status
+func (orchestrator *Orchestrator) Trigger(
+	dagName string,
+	executionDate time.Time,
+	conf map[string]string,
+) (*dagrun.DAGRun, int, error) {
+	dag := orchestrator.GetDag(dagName)
+	if dag == nil {
+		return nil, http.StatusNotFound, fmt.Errorf("Given DAG not present")
+	}
+	dagRun, err := dag.Trigger(executionDate, conf, orchestrator.channelHolder)
+	if err != nil {
+		return nil, http.StatusConflict, err
+	}
+	return dagRun, http.StatusCreated, nil
 }
 
 // RunDags schedules pods for all dags that are ready
@@ -217,7 +362,7 @@ func cycleUntilChannelClose(
 		select {
 		case _, ok := <-close:
 			if !ok {
-				logs.InfoLogger.Printf("Closing %s\n", loopName)
+				logs.Infof("Closing %s", loopName)
 				return
 			}
 		default:
@@ -234,7 +379,19 @@ func (orchestrator *Orchestrator) getTaskInformer() inform.TaskInformer {
 func (orchestrator *Orchestrator) setupDatabaseTables() {
 	orchestrator.dagTableClient.CreateTable()
 	orchestrator.dagrunTableClient.CreateTable()
+	orchestrator.taskTableClient.CreateTable()
 	orchestrator.metricsTableClient.CreateTable()
+	orchestrator.importErrorClient.CreateTable()
+}
+
+// SetupDatabase creates the tables that goflow keeps its state in, adding the columns that tables
+// created by an earlier version of goflow are missing, without starting an orchestrator
+func SetupDatabase(sqlClient *database.SQLClient) {
+	dagtable.NewTableClient(sqlClient).CreateTable()
+	dagruntable.NewTableClient(sqlClient).CreateTable()
+	taskinstancetable.NewTableClient(sqlClient).CreateTable()
+	metricstable.NewTableClient(sqlClient).CreateTable()
+	importerrortable.NewTableClient(sqlClient).CreateTable()
 }
 
 // Start begins the orchestrator event loop
@@ -242,12 +399,7 @@ func (orchestrator *Orchestrator) Start(cycleDuration time.Duration) {
 	orchestrator.setupDatabaseTables()
 	taskInformer := orchestrator.getTaskInformer()
 	taskInformer.Start()
-	go cycleUntilChannelClose(
-		orchestrator.CollectDAGs,
-		orchestrator.closingChannel,
-		cycleDuration,
-		"Collect DAGs",
-	)
+	go orchestrator.watchDAGFolder(cycleDuration)
 	go cycleUntilChannelClose(
 		orchestrator.RunDags,
 		orchestrator.closingChannel,
@@ -292,11 +444,16 @@ func (orchestrator *Orchestrator) WriteDAGFile(config *dagconfig.DAGConfig) (int
 	return http.StatusOK, err
 }
 
+// extractDAGFromPodName returns the name of the DAG that the pod was run for, or an empty string
+// if the pod's name does not hold a DAG run's execution date, as with names that were shortened
 func extractDAGFromPodName(podName string) string {
 	re := regexp.MustCompile(`(?P<name>.*)-\d{4}-\d{2}-\d{4}-\d{2}-\d{2}plus\d{4}utc`)
 	matches := re.FindStringSubmatch(podName)
 	if len(matches) < 2 {
-		logs.ErrorLogger.Println("Error in DAG name extraction from pod")
+		logs.With(logs.Fields{logs.PodField: podName}).Errorf(
+			"Error in DAG name extraction from pod",
+		)
+		return ""
 	}
 	return matches[1]
 }
@@ -1,11 +1,21 @@
 package orchestrator
 
 import (
+	"bytes"
 	dagconfig "goflow/internal/dag/config"
 	"goflow/internal/dag/metrics"
+	dagrun "goflow/internal/dag/run"
 	"goflow/internal/database"
+	"goflow/internal/executor"
+	"goflow/internal/logs"
 	"goflow/internal/testutils"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"goflow/internal/config"
 	"goflow/internal/dag/dagtype"
@@ -57,7 +67,7 @@ func getTestDAG(orch *Orchestrator) dagtype.DAG {
 		StartDateTime: "2019-01-01",
 		EndDateTime:   "",
 	}
-	return dagtype.CreateDAG(
+	dag, err := dagtype.CreateDAG(
 		config,
 		config.String(),
 		orch.kubeClient,
@@ -65,14 +75,19 @@ func getTestDAG(orch *Orchestrator) dagtype.DAG {
 		orch.dagTableClient,
 		"path",
 		orch.dagrunTableClient,
+		orch.taskTableClient,
 		true,
 	)
+	if err != nil {
+		panic(err)
+	}
+	return dag
 }
 
 func TestRegisterDAG(t *testing.T) {
 	defer database.PurgeDB(sqlClient)
 	orch := testOrchestrator()
-	orch.dagTableClient.CreateTable()
+	orch.setupDatabaseTables()
 	dag := getTestDAG(orch)
 	const expectedLength = 1
 	orch.AddDAG(&dag)
@@ -96,7 +111,7 @@ func getDagWithDifferentDockerImage(orch *Orchestrator) dagtype.DAG {
 func TestDAGUpdate(t *testing.T) {
 	defer database.PurgeDB(sqlClient)
 	orch := testOrchestrator()
-	orch.dagTableClient.CreateTable()
+	orch.setupDatabaseTables()
 	dag := getTestDAG(orch)
 	orch.AddDAG(&dag)
 	updatedDAG := getDagWithDifferentDockerImage(orch)
@@ -106,10 +121,81 @@ func TestDAGUpdate(t *testing.T) {
 	}
 }
 
+func TestDAGUpdateTimezoneAndDates(t *testing.T) {
+	defer database.PurgeDB(sqlClient)
+	orch := testOrchestrator()
+	orch.setupDatabaseTables()
+	dag := getTestDAG(orch)
+	orch.AddDAG(&dag)
+	updatedConfig := dag.Config.Copy()
+	updatedConfig.Timezone = "Europe/Berlin"
+	updatedConfig.StartDateTime = "2020-01-01"
+	updatedConfig.EndDateTime = "2021-01-01"
+	updatedDAG, err := dagtype.CreateDAG(
+		&updatedConfig,
+		updatedConfig.String(),
+		orch.kubeClient,
+		make(dagtype.ScheduleCache),
+		orch.dagTableClient,
+		"path",
+		orch.dagrunTableClient,
+		orch.taskTableClient,
+		true,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	orch.UpdateDag(&updatedDAG)
+
+	found := orch.GetDag(dag.Config.Name)
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	if found.Location().String() != berlin.String() {
+		t.Errorf("Expected the time zone to be updated to %s, found %s", berlin, found.Location())
+	}
+	if !found.StartDateTime.Equal(time.Date(2020, 1, 1, 0, 0, 0, 0, berlin)) ||
+		!found.EndDateTime.Equal(time.Date(2021, 1, 1, 0, 0, 0, 0, berlin)) {
+		t.Errorf(
+			"Expected the dates to be updated, found %s and %s",
+			found.StartDateTime,
+			found.EndDateTime,
+		)
+	}
+	if found.Code != updatedDAG.Code {
+		t.Error("Expected the code of the DAG to be updated")
+	}
+}
+
+func TestCollectDagDoesNotLogEnv(t *testing.T) {
+	defer database.PurgeDB(sqlClient)
+	output := &bytes.Buffer{}
+	logs.SetOutput(output)
+	logs.SetLevel(logs.DebugLevel)
+	defer func() {
+		logs.SetOutput(os.Stdout)
+		logs.SetLevel(logs.InfoLevel)
+	}()
+	orch := testOrchestrator()
+	orch.setupDatabaseTables()
+	dag := getTestDAG(orch)
+	dag.Config.Env = map[string]string{"PASSWORD": "hunter2"}
+	dag.Code = dag.Config.String()
+	orch.AddDAG(&dag)
+	updatedDAG := getDagWithDifferentDockerImage(orch)
+	updatedDAG.Config.Env = map[string]string{"PASSWORD": "hunter3"}
+	updatedDAG.Code = updatedDAG.Config.String()
+	orch.collectDAG(&updatedDAG)
+	if !strings.Contains(output.String(), "code_hash") {
+		t.Errorf("Expected the change of code to be logged, found\n%s", output.String())
+	}
+	if strings.Contains(output.String(), "hunter") {
+		t.Errorf("Expected the env values not to be logged, found\n%s", output.String())
+	}
+}
+
 func TestCollectDagUpdatedTime(t *testing.T) {
 	defer database.PurgeDB(sqlClient)
 	orch := testOrchestrator()
-	orch.dagTableClient.CreateTable()
+	orch.setupDatabaseTables()
 	dag := getTestDAG(orch)
 	orch.collectDAG(&dag)
 	addedTime := dag.LastUpdated
@@ -128,12 +214,15 @@ func TestCollectDagUpdatedTime(t *testing.T) {
 func TestUpdateDAGWhileRunning(t *testing.T) {
 	defer database.PurgeDB(sqlClient)
 	orch := testOrchestrator()
-	orch.dagTableClient.CreateTable()
-	orch.dagrunTableClient.CreateTable()
+	orch.setupDatabaseTables()
 	dag := getTestDAG(orch)
 	dag.IsOn = true
 	orch.collectDAG(&dag)
 	dag.AddNextDagRunIfReady(orch.channelHolder)
+	task := dag.DAGRuns[0].Tasks[0]
+	for task.GetState() != dagrun.TaskQueued {
+		time.Sleep(time.Millisecond)
+	}
 	updatedDAG := getDagWithDifferentDockerImage(orch)
 	updatedDAG.IsOn = true
 	orch.collectDAG(&updatedDAG)
@@ -146,7 +235,7 @@ func TestUpdateDAGWhileRunning(t *testing.T) {
 func TestCollectDags(t *testing.T) {
 	defer database.PurgeDB(sqlClient)
 	orch := testOrchestrator()
-	orch.dagTableClient.CreateTable()
+	orch.setupDatabaseTables()
 	orch.CollectDAGs()
 	dagCount := len(orch.DAGs())
 	if dagCount == 0 {
@@ -158,3 +247,409 @@ func TestCollectDags(t *testing.T) {
 		}
 	}
 }
+
+func TestCollectDagsStoresImportErrors(t *testing.T) {
+	defer database.PurgeDB(sqlClient)
+	folder, err := ioutil.TempDir("", "goflow-dags")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(folder)
+	dagFile := filepath.Join(folder, "broken_dag.json")
+	writeDAGFile := func(content []byte) {
+		err := ioutil.WriteFile(dagFile, content, 0644)
+		if err != nil {
+			panic(err)
+		}
+	}
+	writeDAGFile([]byte(`{"Name": "test-broken", "MaxActiveRuns": 0}`))
+	orch := testOrchestrator()
+	orch.config.DAGPath = folder
+	orch.setupDatabaseTables()
+	orch.CollectDAGs()
+	importErrors := orch.ImportErrors()
+	if len(importErrors) != 1 || importErrors[0].FilePath != dagFile {
+		t.Errorf("Expected an import error for %s, found %s", dagFile, importErrors)
+	}
+	if len(orch.DAGs()) != 0 {
+		t.Errorf("Expected no DAGs to be collected, found %d", len(orch.DAGs()))
+	}
+	writeDAGFile(dagconfig.DAGConfig{
+		Name:          "test-broken",
+		Schedule:      "* * * * *",
+		StartDateTime: "2019-01-01",
+		MaxActiveRuns: 1,
+	}.Marshal())
+	orch.CollectDAGs()
+	if importErrors := orch.ImportErrors(); len(importErrors) != 0 {
+		t.Errorf("Expected import errors to be cleared once fixed, found %s", importErrors)
+	}
+	if orch.GetDag("test-broken") == nil {
+		t.Error("Expected fixed DAG test-broken to be collected")
+	}
+}
+
+func waitForRunningTask(t *testing.T, dagRun *dagrun.DAGRun) {
+	deadline := time.Now().Add(5 * time.Second)
+	for dagRun.Tasks[0].GetState() != dagrun.TaskRunning {
+		if time.Now().After(deadline) {
+			t.Fatalf("task of dag run %s did not start running", dagRun.Name)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCollectDagsRemovesDeletedDAG(t *testing.T) {
+	for _, terminateRuns := range []bool{false, true} {
+		func() {
+			defer database.PurgeDB(sqlClient)
+			folder, err := ioutil.TempDir("", "goflow-dags")
+			if err != nil {
+				panic(err)
+			}
+			defer os.RemoveAll(folder)
+			dagFile := filepath.Join(folder, "removed_dag.json")
+			config := &dagconfig.DAGConfig{
+				Name:          "test-removed",
+				Command:       []string{"sleep", "30"},
+				StartDateTime: "2019-01-01",
+				MaxActiveRuns: 1,
+			}
+			if err := config.WriteToFile(dagFile); err != nil {
+				panic(err)
+			}
+			orch := testOrchestrator()
+			orch.config.DAGPath = folder
+			orch.config.TerminateRemovedDAGRuns = terminateRuns
+			orch.executor = executor.NewLocalExecutor("")
+			orch.setupDatabaseTables()
+			orch.CollectDAGs()
+			dag := orch.GetDag("test-removed")
+			if dag == nil {
+				t.Fatal("Expected DAG test-removed to be collected")
+			}
+			dagRun, _, err := orch.Trigger(dag.Config.Name, time.Now(), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			waitForRunningTask(t, dagRun)
+
+			os.Remove(dagFile)
+			orch.CollectDAGs()
+			if orch.GetDag(dag.Config.Name) != nil {
+				t.Error("Expected DAG test-removed to be removed once its file was deleted")
+			}
+			record := orch.dagTableClient.GetDagRecord(dag.Config.Name, dag.Config.Namespace)
+			if record.IsActive {
+				t.Error("Expected DAG test-removed to be marked inactive")
+			}
+			if !terminateRuns {
+				if !dagRun.EndTime.IsZero() {
+					t.Error("Expected the run of a removed DAG to be left to finish")
+				}
+				dag.TerminateRuns()
+			}
+			finishedRun := waitForFinishedRun(t, dag)
+			if finishedRun.Status != dagrun.RunFailed || finishedRun.Reason != "Terminated" {
+				t.Errorf(
+					"Expected the terminated run to fail, found status %s and reason %s",
+					finishedRun.Status,
+					finishedRun.Reason,
+				)
+			}
+			_, found := orch.dagrunTableClient.GetRunForDagName(
+				dag.Config.Name,
+				dagRun.ExecutionDate.Time,
+			)
+			if !found {
+				t.Error("Expected the run of a removed DAG to be kept")
+			}
+		}()
+	}
+}
+
+func writeTestDAGFile(filePath string, name string, command ...string) {
+	config := &dagconfig.DAGConfig{
+		Name:          name,
+		Command:       command,
+		StartDateTime: "2019-01-01",
+		MaxActiveRuns: 1,
+	}
+	if err := config.WriteToFile(filePath); err != nil {
+		panic(err)
+	}
+}
+
+func TestCollectDagsSkipsUnchangedFiles(t *testing.T) {
+	defer database.PurgeDB(sqlClient)
+	folder, err := ioutil.TempDir("", "goflow-dags")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(folder)
+	dagFile := filepath.Join(folder, "unchanged_dag.json")
+	writeTestDAGFile(dagFile, "test-unchanged", "echo", "1")
+	orch := testOrchestrator()
+	orch.config.DAGPath = folder
+	orch.setupDatabaseTables()
+	orch.CollectDAGs()
+	dag := orch.GetDag("test-unchanged")
+	if dag == nil {
+		t.Fatal("Expected DAG test-unchanged to be collected")
+	}
+	isActive := func() bool {
+		return orch.dagTableClient.GetDagRecord(dag.Config.Name, dag.Config.Namespace).IsActive
+	}
+	orch.dagTableClient.UpdateDAGActive(dag.ID, false)
+
+	orch.CollectDAGs()
+	if isActive() {
+		t.Error("Expected a DAG file whose contents did not change not to be read again")
+	}
+	writeTestDAGFile(dagFile, "test-unchanged", "echo", "2")
+	orch.CollectDAGs()
+	if !isActive() {
+		t.Error("Expected a DAG file whose contents changed to be read again")
+	}
+	if command := orch.GetDag("test-unchanged").Config.Command; command[1] != "2" {
+		t.Errorf("Expected the DAG to be updated, found command %v", command)
+	}
+}
+
+func TestCollectDagsFromYAMLFile(t *testing.T) {
+	defer database.PurgeDB(sqlClient)
+	folder, err := ioutil.TempDir("", "goflow-dags")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(folder)
+	dagFile := filepath.Join(folder, "team_dag.yaml")
+	writeDAGFile := func(content string) {
+		if err := ioutil.WriteFile(dagFile, []byte(content), 0644); err != nil {
+			panic(err)
+		}
+	}
+	const firstDAG = "Name: test-first\nStartDateTime: \"2019-01-01\"\nMaxActiveRuns: 1\n"
+	const secondDAG = "Name: test-second\nStartDateTime: \"2019-01-01\"\nMaxActiveRuns: 1\n"
+	writeDAGFile(firstDAG + "---\n" + secondDAG)
+	orch := testOrchestrator()
+	orch.config.DAGPath = folder
+	orch.setupDatabaseTables()
+	orch.CollectDAGs()
+	if orch.GetDag("test-first") == nil || orch.GetDag("test-second") == nil {
+		t.Fatal("Expected a DAG to be collected for each document of the YAML file")
+	}
+	writeDAGFile(firstDAG)
+	orch.CollectDAGs()
+	if orch.GetDag("test-first") == nil {
+		t.Error("Expected DAG test-first to be kept")
+	}
+	if orch.GetDag("test-second") != nil {
+		t.Error("Expected DAG test-second to be removed once its document was deleted")
+	}
+}
+
+func waitForDAG(t *testing.T, orch *Orchestrator, dagName string, present bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		orch.dagMapLock.RLock()
+		_, found := orch.dagMap[dagName]
+		orch.dagMapLock.RUnlock()
+		if found == present {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Expected DAG %s to be present: %t", dagName, present)
+}
+
+func TestWatchDAGFolder(t *testing.T) {
+	defer database.PurgeDB(sqlClient)
+	folder, err := ioutil.TempDir("", "goflow-dags")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(folder)
+	orch := testOrchestrator()
+	orch.config.DAGPath = folder
+	orch.setupDatabaseTables()
+	go orch.watchDAGFolder(time.Second)
+	defer orch.Stop()
+
+	dagFile := filepath.Join(folder, "watched_dag.json")
+	writeTestDAGFile(dagFile, "test-watched", "echo", "1")
+	waitForDAG(t, orch, "test-watched", true)
+
+	subfolder := filepath.Join(folder, "team")
+	if err := os.Mkdir(subfolder, 0755); err != nil {
+		panic(err)
+	}
+	writeTestDAGFile(filepath.Join(subfolder, "nested_dag.json"), "test-nested", "echo", "1")
+	waitForDAG(t, orch, "test-nested", true)
+
+	os.Remove(dagFile)
+	waitForDAG(t, orch, "test-watched", false)
+	os.RemoveAll(subfolder)
+	waitForDAG(t, orch, "test-nested", false)
+}
+
+func TestBackfillMissingDAG(t *testing.T) {
+	orch := testOrchestrator()
+	start := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, status, err := orch.Backfill("missing-dag", start, start)
+	if err == nil || status != http.StatusNotFound {
+		t.Errorf("Expected a not found error for a missing DAG, got status %d", status)
+	}
+}
+
+func TestTriggerMissingDAG(t *testing.T) {
+	orch := testOrchestrator()
+	_, status, err := orch.Trigger("missing-dag", time.Now(), nil)
+	if err == nil || status != http.StatusNotFound {
+		t.Errorf("Expected a not found error for a missing DAG, got status %d", status)
+	}
+}
+
+func createTestDAG(orch *Orchestrator, config *dagconfig.DAGConfig) *dagtype.DAG {
+	config.Namespace = "default"
+	config.DockerImage = "busybox"
+	config.RetryPolicy = "Never"
+	config.Command = []string{"echo", config.Name}
+	config.MaxActiveRuns = 1
+	config.StartDateTime = "2019-01-01"
+	dag, err := dagtype.CreateDAG(
+		config,
+		config.String(),
+		orch.kubeClient,
+		make(dagtype.ScheduleCache),
+		orch.dagTableClient,
+		"path",
+		orch.dagrunTableClient,
+		orch.taskTableClient,
+		true,
+	)
+	if err != nil {
+		panic(err)
+	}
+	orch.AddDAG(&dag)
+	return &dag
+}
+
+func waitForFinishedRun(t *testing.T, dag *dagtype.DAG) *dagrun.DAGRun {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if len(dag.DAGRuns) != 0 && !dag.DAGRuns[0].EndTime.IsZero() {
+			return dag.DAGRuns[0]
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("dag %s did not finish a run", dag.Config.Name)
+	return nil
+}
+
+func TestTriggerDownstream(t *testing.T) {
+	defer database.PurgeDB(sqlClient)
+	orch := testOrchestrator()
+	orch.executor = executor.NewLocalExecutor("")
+	orch.setupDatabaseTables()
+	extract := createTestDAG(
+		orch,
+		&dagconfig.DAGConfig{Name: "extract", Schedule: "@daily", Produces: []string{"raw"}},
+	)
+	load := createTestDAG(
+		orch,
+		&dagconfig.DAGConfig{
+			Name:        "load",
+			TriggeredBy: []dagconfig.TriggerConfig{{Dataset: "raw"}},
+		},
+	)
+	report := createTestDAG(
+		orch,
+		&dagconfig.DAGConfig{
+			Name: "report",
+			TriggeredBy: []dagconfig.TriggerConfig{
+				{DAG: "extract"},
+				{DAG: "load"},
+			},
+		},
+	)
+	if load.Ready() || report.Ready() {
+		t.Error("DAGs with triggers should not be ready to run on a schedule")
+	}
+
+	executionDate := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, _, err := orch.Trigger(extract.Config.Name, executionDate, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForFinishedRun(t, extract)
+	loadRun := waitForFinishedRun(t, load)
+	reportRun := waitForFinishedRun(t, report)
+
+	for _, run := range []*dagrun.DAGRun{loadRun, reportRun} {
+		if run.RunType != dagrun.RunTriggered {
+			t.Errorf("Expected run type %s, found %s", dagrun.RunTriggered, run.RunType)
+		}
+		if !run.ExecutionDate.Time.Equal(executionDate) {
+			t.Errorf("Expected execution date %s, found %s", executionDate, run.ExecutionDate)
+		}
+	}
+	if loadRun.TriggeredBy != "extract" {
+		t.Errorf("Expected load to be triggered by extract, found \"%s\"", loadRun.TriggeredBy)
+	}
+	if reportRun.TriggeredBy != "load" {
+		t.Errorf("Expected report to be triggered by load, found \"%s\"", reportRun.TriggeredBy)
+	}
+	row, found := orch.dagrunTableClient.GetRunForDagName("report", executionDate)
+	if !found || row.TriggeredBy != "load" {
+		t.Errorf("Expected the report run to be stored as triggered by load, found %s", row)
+	}
+	if len(report.DAGRuns) != 1 {
+		t.Errorf("Expected report to run once, found %d runs", len(report.DAGRuns))
+	}
+}
+
+func TestTriggersTransitively(t *testing.T) {
+	defer database.PurgeDB(sqlClient)
+	orch := testOrchestrator()
+	orch.setupDatabaseTables()
+	createTestDAG(
+		orch,
+		&dagconfig.DAGConfig{
+			Name:        "first",
+			TriggeredBy: []dagconfig.TriggerConfig{{DAG: "third"}},
+		},
+	)
+	createTestDAG(
+		orch,
+		&dagconfig.DAGConfig{
+			Name:        "second",
+			TriggeredBy: []dagconfig.TriggerConfig{{DAG: "first"}},
+			Produces:    []string{"dataset"},
+		},
+	)
+	createTestDAG(
+		orch,
+		&dagconfig.DAGConfig{
+			Name:        "third",
+			TriggeredBy: []dagconfig.TriggerConfig{{Dataset: "dataset"}},
+		},
+	)
+	if !orch.triggersTransitively("first", "third") {
+		t.Error("Expected first to trigger third through second's dataset")
+	}
+	if orch.triggersTransitively("second", "missing") {
+		t.Error("Expected second not to trigger a missing DAG")
+	}
+}
+
+func TestExtractDAGFromPodName(t *testing.T) {
+	podName := "etl-2019-01-0100-00-00plus0000utc-extract"
+	if dagName := extractDAGFromPodName(podName); dagName != "etl" {
+		t.Errorf("Expected DAG etl to be extracted from %s, found %s", podName, dagName)
+	}
+	shortened := "etl-with-a-long-name-2019-01-0100-0-4f2a9c1e"
+	if dagName := extractDAGFromPodName(shortened); dagName != "" {
+		t.Errorf("Expected no DAG to be extracted from %s, found %s", shortened, dagName)
+	}
+}
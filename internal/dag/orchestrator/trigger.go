@@ -0,0 +1,127 @@
+package orchestrator
+
+import (
+	dagconfig "goflow/internal/dag/config"
+	dagrun "goflow/internal/dag/run"
+	"goflow/internal/logs"
+	"goflow/internal/stringutils"
+	"time"
+)
+
+// triggerDownstream starts a run, for the same execution date, of every DAG that is triggered by
+// the finished run's DAG or by a dataset it produced, once all of that DAG's triggers are met
+func (orchestrator *Orchestrator) triggerDownstream(finished *dagrun.DAGRun) {
+	if finished.Status != dagrun.RunSuccess {
+		return
+	}
+	upstream := finished.Config.Name
+	executionDate := finished.ExecutionDate.Time
+	datasets := finished.ProducedDatasets()
+	for _, dag := range orchestrator.DAGs() {
+		if !dag.IsOn || !dag.Config.TriggersOn(upstream, datasets) {
+			continue
+		}
+		if orchestrator.triggersTransitively(dag.Config.Name, upstream) {
+			logs.With(logs.Fields{logs.DAGField: dag.Config.Name}).Errorf(
+				"dag %s is not triggered by dag %s since it would trigger dag %s in turn",
+				dag.Config.Name,
+				upstream,
+				upstream,
+			)
+			continue
+		}
+		if !orchestrator.triggersMet(dag.Config, executionDate) {
+			logs.With(logs.Fields{logs.DAGField: dag.Config.Name}).Infof(
+				"dag %s is waiting on its other triggers for %s",
+				dag.Config.Name,
+				executionDate,
+			)
+			continue
+		}
+		dag.TriggerFromUpstream(executionDate, upstream, orchestrator.channelHolder)
+	}
+}
+
+// triggersMet returns true if every upstream DAG of the config has a successful run for the
+// execution date, and every dataset it is triggered by has been produced for that date
+func (orchestrator *Orchestrator) triggersMet(
+	config *dagconfig.DAGConfig,
+	executionDate time.Time,
+) bool {
+	for _, trigger := range config.TriggeredBy {
+		if trigger.DAG != "" && !orchestrator.dagRunSucceeded(trigger.DAG, executionDate) {
+			return false
+		}
+		if trigger.Dataset != "" && !orchestrator.datasetProduced(trigger.Dataset, executionDate) {
+			return false
+		}
+	}
+	return true
+}
+
+// dagRunSucceeded returns true if the named DAG's run for the execution date has succeeded
+func (orchestrator *Orchestrator) dagRunSucceeded(dagName string, executionDate time.Time) bool {
+	row, found := orchestrator.dagrunTableClient.GetRunForDagName(dagName, executionDate)
+	return found && row.Status == string(dagrun.RunSuccess)
+}
+
+// datasetProduced returns true if a DAG that produces the dataset has a successful run for the
+// execution date, or a task that produces it has succeeded for that date
+func (orchestrator *Orchestrator) datasetProduced(dataset string, executionDate time.Time) bool {
+	for _, dag := range orchestrator.DAGs() {
+		if stringutils.NewStringSet(dag.Config.Produces).Contains(dataset) &&
+			orchestrator.dagRunSucceeded(dag.Config.Name, executionDate) {
+			return true
+		}
+		producers := stringutils.NewStringSet(nil)
+		for _, task := range dag.Config.Tasks {
+			if stringutils.NewStringSet(task.Produces).Contains(dataset) {
+				producers.Add(task.Name)
+			}
+		}
+		if len(producers) == 0 {
+			continue
+		}
+		for _, row := range orchestrator.taskTableClient.GetTaskInstancesForDagRun(
+			dag.ID,
+			executionDate,
+		) {
+			if producers.Contains(row.TaskName) && row.State == string(dagrun.TaskSuccess) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// triggersTransitively returns true if a run of the named DAG would, through the DAGs and
+// datasets downstream of it, trigger a run of the target DAG
+func (orchestrator *Orchestrator) triggersTransitively(dagName string, target string) bool {
+	dags := orchestrator.DAGs()
+	configs := make(map[string]*dagconfig.DAGConfig, len(dags))
+	for _, dag := range dags {
+		configs[dag.Config.Name] = dag.Config
+	}
+	visited := stringutils.NewStringSet([]string{dagName})
+	queue := []string{dagName}
+	for len(queue) != 0 {
+		current, ok := configs[queue[0]]
+		queue = queue[1:]
+		if !ok {
+			continue
+		}
+		for name, config := range configs {
+			if !config.TriggersOn(current.Name, current.Datasets()) {
+				continue
+			}
+			if name == target {
+				return true
+			}
+			if !visited.Contains(name) {
+				visited.Add(name)
+				queue = append(queue, name)
+			}
+		}
+	}
+	return false
+}
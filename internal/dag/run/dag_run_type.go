@@ -1,47 +1,65 @@
 package run
 
 import (
-	"context"
-	"fmt"
-
+	"encoding/json"
 	"goflow/internal/jsonpanic"
-	"goflow/internal/logs"
 
 	"goflow/internal/dag/activeruns"
 	dagconfig "goflow/internal/dag/config"
+	"goflow/internal/executor"
 	"goflow/internal/k8s/pod/event/holder"
 	"goflow/internal/k8s/pod/utils"
-	podwatch "goflow/internal/k8s/pod/watch"
+	"goflow/internal/logs"
 
+	"sync"
 	"time"
 
 	dagruntable "goflow/internal/dag/sql/dagrun"
+	taskinstancetable "goflow/internal/dag/sql/taskinstance"
 
-	core "k8s.io/api/core/v1"
 	k8sapi "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
-	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
 )
 
 const serviceAccount = "goflow"
 
-// DAGRun is a single run of a given dag - corresponds with a kubernetes pod
+// terminatedReason is the reason given for a dag run that was terminated, and for its tasks
+const terminatedReason = "Terminated"
+
+// DAGRun is a single run of a given dag - corresponds with one kubernetes pod per task
 type DAGRun struct {
 	Name          string
 	Config        *dagconfig.DAGConfig
 	ExecutionDate k8sapi.Time // This is the date that will be passed to the pod that runs
-	StartTime     k8sapi.Time
-	EndTime       k8sapi.Time
-	pod           *core.Pod
-	withLogs      bool
-	kubeClient    kubernetes.Interface
-	watcher       *podwatch.PodWatcher
-	holder        *holder.ChannelHolder
-	dagRunCount   *activeruns.ActiveRuns
+	// The period of time covered by the run, which ends at the next scheduled time
+	DataIntervalStart k8sapi.Time
+	DataIntervalEnd   k8sapi.Time
+	StartTime         k8sapi.Time
+	EndTime           k8sapi.Time
+	Status            RunStatus
+	RunType           RunType
+	Conf              map[string]string // Passed to the pods of each task as environment variables
+	TriggeredBy       string            // The upstream DAG whose successful run triggered this one
+	ExitCode          int32
+	Reason            string
+	Tasks             []*TaskRun
+	withLogs          bool
+	kubeClient        kubernetes.Interface
+	holder            *holder.ChannelHolder
+	executor          executor.Executor
+	finishHandler     FinishHandler
+	dagRunCount       *activeruns.ActiveRuns
 	*dagruntable.TableClient
-	dagID int
+	taskTableClient *taskinstancetable.TableClient
+	dagID           int
+	runNumber       int           // Which run of the execution date this is, starting from 1
+	terminated      chan struct{} // Closed once the dag run has been terminated
+	terminateOnce   *sync.Once
 }
 
+// FinishHandler is called with a dag run once its terminal status has been recorded
+type FinishHandler func(dagRun *DAGRun)
+
 // NewDAGRun returns a new instance of DAGRun
 func NewDAGRun(
 	executionDate time.Time,
@@ -51,50 +69,58 @@ func NewDAGRun(
 	channelHolder *holder.ChannelHolder,
 	activeRuns *activeruns.ActiveRuns,
 	tableClient *dagruntable.TableClient,
+	taskTableClient *taskinstancetable.TableClient,
 	dagID int,
 ) *DAGRun {
-	podName := utils.CleanK8sName(dagConfig.Name + "-" + executionDate.String())
-	return &DAGRun{
-		Name:   podName,
+	runName := utils.CleanK8sName(dagConfig.Name + "-" + executionDate.UTC().String())
+	dagRun := &DAGRun{
+		Name:   runName,
 		Config: dagConfig,
 		ExecutionDate: k8sapi.Time{
 			Time: executionDate,
 		},
+		DataIntervalStart: k8sapi.Time{
+			Time: executionDate,
+		},
+		DataIntervalEnd: k8sapi.Time{
+			Time: executionDate,
+		},
 		StartTime: k8sapi.Time{
 			Time: time.Now(),
 		},
 		EndTime: k8sapi.Time{
 			Time: time.Time{},
 		},
-		withLogs:   withLogs,
-		kubeClient: kubeClient,
-		watcher: podwatch.NewPodWatcher(
-			podName,
-			dagConfig.Namespace,
-			kubeClient,
-			withLogs,
-			channelHolder,
-		),
-		holder:      channelHolder,
-		dagRunCount: activeRuns,
-		TableClient: tableClient,
-		dagID:       dagID,
-	}
-}
-
-func (dagRun *DAGRun) getContainerFrame() core.Container {
-	return core.Container{
-		Name:            "task",
-		Image:           dagRun.Config.DockerImage,
-		Command:         dagRun.Config.Command,
-		Args:            nil,
-		WorkingDir:      "",
-		EnvFrom:         nil,
-		Env:             nil,
-		VolumeMounts:    nil,
-		VolumeDevices:   nil,
-		ImagePullPolicy: core.PullIfNotPresent,
+		RunType:         RunScheduled,
+		withLogs:        withLogs,
+		kubeClient:      kubeClient,
+		holder:          channelHolder,
+		executor:        executor.NewKubernetesExecutor(kubeClient, channelHolder),
+		dagRunCount:     activeRuns,
+		TableClient:     tableClient,
+		taskTableClient: taskTableClient,
+		dagID:           dagID,
+		terminated:      make(chan struct{}),
+		terminateOnce:   &sync.Once{},
+	}
+	for _, taskConfig := range dagConfig.TaskConfigs() {
+		dagRun.Tasks = append(
+			dagRun.Tasks,
+			newTaskRun(dagRun, taskConfig, dagRun.podName(taskConfig.Name)),
+		)
 	}
+	return dagRun
+}
+
+// SetExecutor sets the executor that launches the dag run's tasks, which are run as kubernetes
+// pods by default
+func (dagRun *DAGRun) SetExecutor(taskExecutor executor.Executor) {
+	dagRun.executor = taskExecutor
+}
+
+// SetFinishHandler sets the function called once the dag run has finished
+func (dagRun *DAGRun) SetFinishHandler(handler FinishHandler) {
+	dagRun.finishHandler = handler
 }
 
 func copyStringMap(mapToCopy map[string]string) map[string]string {
@@ -105,109 +131,300 @@ func copyStringMap(mapToCopy map[string]string) map[string]string {
 	return copy
 }
 
-// getPodFrame returns a pod from a DagRun
-func (dagRun *DAGRun) getPodFrame() core.Pod {
-	labels := copyStringMap(dagRun.Config.Labels)
-	labels["Name"] = dagRun.Name
-	labels["App"] = "goflow"
-	return core.Pod{
-		TypeMeta: k8sapi.TypeMeta{
-			Kind:       "Pod",
-			APIVersion: "v1",
-		},
-		ObjectMeta: k8sapi.ObjectMeta{
-			Name:        dagRun.Name,
-			Namespace:   dagRun.Config.Namespace,
-			Labels:      labels,
-			Annotations: dagRun.Config.Annotations,
-		},
-		Spec: core.PodSpec{
-			Volumes:               nil,
-			Containers:            []core.Container{dagRun.getContainerFrame()},
-			EphemeralContainers:   nil,
-			RestartPolicy:         dagRun.Config.RetryPolicy,
-			ActiveDeadlineSeconds: dagRun.Config.TimeLimit,
-			ServiceAccountName:    serviceAccount,
-		},
+// log returns a log entry about the dag run
+func (dagRun *DAGRun) log() logs.Entry {
+	return logs.With(logs.Fields{logs.DAGField: dagRun.Config.Name, logs.RunField: dagRun.Name})
+}
+
+// podName returns the name of the pod for the given task. A DAG without any Tasks keeps the
+// name of the dag run for its single pod. Names that are too long to be used as a label are
+// shortened.
+func (dagRun *DAGRun) podName(taskName string) string {
+	if len(dagRun.Config.Tasks) == 0 {
+		return utils.ShortenK8sName(dagRun.Name)
 	}
+	return utils.ShortenK8sName(utils.CleanK8sName(dagRun.Name + "-" + taskName))
 }
 
-// createPod creates and registers a new pod with
-func (dagRun *DAGRun) createPod() {
-	podFrame := dagRun.getPodFrame()
-	logs.InfoLogger.Printf("Creating pod %s...\n", podFrame.Name)
-	pod, err := dagRun.podClient().Create(
-		context.TODO(),
-		&podFrame,
-		k8sapi.CreateOptions{},
-	)
-	if err != nil {
-		panic(err)
+// Task returns the task run with the given name, or nil if there is no such task
+func (dagRun *DAGRun) Task(name string) *TaskRun {
+	for _, task := range dagRun.Tasks {
+		if task.Name == name {
+			return task
+		}
 	}
-	logs.InfoLogger.Printf(
-		"Pod '%s' created in namespace '%s'\n",
-		podFrame.Name,
-		podFrame.Namespace,
-	)
-	dagRun.pod = pod
+	return nil
+}
+
+// upstreamSucceeded returns true if every task the given task depends on has succeeded
+func (dagRun *DAGRun) upstreamSucceeded(task *TaskRun) bool {
+	for _, upstream := range task.Config.DependsOn {
+		upstreamTask := dagRun.Task(upstream)
+		if upstreamTask == nil || !upstreamTask.Succeeded() {
+			return false
+		}
+	}
+	return true
 }
 
-// podClient returns the api endpoint for pods
-func (dagRun *DAGRun) podClient() v1.PodInterface {
-	return dagRun.kubeClient.CoreV1().Pods(dagRun.Config.Namespace)
+// runTasks starts each task once all of its upstream tasks have succeeded and returns when no
+// more tasks can be started. Tasks downstream of a failed task are marked as upstream failed, and
+// tasks downstream of a skipped task are skipped. Tasks that were already queued, running, up for
+// retry or up for reschedule when the run was resumed carry on from where they were. Once the run
+// is terminated, tasks that would be started are skipped instead.
+func (dagRun *DAGRun) runTasks() {
+	finished := make(chan *TaskRun, len(dagRun.Tasks))
+	started := make(map[string]bool)
+	running := 0
+	for _, task := range dagRun.Tasks {
+		state := task.GetState()
+		switch {
+		case state.Finished():
+			started[task.Name] = true
+		case state == TaskQueued || state == TaskRunning || state == TaskUpForRetry ||
+			state == TaskUpForReschedule:
+			started[task.Name] = true
+			running++
+			go func(task *TaskRun) {
+				task.Resume()
+				finished <- task
+			}(task)
+		}
+	}
+	for {
+		for _, task := range dagRun.Tasks {
+			if started[task.Name] || !dagRun.upstreamSucceeded(task) {
+				continue
+			}
+			started[task.Name] = true
+			if dagRun.isTerminated() {
+				task.Reason = terminatedReason
+				task.setState(TaskSkipped)
+				continue
+			}
+			running++
+			go func(task *TaskRun) {
+				task.Start()
+				finished <- task
+			}(task)
+		}
+		if running == 0 {
+			break
+		}
+		<-finished
+		running--
+	}
+	dagRun.resolveUnstartedTasks(started)
 }
 
-// Run runs the pods and monitoring methods
-func (dagRun *DAGRun) Run() {
-	podFrame := dagRun.getPodFrame()
-	dagRun.holder.AddChannelGroup(podFrame.Name)
-	go dagRun.watcher.MonitorPod() // Start monitoring before the pod is actually running
-	dagRun.createPod()
+// resolveUnstartedTasks marks each task that was never started as upstream failed if any of its
+// upstream tasks failed, or as skipped if its upstream tasks were skipped, such as by a sensor
+// that soft failed
+func (dagRun *DAGRun) resolveUnstartedTasks(started map[string]bool) {
+	for resolved := true; resolved; {
+		resolved = false
+		for _, task := range dagRun.Tasks {
+			if started[task.Name] {
+				continue
+			}
+			upstreamFailed := false
+			upstreamPending := false
+			for _, upstream := range task.Config.DependsOn {
+				switch dagRun.Task(upstream).GetState() {
+				case TaskFailed, TaskUpstreamFailed:
+					upstreamFailed = true
+				case TaskSuccess, TaskSkipped:
+				default:
+					upstreamPending = true
+				}
+			}
+			if upstreamFailed {
+				task.setState(TaskUpstreamFailed)
+			} else if upstreamPending {
+				continue
+			} else {
+				task.setState(TaskSkipped)
+			}
+			started[task.Name] = true
+			resolved = true
+		}
+	}
+	for _, task := range dagRun.Tasks {
+		if !started[task.Name] {
+			task.setState(TaskUpstreamFailed)
+		}
+	}
 }
 
 func (dagRun *DAGRun) row() dagruntable.Row {
-	return dagruntable.NewRow(dagRun.dagID, "", dagRun.ExecutionDate.Time)
+	row := dagruntable.NewRow(dagRun.dagID, string(dagRun.Status), dagRun.ExecutionDate.Time)
+	row.StartDate = dagRun.StartTime.Time
+	row.EndDate = dagRun.EndTime.Time
+	row.ExitCode = int(dagRun.ExitCode)
+	row.Reason = dagRun.Reason
+	row.RunType = string(dagRun.RunType)
+	row.TriggeredBy = dagRun.TriggeredBy
+	row.RunNumber = dagRun.runNumber
+	if len(dagRun.Conf) != 0 {
+		confBytes, err := json.Marshal(dagRun.Conf)
+		if err != nil {
+			panic(err)
+		}
+		row.Conf = string(confBytes)
+	}
+	return row
 }
 
-// Start runs the dagrun and waits for the monitoring to finish
+// finish records the terminal status of the dag run. The exit code and reason are taken from the
+// first failed task, or from the last task if every task succeeded. A terminated run has failed.
+func (dagRun *DAGRun) finish() {
+	dagRun.Status = RunSuccess
+	var failedTask *TaskRun
+	for _, task := range dagRun.Tasks {
+		state := task.GetState()
+		if state == TaskSuccess || state == TaskSkipped {
+			continue
+		}
+		dagRun.Status = RunFailed
+		if state == TaskFailed && failedTask == nil {
+			failedTask = task
+		}
+	}
+	resultTask := dagRun.Tasks[len(dagRun.Tasks)-1]
+	if failedTask != nil {
+		resultTask = failedTask
+	}
+	dagRun.ExitCode = resultTask.ExitCode
+	dagRun.Reason = resultTask.Reason
+	if dagRun.isTerminated() {
+		dagRun.Status = RunFailed
+		dagRun.Reason = terminatedReason
+	}
+	dagRun.EndTime = k8sapi.Time{Time: time.Now()}
+	dagRun.log().Infof(
+		"DAG run %s finished with status \"%s\", exit code %d and reason \"%s\"",
+		dagRun.Name,
+		dagRun.Status,
+		dagRun.ExitCode,
+		dagRun.Reason,
+	)
+	dagRun.UpsertDagRun(dagRun.row())
+	if dagRun.finishHandler != nil {
+		dagRun.finishHandler(dagRun)
+	}
+}
+
+// ProducedDatasets returns the datasets updated by the dag run, which are those produced by its
+// successful tasks, along with those produced by the DAG if the whole run succeeded
+func (dagRun *DAGRun) ProducedDatasets() []string {
+	datasets := make([]string, 0)
+	if dagRun.Status == RunSuccess {
+		datasets = append(datasets, dagRun.Config.Produces...)
+	}
+	for _, task := range dagRun.Tasks {
+		if task.Succeeded() {
+			datasets = append(datasets, task.Config.Produces...)
+		}
+	}
+	return datasets
+}
+
+// Start runs the dagrun and waits for all of its tasks to finish
 func (dagRun *DAGRun) Start() {
-	defer dagRun.DeletePod()
 	defer dagRun.dagRunCount.Dec()
-	go dagRun.Run()
+	dagRun.Status = RunRunning
+	dagRun.runNumber = dagRun.NextRunNumber(dagRun.dagID, dagRun.ExecutionDate.Time)
 	dagRun.UpsertDagRun(dagRun.row())
-	dagRun.watcher.WaitForMonitorDone()
+	for _, task := range dagRun.Tasks {
+		task.setState(TaskScheduled)
+	}
+	dagRun.runTasks()
+	dagRun.finish()
 }
 
-// Logs returns the channel holding the watcher's logs
-func (dagRun *DAGRun) Logs() chan string {
-	return dagRun.watcher.Logs
+// Resume continues a dag run that was in progress when goflow stopped, restoring the state of its
+// tasks from their task instance rows
+func (dagRun *DAGRun) Resume(runRow dagruntable.Row, taskRows []taskinstancetable.Row) {
+	defer dagRun.dagRunCount.Dec()
+	dagRun.Status = RunRunning
+	dagRun.StartTime = k8sapi.Time{Time: runRow.StartDate}
+	dagRun.runNumber = runRow.RunNumber
+	if runRow.RunType != "" {
+		dagRun.RunType = RunType(runRow.RunType)
+	}
+	dagRun.TriggeredBy = runRow.TriggeredBy
+	if runRow.Conf != "" {
+		err := json.Unmarshal([]byte(runRow.Conf), &dagRun.Conf)
+		if err != nil {
+			panic(err)
+		}
+	}
+	rowsByTask := make(map[string]taskinstancetable.Row)
+	for _, row := range taskRows {
+		rowsByTask[row.TaskName] = row
+	}
+	for _, task := range dagRun.Tasks {
+		row, ok := rowsByTask[task.Name]
+		if ok {
+			task.restore(row)
+			continue
+		}
+		task.setState(TaskScheduled)
+	}
+	dagRun.log().Infof("Resuming dag run %s", dagRun.Name)
+	dagRun.runTasks()
+	dagRun.finish()
 }
 
-// DeletePod deletes the dag run's associated pod
+// DeletePod deletes the pods of all of the dag run's tasks
 func (dagRun *DAGRun) DeletePod() {
-	logs.InfoLogger.Printf(
-		"Deleting pod %s, in namespace %s",
-		dagRun.pod.Name,
-		dagRun.pod.Namespace,
-	)
-	err := dagRun.podClient().Delete(
-		context.TODO(),
-		dagRun.Name,
-		k8sapi.DeleteOptions{},
-	)
-	if err != nil {
-		panic(err)
+	for _, task := range dagRun.Tasks {
+		task.DeletePod()
 	}
 }
 
-// MostRecentPod returns the pod run for this dag run
-func (dagRun *DAGRun) MostRecentPod() (core.Pod, error) {
-	if dagRun.pod == nil {
-		return core.Pod{}, fmt.Errorf("pod %s has not been created yet", dagRun.Name)
+// Terminate cancels the current attempts of the dag run's unfinished tasks. The tasks of a
+// terminated run are not retried, and its tasks that have not started yet are skipped.
+func (dagRun *DAGRun) Terminate() {
+	dagRun.log().Infof("Terminating DAG run %s", dagRun.Name)
+	dagRun.terminateOnce.Do(func() {
+		close(dagRun.terminated)
+	})
+	for _, task := range dagRun.Tasks {
+		if !task.GetState().Finished() {
+			task.DeletePod()
+		}
+	}
+}
+
+// isTerminated returns true once the dag run has been terminated
+func (dagRun *DAGRun) isTerminated() bool {
+	select {
+	case <-dagRun.terminated:
+		return true
+	default:
+		return false
+	}
+}
+
+// Redacted returns a copy of the dag run whose config and task configs do not contain the values
+// of literal environment variables, and whose Conf does not contain the values it was given
+func (dagRun *DAGRun) Redacted() *DAGRun {
+	redactedRun := *dagRun
+	redactedRun.Conf = dagconfig.RedactEnv(dagRun.Conf)
+	if dagRun.Config != nil {
+		redactedConfig := dagRun.Config.Redacted()
+		redactedRun.Config = &redactedConfig
+	}
+	redactedRun.Tasks = make([]*TaskRun, 0, len(dagRun.Tasks))
+	for _, task := range dagRun.Tasks {
+		redactedTask := *task
+		redactedTask.Config = task.Config.Redacted()
+		redactedRun.Tasks = append(redactedRun.Tasks, &redactedTask)
 	}
-	return *dagRun.pod, nil
+	return &redactedRun
 }
 
 func (dagRun *DAGRun) String() string {
-	return jsonpanic.JSONPanicFormat(dagRun)
+	return jsonpanic.JSONPanicFormat(dagRun.Redacted())
 }
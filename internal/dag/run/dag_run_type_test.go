@@ -5,18 +5,17 @@ import (
 	"goflow/internal/dag/activeruns"
 	dagconfig "goflow/internal/dag/config"
 	"goflow/internal/database"
-	"goflow/internal/jsonpanic"
 	"goflow/internal/testutils"
 
 	"goflow/internal/k8s/pod/event/holder"
 	podutils "goflow/internal/k8s/pod/utils"
-	"strings"
 	"testing"
 
 	"time"
 
 	dagtable "goflow/internal/dag/sql/dag"
 	dagruntable "goflow/internal/dag/sql/dagrun"
+	taskinstancetable "goflow/internal/dag/sql/taskinstance"
 
 	core "k8s.io/api/core/v1"
 	k8sapi "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -26,6 +25,7 @@ import (
 
 var TABLECLIENT *dagruntable.TableClient
 var DAGTABLECLIENT *dagtable.TableClient
+var TASKTABLECLIENT *taskinstancetable.TableClient
 var SQLCLIENT *database.SQLClient
 
 func TestMain(m *testing.M) {
@@ -33,6 +33,7 @@ func TestMain(m *testing.M) {
 	SQLCLIENT = database.NewSQLiteClient(testutils.GetSQLiteLocation())
 	TABLECLIENT = dagruntable.NewTableClient(SQLCLIENT)
 	DAGTABLECLIENT = dagtable.NewTableClient(SQLCLIENT)
+	TASKTABLECLIENT = taskinstancetable.NewTableClient(SQLCLIENT)
 	m.Run()
 }
 
@@ -58,42 +59,23 @@ func getTestDAGConfig(name string, command []string) *dagconfig.DAGConfig {
 	}
 }
 
-func TestCreatePod(t *testing.T) {
-	client := fake.NewSimpleClientset()
-	defer podutils.CleanUpEnvironment(client)
-	dagRun := NewDAGRun(
-		getTestDate(),
-		getTestDAGConfig("test-create-pod", []string{}),
-		false,
-		client,
-		holder.New(),
-		activeruns.New(),
-		TABLECLIENT,
-		0,
-	)
-	dagRun.createPod()
-	foundPod, err := dagRun.kubeClient.CoreV1().Pods(
-		dagRun.Config.Namespace,
-	).Get(
-		context.TODO(),
-		dagRun.pod.Name,
-		k8sapi.GetOptions{},
-	)
-	if err != nil {
-		panic(err)
-	}
-	foundPodValue := jsonpanic.JSONPanic(*foundPod)
-	expectedValue := jsonpanic.JSONPanic(*dagRun.pod)
-	if foundPodValue != expectedValue {
-		t.Error("Expected:", expectedValue)
-		t.Error("Found:", foundPodValue)
-	}
+func setupDatabase() {
+	DAGTABLECLIENT.CreateTable()
+	TABLECLIENT.CreateTable()
+	TASKTABLECLIENT.CreateTable()
+	DAGTABLECLIENT.UpsertDAG(dagtable.NewRow(0, true, "test", "default", "0.0.0", "test", "json"))
 }
 
-func TestRunPod(t *testing.T) {
+func TestStart(t *testing.T) {
 	// Test with logs and without logs
 	client := fake.NewSimpleClientset()
 	defer podutils.CleanUpEnvironment(client)
+
+	// Set up DB
+	setupDatabase()
+	defer database.PurgeDB(SQLCLIENT)
+
+	// Run tests
 	tables := []struct {
 		name     string
 		withLogs bool
@@ -116,39 +98,32 @@ func TestRunPod(t *testing.T) {
 				holder.New(),
 				activeruns.New(),
 				TABLECLIENT,
+				TASKTABLECLIENT,
 				0,
 			)
-			dagRun.Run()
-
-			dagRun.holder.GetChannelGroup(dagRun.pod.Name).Ready <- dagRun.pod
+			done := make(chan struct{})
+			go func() {
+				dagRun.Start()
+				close(done)
+			}()
 
-			podCopy := dagRun.pod.DeepCopy()
-			podCopy.Status.Phase = core.PodSucceeded
-			dagRun.holder.GetChannelGroup(dagRun.pod.Name).Update <- podCopy
+			waitForTaskPod(dagRun.Tasks[0])
+			completePod(dagRun.Tasks[0], core.PodSucceeded)
+			<-done
 
-			dagRun.watcher.WaitForMonitorDone()
-
-			// Test for dag completion in state of dag
-			if (dagRun.watcher.Phase != core.PodSucceeded) &&
-				(dagRun.watcher.Phase != core.PodFailed) {
-				t.Errorf(
-					"A finished dagRun should be in phase %s or state %s, but found in state %s",
-					core.PodSucceeded,
-					core.PodFailed,
-					dagRun.watcher.Phase,
-				)
+			podList, err := client.CoreV1().Pods(
+				dagRun.Config.Namespace,
+			).List(
+				context.TODO(),
+				k8sapi.ListOptions{},
+			)
+			if err != nil {
+				panic(err)
 			}
-
-			// Test for log output if logs enabled
-			if table.withLogs {
-				logMsg := <-dagRun.Logs()
-				logMsg = strings.ReplaceAll(logMsg, "\n", "")
-				if logMsg != expectedLogMessage && logMsg != "fake logs" {
-					t.Errorf(
-						"Expected log message %s, found log message %s",
-						expectedLogMessage,
-						logMsg,
-					)
+			for _, item := range podList.Items {
+				t.Log(item.Name)
+				if item.Name == dagRun.Name {
+					t.Errorf("Pod with name %s should have been deleted", dagRun.Name)
 				}
 			}
 		}()
@@ -157,115 +132,281 @@ func TestRunPod(t *testing.T) {
 
 }
 
-func TestDeletePod(t *testing.T) {
+func waitForTaskPod(taskRun *TaskRun) {
+	for !taskRun.dagRun.holder.Contains(taskRun.PodName) || taskPod(taskRun) == nil {
+		time.Sleep(1 * time.Millisecond)
+	}
+}
+
+func TestStartTasksInDependencyOrder(t *testing.T) {
 	client := fake.NewSimpleClientset()
 	defer podutils.CleanUpEnvironment(client)
+	setupDatabase()
+	defer database.PurgeDB(SQLCLIENT)
+
+	config := getTestDAGConfig("test-task-order", []string{})
+	config.Tasks = []dagconfig.TaskConfig{
+		{Name: "extract", Command: []string{"echo", "extract"}},
+		{Name: "transform", Command: []string{"echo", "transform"}, DependsOn: []string{"extract"}},
+		{Name: "validate", Command: []string{"echo", "validate"}, DependsOn: []string{"extract"}},
+		{Name: "load", Command: []string{"echo", "load"}, DependsOn: []string{"transform"}},
+	}
 	dagRun := NewDAGRun(
 		getTestDate(),
-		getTestDAGConfig("test-delete-pod", []string{}),
+		config,
 		false,
 		client,
 		holder.New(),
 		activeruns.New(),
 		TABLECLIENT,
+		TASKTABLECLIENT,
 		0,
 	)
-	podFrame := dagRun.getPodFrame()
-	podsClient := client.CoreV1().Pods(dagRun.Config.Namespace)
-
-	createdPod, err := podsClient.Create(context.TODO(), &podFrame, k8sapi.CreateOptions{})
-	dagRun.pod = createdPod
-	if err != nil {
-		panic(err)
+	done := make(chan struct{})
+	go func() {
+		dagRun.Start()
+		close(done)
+	}()
+
+	extract := dagRun.Task("extract")
+	waitForTaskPod(extract)
+	for _, name := range []string{"transform", "validate", "load"} {
+		if taskPod(dagRun.Task(name)) != nil {
+			t.Errorf("Task %s should not start before its upstream tasks succeed", name)
+		}
 	}
-	dagRun.DeletePod()
-	list, err := podsClient.List(context.TODO(), k8sapi.ListOptions{})
-	if err != nil {
-		panic(err)
+	completePod(extract, core.PodSucceeded)
+
+	transform := dagRun.Task("transform")
+	validate := dagRun.Task("validate")
+	waitForTaskPod(transform)
+	waitForTaskPod(validate)
+	completePod(transform, core.PodFailed)
+	completePod(validate, core.PodSucceeded)
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("DAG run should finish once no more tasks can be started")
+	}
+	if taskPod(dagRun.Task("load")) != nil {
+		t.Error("Task load should not start when its upstream task has failed")
+	}
+	if dagRun.Status != RunFailed {
+		t.Errorf("Expected dag run status %s, found %s", RunFailed, dagRun.Status)
+	}
+	expectedStates := map[string]TaskState{
+		"extract":   TaskSuccess,
+		"transform": TaskFailed,
+		"validate":  TaskSuccess,
+		"load":      TaskUpstreamFailed,
 	}
-	for _, pod := range list.Items {
-		if jsonpanic.JSONPanic(*createdPod) == jsonpanic.JSONPanic(pod) {
-			t.Errorf("Pod %s should have been deleted", createdPod.Name)
+	rows := TASKTABLECLIENT.GetTaskInstancesForDagRun(0, dagRun.ExecutionDate.Time)
+	if len(rows) != len(expectedStates) {
+		t.Errorf("Expected %d task instance rows, found %d", len(expectedStates), len(rows))
+	}
+	for _, row := range rows {
+		expectedState := expectedStates[row.TaskName]
+		if dagRun.Task(row.TaskName).GetState() != expectedState {
+			t.Errorf(
+				"Expected task %s in state %s, found %s",
+				row.TaskName,
+				expectedState,
+				dagRun.Task(row.TaskName).GetState(),
+			)
+		}
+		if row.State != string(expectedState) {
+			t.Errorf(
+				"Expected task instance %s stored in state %s, found %s",
+				row.TaskName,
+				expectedState,
+				row.State,
+			)
 		}
 	}
 }
 
-func setupDatabase() {
-	DAGTABLECLIENT.CreateTable()
-	TABLECLIENT.CreateTable()
-	DAGTABLECLIENT.UpsertDAG(dagtable.NewRow(0, true, "test", "default", "0.0.0", "test", "json"))
-}
-
-func TestStart(t *testing.T) {
-	// Test with logs and without logs
+func TestStartRecordsTerminalStatus(t *testing.T) {
 	client := fake.NewSimpleClientset()
 	defer podutils.CleanUpEnvironment(client)
 
-	// Set up DB
-	setupDatabase()
-	defer database.PurgeDB(SQLCLIENT)
-
-	// Run tests
 	tables := []struct {
-		name     string
-		withLogs bool
+		name             string
+		podStatus        core.PodStatus
+		expectedStatus   RunStatus
+		expectedExitCode int32
+		expectedReason   string
 	}{
-		{"Without Logs", false},
-		{"With Logs", true},
+		{
+			"Succeeded",
+			core.PodStatus{
+				Phase: core.PodSucceeded,
+				ContainerStatuses: []core.ContainerStatus{{
+					State: core.ContainerState{
+						Terminated: &core.ContainerStateTerminated{Reason: "Completed"},
+					},
+				}},
+			},
+			RunSuccess,
+			0,
+			"Completed",
+		},
+		{
+			"Failed",
+			core.PodStatus{
+				Phase: core.PodFailed,
+				ContainerStatuses: []core.ContainerStatus{{
+					State: core.ContainerState{
+						Terminated: &core.ContainerStateTerminated{ExitCode: 3, Reason: "Error"},
+					},
+				}},
+			},
+			RunFailed,
+			3,
+			"Error",
+		},
 	}
 	for _, table := range tables {
 		t.Logf("Test case: %s", table.name)
 		func() {
-			expectedLogMessage := "Hello World!!!"
+			setupDatabase()
+			defer database.PurgeDB(SQLCLIENT)
 			dagRun := NewDAGRun(
 				getTestDate(),
 				getTestDAGConfig(
-					"test-start-pod"+podutils.CleanK8sName(table.name),
-					[]string{"echo", expectedLogMessage},
+					"test-terminal-status-"+podutils.CleanK8sName(table.name),
+					[]string{},
 				),
-				table.withLogs,
+				false,
 				client,
 				holder.New(),
 				activeruns.New(),
 				TABLECLIENT,
+				TASKTABLECLIENT,
 				0,
 			)
-			go dagRun.Start()
-
-			for {
-				// Need to make sure that dag is actually totally ready before
-				// moving on with test
-				if dagRun.holder.Contains(dagRun.Name) && dagRun.pod != nil {
-					break
-				}
-				time.Sleep(1 * time.Millisecond)
+			done := make(chan struct{})
+			go func() {
+				dagRun.Start()
+				close(done)
+			}()
+			waitForTaskPod(dagRun.Tasks[0])
+			completePodWithStatus(dagRun.Tasks[0], table.podStatus)
+			<-done
+
+			if dagRun.Status != table.expectedStatus {
+				t.Errorf("Expected status %s, found %s", table.expectedStatus, dagRun.Status)
+			}
+			if dagRun.ExitCode != table.expectedExitCode || dagRun.Reason != table.expectedReason {
+				t.Errorf(
+					"Expected exit code %d and reason \"%s\", found %d and \"%s\"",
+					table.expectedExitCode,
+					table.expectedReason,
+					dagRun.ExitCode,
+					dagRun.Reason,
+				)
+			}
+			if dagRun.EndTime.IsZero() {
+				t.Error("End time should be set once the dag run has finished")
 			}
 
-			dagRun.holder.GetChannelGroup(dagRun.Name).Ready <- dagRun.pod
-
-			podCopy := dagRun.pod.DeepCopy()
-			podCopy.Status.Phase = core.PodSucceeded
-			dagRun.holder.GetChannelGroup(dagRun.Name).Update <- podCopy
-
-			time.Sleep(3 * time.Millisecond)
-
-			podList, err := client.CoreV1().Pods(
-				dagRun.Config.Namespace,
-			).List(
-				context.TODO(),
-				k8sapi.ListOptions{},
-			)
-			if err != nil {
-				panic(err)
+			rows := TABLECLIENT.GetLastNRunsForDagID(0, 1)
+			if len(rows) != 1 {
+				t.Fatalf("Expected 1 dag run row, found %d", len(rows))
 			}
-			for _, item := range podList.Items {
-				t.Log(item.Name)
-				if item.Name == dagRun.Name {
-					t.Errorf("Pod with name %s should have been deleted", dagRun.Name)
-				}
+			row := rows[0]
+			if row.Status != string(table.expectedStatus) ||
+				row.ExitCode != int(table.expectedExitCode) ||
+				row.Reason != table.expectedReason ||
+				row.EndDate.IsZero() {
+				t.Errorf("Dag run row %s does not match the finished dag run", row)
 			}
 		}()
+	}
+}
 
+func TestResume(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	defer podutils.CleanUpEnvironment(client)
+	setupDatabase()
+	defer database.PurgeDB(SQLCLIENT)
+
+	config := getTestDAGConfig("test-resume", []string{})
+	config.Tasks = []dagconfig.TaskConfig{
+		{Name: "extract", Command: []string{"echo", "extract"}},
+		{Name: "transform", Command: []string{"echo", "transform"}, DependsOn: []string{"extract"}},
+		{Name: "validate", Command: []string{"echo", "validate"}, DependsOn: []string{"extract"}},
+		{Name: "load", Command: []string{"echo", "load"}, DependsOn: []string{"transform"}},
+	}
+	dagRun := NewDAGRun(
+		getTestDate(),
+		config,
+		false,
+		client,
+		holder.New(),
+		activeruns.New(),
+		TABLECLIENT,
+		TASKTABLECLIENT,
+		0,
+	)
+	transform := dagRun.Task("transform")
+	podFrame, err := transform.getPodFrame()
+	if err != nil {
+		panic(err)
+	}
+	podFrame.Status.Phase = core.PodRunning
+	_, err = podClient(transform).Create(context.TODO(), &podFrame, k8sapi.CreateOptions{})
+	if err != nil {
+		panic(err)
+	}
+	noTime := time.Time{}
+	executionDate := dagRun.ExecutionDate.Time
+	taskRows := []taskinstancetable.Row{
+		taskinstancetable.NewRow(0, "extract", executionDate, "success", "", noTime, noTime),
+		taskinstancetable.NewRow(0, "transform", executionDate, "running", "", noTime, noTime),
+		taskinstancetable.NewRow(0, "validate", executionDate, "queued", "", noTime, noTime),
+	}
+	done := make(chan struct{})
+	go func() {
+		dagRun.Resume(dagruntable.NewRow(0, string(RunRunning), executionDate), taskRows)
+		close(done)
+	}()
+
+	waitForTaskPod(transform)
+	transformUpdate := podFrame.DeepCopy()
+	transformUpdate.Status.Phase = core.PodSucceeded
+	dagRun.holder.GetChannelGroup(transform.PodName).Update <- transformUpdate
+	load := dagRun.Task("load")
+	waitForTaskPod(load)
+	completePod(load, core.PodSucceeded)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Resumed DAG run should finish once no more tasks can be started")
 	}
 
+	expectedStates := map[string]TaskState{
+		"extract":   TaskSuccess,
+		"transform": TaskSuccess,
+		"validate":  TaskFailed,
+		"load":      TaskSuccess,
+	}
+	for name, expectedState := range expectedStates {
+		if dagRun.Task(name).GetState() != expectedState {
+			t.Errorf(
+				"Expected task %s in state %s, found %s",
+				name,
+				expectedState,
+				dagRun.Task(name).GetState(),
+			)
+		}
+	}
+	if dagRun.Status != RunFailed || dagRun.Reason != podNotFoundReason {
+		t.Errorf(
+			"Expected run to fail with reason %s, found status %s and reason %s",
+			podNotFoundReason,
+			dagRun.Status,
+			dagRun.Reason,
+		)
+	}
 }
@@ -0,0 +1,13 @@
+package run
+
+// RunStatus is the status of a DAG run
+type RunStatus string
+
+const (
+	// RunRunning is the status of a DAG run whose tasks have not all finished
+	RunRunning RunStatus = "running"
+	// RunSuccess is the status of a DAG run whose tasks have all succeeded
+	RunSuccess RunStatus = "success"
+	// RunFailed is the status of a DAG run with at least one task that did not succeed
+	RunFailed RunStatus = "failed"
+)
@@ -0,0 +1,15 @@
+package run
+
+// RunType is the way in which a DAG run was created
+type RunType string
+
+const (
+	// RunScheduled is the type of a run created by the DAG's schedule
+	RunScheduled RunType = "scheduled"
+	// RunManual is the type of a run that was triggered by a user
+	RunManual RunType = "manual"
+	// RunBackfill is the type of a run created by a backfill
+	RunBackfill RunType = "backfill"
+	// RunTriggered is the type of a run triggered by the success of an upstream DAG or dataset
+	RunTriggered RunType = "triggered"
+)
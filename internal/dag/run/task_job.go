@@ -0,0 +1,40 @@
+package run
+
+import (
+	batch "k8s.io/api/batch/v1"
+	core "k8s.io/api/core/v1"
+	k8sapi "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// getJobFrame returns a Job, named after the task's pod, whose pods are built from the given pod
+// frame. The DAG's TimeLimit applies to the Job as a whole rather than to each of its pods.
+func (taskRun *TaskRun) getJobFrame(podFrame core.Pod) batch.Job {
+	dagConfig := taskRun.dagRun.Config
+	podSpec := podFrame.Spec
+	podSpec.ActiveDeadlineSeconds = nil
+	var parallelism *int32
+	if dagConfig.Parallelism > 0 {
+		parallelism = &dagConfig.Parallelism
+	}
+	return batch.Job{
+		TypeMeta: k8sapi.TypeMeta{
+			Kind:       "Job",
+			APIVersion: "batch/v1",
+		},
+		ObjectMeta: podFrame.ObjectMeta,
+		Spec: batch.JobSpec{
+			Parallelism:             parallelism,
+			Completions:             dagConfig.Completions,
+			BackoffLimit:            dagConfig.BackoffLimit,
+			ActiveDeadlineSeconds:   dagConfig.TimeLimit,
+			TTLSecondsAfterFinished: dagConfig.TTLSecondsAfterFinished,
+			Template: core.PodTemplateSpec{
+				ObjectMeta: k8sapi.ObjectMeta{
+					Labels:      podFrame.Labels,
+					Annotations: podFrame.Annotations,
+				},
+				Spec: podSpec,
+			},
+		},
+	}
+}
@@ -0,0 +1,560 @@
+package run
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	dagconfig "goflow/internal/dag/config"
+	taskinstancetable "goflow/internal/dag/sql/taskinstance"
+	"goflow/internal/dag/templating"
+	"goflow/internal/executor"
+	"goflow/internal/jsonpanic"
+	"goflow/internal/k8s/pod/utils"
+	"goflow/internal/logs"
+
+	core "k8s.io/api/core/v1"
+	k8sapi "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// podNotFoundReason is the reason given for a resumed task whose pod no longer exists
+const podNotFoundReason = "PodNotFound"
+
+// launchErrorReason is the reason given for a task whose attempt could not be launched
+const launchErrorReason = "LaunchError"
+
+// launchError is returned when a task's executor fails to launch an attempt of the task
+type launchError struct {
+	err error
+}
+
+func (err *launchError) Error() string {
+	return err.err.Error()
+}
+
+// TaskRun is a single run of one task of a DAG - corresponds with a kubernetes pod
+type TaskRun struct {
+	Name        string
+	PodName     string
+	Config      dagconfig.TaskConfig
+	State       TaskState
+	Attempt     int
+	StartTime   k8sapi.Time
+	EndTime     k8sapi.Time
+	ExitCode    int32
+	Reason      string
+	basePodName string
+	execution   executor.Execution
+	dagRun      *DAGRun
+	stateLock   *sync.Mutex
+}
+
+// newTaskRun returns a new TaskRun for the given task configuration
+func newTaskRun(dagRun *DAGRun, taskConfig dagconfig.TaskConfig, podName string) *TaskRun {
+	taskRun := &TaskRun{
+		Name:        taskConfig.Name,
+		Config:      taskConfig,
+		basePodName: podName,
+		dagRun:      dagRun,
+		stateLock:   &sync.Mutex{},
+	}
+	taskRun.setAttempt(1)
+	return taskRun
+}
+
+// attemptPodName returns the name of the pod for the given attempt. The first attempt uses the
+// base pod name, so that every attempt's pod remains distinct. Names that are too long to be used
+// as a label are shortened.
+func (taskRun *TaskRun) attemptPodName(attempt int) string {
+	if attempt <= 1 {
+		return taskRun.basePodName
+	}
+	return utils.ShortenK8sName(fmt.Sprintf("%s-attempt-%d", taskRun.basePodName, attempt))
+}
+
+// setAttempt prepares the task to run the given attempt with its own pod
+func (taskRun *TaskRun) setAttempt(attempt int) {
+	taskRun.Attempt = attempt
+	taskRun.PodName = taskRun.attemptPodName(attempt)
+	taskRun.execution = nil
+	taskRun.ExitCode = 0
+	taskRun.Reason = ""
+}
+
+// log returns a log entry about the task's current attempt
+func (taskRun *TaskRun) log() logs.Entry {
+	fields := logs.Fields{logs.TaskField: taskRun.Name, logs.PodField: taskRun.PodName}
+	if taskRun.dagRun != nil {
+		return taskRun.dagRun.log().With(fields)
+	}
+	return logs.With(fields)
+}
+
+// hasAttemptsLeft returns true if the task may be retried after its current attempt
+func (taskRun *TaskRun) hasAttemptsLeft() bool {
+	return taskRun.Attempt <= int(taskRun.dagRun.Config.Retries)
+}
+
+func (taskRun *TaskRun) row() taskinstancetable.Row {
+	row := taskinstancetable.NewRow(
+		taskRun.dagRun.dagID,
+		taskRun.Name,
+		taskRun.dagRun.ExecutionDate.Time,
+		string(taskRun.State),
+		taskRun.PodName,
+		taskRun.StartTime.Time,
+		taskRun.EndTime.Time,
+	)
+	row.Attempt = taskRun.Attempt
+	return row
+}
+
+// restore sets the task's state, attempt and times to those stored in the task instance table
+func (taskRun *TaskRun) restore(row taskinstancetable.Row) {
+	taskRun.stateLock.Lock()
+	defer taskRun.stateLock.Unlock()
+	if row.Attempt > 1 {
+		taskRun.setAttempt(row.Attempt)
+	}
+	taskRun.State = TaskState(row.State)
+	taskRun.StartTime = k8sapi.Time{Time: row.StartDate}
+	taskRun.EndTime = k8sapi.Time{Time: row.EndDate}
+}
+
+// GetState returns the current state of the task
+func (taskRun *TaskRun) GetState() TaskState {
+	taskRun.stateLock.Lock()
+	defer taskRun.stateLock.Unlock()
+	return taskRun.State
+}
+
+// setState moves the task to the given state and records it in the task instance table,
+// returns false if the task cannot move to the given state
+func (taskRun *TaskRun) setState(state TaskState) bool {
+	taskRun.stateLock.Lock()
+	defer taskRun.stateLock.Unlock()
+	if taskRun.State == state {
+		return true
+	}
+	if !taskRun.State.CanTransitionTo(state) {
+		taskRun.log().Warningf(
+			"Task %s cannot move from state \"%s\" to state \"%s\"",
+			taskRun.PodName,
+			taskRun.State,
+			state,
+		)
+		return false
+	}
+	taskRun.log().Infof(
+		"Task %s moved from state \"%s\" to state \"%s\"",
+		taskRun.PodName,
+		taskRun.State,
+		state,
+	)
+	taskRun.State = state
+	if state == TaskRunning && taskRun.StartTime.IsZero() {
+		taskRun.StartTime = k8sapi.Time{Time: time.Now()}
+	}
+	if state.Finished() {
+		taskRun.EndTime = k8sapi.Time{Time: time.Now()}
+	}
+	taskRun.dagRun.taskTableClient.UpsertTaskInstance(taskRun.row())
+	return true
+}
+
+// handlePodPhase moves the task to the state matching the phase of its pod, recording the exit
+// code and termination reason of the pod once it has finished. A failed task that has attempts
+// left is marked as up for retry.
+func (taskRun *TaskRun) handlePodPhase(phase core.PodPhase, exitCode int32, reason string) {
+	state, ok := taskStateFromPodPhase(phase)
+	if !ok {
+		return
+	}
+	if state.Finished() {
+		taskRun.ExitCode, taskRun.Reason = exitCode, reason
+	}
+	if state == TaskFailed {
+		taskRun.fail(taskRun.Reason)
+		return
+	}
+	taskRun.setState(state)
+}
+
+// fail marks the task as up for retry if it has attempts left, or as failed otherwise. The tasks
+// of a terminated run are not retried.
+func (taskRun *TaskRun) fail(reason string) {
+	taskRun.Reason = reason
+	if taskRun.dagRun.isTerminated() {
+		taskRun.Reason = terminatedReason
+		taskRun.setState(TaskFailed)
+		return
+	}
+	if taskRun.hasAttemptsLeft() {
+		taskRun.setState(TaskUpForRetry)
+		return
+	}
+	taskRun.setState(TaskFailed)
+}
+
+// ReservedEnvPrefix starts the names of the environment variables that describe the run, which a
+// run's conf may not set
+const ReservedEnvPrefix = "GOFLOW_"
+
+// Names of the environment variables that describe the run to every task's container
+const (
+	executionDateEnv     = "GOFLOW_EXECUTION_DATE"
+	dataIntervalStartEnv = "GOFLOW_DATA_INTERVAL_START"
+	dataIntervalEndEnv   = "GOFLOW_DATA_INTERVAL_END"
+	dagNameEnv           = "GOFLOW_DAG_NAME"
+	runIDEnv             = "GOFLOW_RUN_ID"
+	taskNameEnv          = "GOFLOW_TASK_NAME"
+	attemptEnv           = "GOFLOW_ATTEMPT"
+)
+
+// templateErrorReason is the reason given for a task whose pod cannot be built from its templates
+const templateErrorReason = "TemplateError"
+
+// templateContext returns the values available to the templates of the task's current attempt
+func (taskRun *TaskRun) templateContext() templating.Context {
+	dagRun := taskRun.dagRun
+	return templating.Context{
+		ExecutionDate:     dagRun.ExecutionDate.Time,
+		DataIntervalStart: dagRun.DataIntervalStart.Time,
+		DataIntervalEnd:   dagRun.DataIntervalEnd.Time,
+		DAGName:           dagRun.Config.Name,
+		RunID:             dagRun.Name,
+		TaskName:          taskRun.Name,
+		Attempt:           taskRun.Attempt,
+		Conf:              dagRun.Conf,
+	}
+}
+
+// env returns the environment variables passed to the task's container. The variables describing
+// the run come first, followed by the task's rendered Env, the dag run's conf and the DAG's
+// SecretEnv references, each of which takes precedence over the ones before it. The variables
+// describing the run are applied last, so that they cannot be overridden.
+func (taskRun *TaskRun) env(runContext templating.Context) ([]core.EnvVar, error) {
+	names := []string{
+		executionDateEnv,
+		dataIntervalStartEnv,
+		dataIntervalEndEnv,
+		dagNameEnv,
+		runIDEnv,
+		taskNameEnv,
+		attemptEnv,
+	}
+	contextEnvVars := map[string]core.EnvVar{
+		executionDateEnv:     {Value: runContext.ExecutionDate.Format(time.RFC3339)},
+		dataIntervalStartEnv: {Value: runContext.DataIntervalStart.Format(time.RFC3339)},
+		dataIntervalEndEnv:   {Value: runContext.DataIntervalEnd.Format(time.RFC3339)},
+		dagNameEnv:           {Value: runContext.DAGName},
+		runIDEnv:             {Value: runContext.RunID},
+		taskNameEnv:          {Value: runContext.TaskName},
+		attemptEnv:           {Value: strconv.Itoa(runContext.Attempt)},
+	}
+	envVars := make(map[string]core.EnvVar)
+	for name, text := range taskRun.Config.Env {
+		value, err := templating.Render(text, runContext)
+		if err != nil {
+			return nil, err
+		}
+		envVars[name] = core.EnvVar{Value: value}
+	}
+	for name, value := range runContext.Conf {
+		envVars[name] = core.EnvVar{Value: value}
+	}
+	for _, secretEnvVar := range taskRun.dagRun.Config.SecretEnvVars() {
+		envVars[secretEnvVar.Name] = secretEnvVar
+	}
+	extraNames := make([]string, 0, len(envVars))
+	for name := range envVars {
+		if _, ok := contextEnvVars[name]; !ok {
+			extraNames = append(extraNames, name)
+		}
+	}
+	sort.Strings(extraNames)
+	for name, envVar := range contextEnvVars {
+		envVars[name] = envVar
+	}
+	names = append(names, extraNames...)
+	env := make([]core.EnvVar, 0, len(names))
+	for _, name := range names {
+		envVar := envVars[name]
+		envVar.Name = name
+		env = append(env, envVar)
+	}
+	return env, nil
+}
+
+// getContainerFrame returns the task's container, with its Command, Args and Env rendered from
+// their templates
+func (taskRun *TaskRun) getContainerFrame() (core.Container, error) {
+	runContext := taskRun.templateContext()
+	command, err := templating.RenderAll(taskRun.Config.Command, runContext)
+	if err != nil {
+		return core.Container{}, err
+	}
+	args, err := templating.RenderAll(taskRun.Config.Args, runContext)
+	if err != nil {
+		return core.Container{}, err
+	}
+	env, err := taskRun.env(runContext)
+	if err != nil {
+		return core.Container{}, err
+	}
+	return core.Container{
+		Name:            dagconfig.TaskContainerName,
+		Image:           taskRun.Config.DockerImage,
+		Command:         command,
+		Args:            args,
+		WorkingDir:      "",
+		EnvFrom:         taskRun.dagRun.Config.EnvFromSources(),
+		Env:             env,
+		VolumeMounts:    nil,
+		VolumeDevices:   nil,
+		ImagePullPolicy: core.PullIfNotPresent,
+	}, nil
+}
+
+// getPodFrame returns a pod from a TaskRun, with the DAG's PodTemplate merged over it
+func (taskRun *TaskRun) getPodFrame() (core.Pod, error) {
+	container, err := taskRun.getContainerFrame()
+	if err != nil {
+		return core.Pod{}, err
+	}
+	dagConfig := taskRun.dagRun.Config
+	labels := copyStringMap(dagConfig.Labels)
+	labels["Name"] = taskRun.PodName
+	labels["App"] = "goflow"
+	labels["Task"] = utils.ShortenK8sName(taskRun.Name)
+	labels["Attempt"] = strconv.Itoa(taskRun.Attempt)
+	spec, err := dagConfig.MergePodTemplate(core.PodSpec{
+		Volumes:               nil,
+		Containers:            []core.Container{container},
+		EphemeralContainers:   nil,
+		RestartPolicy:         dagConfig.RetryPolicy,
+		ActiveDeadlineSeconds: dagConfig.TimeLimit,
+		ServiceAccountName:    serviceAccount,
+	})
+	if err != nil {
+		return core.Pod{}, err
+	}
+	return core.Pod{
+		TypeMeta: k8sapi.TypeMeta{
+			Kind:       "Pod",
+			APIVersion: "v1",
+		},
+		ObjectMeta: k8sapi.ObjectMeta{
+			Name:        taskRun.PodName,
+			Namespace:   dagConfig.Namespace,
+			Labels:      labels,
+			Annotations: dagConfig.Annotations,
+		},
+		Spec: spec,
+	}, nil
+}
+
+// attempt returns the task's current attempt, as given to its executor, built from the given pod
+// frame. The Job is also built when the DAG runs its tasks as Jobs.
+func (taskRun *TaskRun) attempt(podFrame core.Pod) executor.Attempt {
+	attempt := executor.Attempt{Pod: podFrame, WithLogs: taskRun.dagRun.withLogs}
+	if taskRun.dagRun.Config.RunsAsJob() {
+		jobFrame := taskRun.getJobFrame(podFrame)
+		attempt.Job = &jobFrame
+	}
+	return attempt
+}
+
+// setExecution records the execution of the task's current attempt
+func (taskRun *TaskRun) setExecution(execution executor.Execution) {
+	taskRun.stateLock.Lock()
+	defer taskRun.stateLock.Unlock()
+	taskRun.execution = execution
+}
+
+// getExecution returns the execution of the task's current attempt, or nil if it has not been
+// launched
+func (taskRun *TaskRun) getExecution() executor.Execution {
+	taskRun.stateLock.Lock()
+	defer taskRun.stateLock.Unlock()
+	return taskRun.execution
+}
+
+// Run launches the task's current attempt with the dag run's executor, returns an error without
+// launching the attempt if the pod cannot be built from the task's templates or if the executor
+// fails to launch it
+func (taskRun *TaskRun) Run() error {
+	taskRun.setState(TaskQueued)
+	podFrame, err := taskRun.getPodFrame()
+	if err != nil {
+		return err
+	}
+	execution, err := taskRun.dagRun.executor.Launch(
+		taskRun.attempt(podFrame),
+		taskRun.handlePodPhase,
+	)
+	if err != nil {
+		return &launchError{err}
+	}
+	taskRun.setExecution(execution)
+	if taskRun.dagRun.isTerminated() {
+		taskRun.DeletePod()
+	}
+	return nil
+}
+
+// Start runs the task, retrying it until it succeeds or has no attempts left
+func (taskRun *TaskRun) Start() {
+	taskRun.runAttempt()
+	taskRun.retryWhileUpForRetry()
+}
+
+// runAttempt runs the current attempt of the task and waits for the monitoring to finish
+func (taskRun *TaskRun) runAttempt() {
+	if taskRun.isSensor() {
+		taskRun.runSensorAttempt()
+		return
+	}
+	err := taskRun.Run()
+	if err != nil {
+		var launchErr *launchError
+		if errors.As(err, &launchErr) {
+			taskRun.log().Errorf("Task %s could not be run: %s", taskRun.PodName, err.Error())
+			taskRun.fail(launchErrorReason)
+			return
+		}
+		taskRun.failTemplate(err)
+		return
+	}
+	taskRun.getExecution().Wait()
+	taskRun.cleanUpAttempt()
+}
+
+// failTemplate marks a task whose templates could not be rendered as failed. Rendering the
+// templates again would give the same error, so the task is not retried.
+func (taskRun *TaskRun) failTemplate(err error) {
+	taskRun.log().Errorf("Task %s could not be run: %s", taskRun.PodName, err.Error())
+	taskRun.Reason = templateErrorReason
+	taskRun.setState(TaskFailed)
+}
+
+// cleanUpAttempt deletes the pod of the attempt once it has finished, whether it succeeded or
+// failed, since its logs have already been collected by then
+func (taskRun *TaskRun) cleanUpAttempt() {
+	taskRun.DeletePod()
+}
+
+// retryWhileUpForRetry runs new attempts of the task, after waiting for the retry delay, for as
+// long as the task is up for retry
+func (taskRun *TaskRun) retryWhileUpForRetry() {
+	for taskRun.GetState() == TaskUpForRetry {
+		delay := taskRun.dagRun.Config.RetryDelayAfter(taskRun.Attempt)
+		taskRun.log().Infof(
+			"Task %s failed attempt %d, retrying in %s",
+			taskRun.PodName,
+			taskRun.Attempt,
+			delay,
+		)
+		select {
+		case <-time.After(delay):
+		case <-taskRun.dagRun.terminated:
+			taskRun.fail(terminatedReason)
+			return
+		}
+		taskRun.stateLock.Lock()
+		taskRun.setAttempt(taskRun.Attempt + 1)
+		taskRun.stateLock.Unlock()
+		taskRun.runAttempt()
+	}
+}
+
+// Resume takes over the task's pod if it is still present and waits for it to finish, retrying
+// the task if it fails. A task whose pod no longer exists is treated as failed.
+func (taskRun *TaskRun) Resume() {
+	defer taskRun.retryWhileUpForRetry()
+	if taskRun.GetState() == TaskUpForRetry {
+		return
+	}
+	if taskRun.isSensor() {
+		taskRun.resumeSensor()
+		return
+	}
+	podFrame := core.Pod{ObjectMeta: k8sapi.ObjectMeta{
+		Name:      taskRun.PodName,
+		Namespace: taskRun.dagRun.Config.Namespace,
+	}}
+	execution, found := taskRun.dagRun.executor.Resume(
+		taskRun.attempt(podFrame),
+		taskRun.handlePodPhase,
+	)
+	if !found {
+		taskRun.log().Warningf(
+			"Pod %s of task %s no longer exists, marking the task as failed",
+			taskRun.PodName,
+			taskRun.Name,
+		)
+		taskRun.fail(podNotFoundReason)
+		return
+	}
+	defer taskRun.cleanUpAttempt()
+	taskRun.setExecution(execution)
+	execution.Wait()
+}
+
+// Succeeded returns true if the task's pod has completed successfully
+func (taskRun *TaskRun) Succeeded() bool {
+	return taskRun.GetState() == TaskSuccess
+}
+
+// Logs returns the channel holding the logs of the task's current attempt, which is nil until
+// the attempt has been launched
+func (taskRun *TaskRun) Logs() chan string {
+	execution := taskRun.getExecution()
+	if execution == nil {
+		return nil
+	}
+	return execution.Logs()
+}
+
+// LogText returns the logs of the task's current attempt so far, which are empty until the
+// attempt has been launched or if the DAG does not keep its logs
+func (taskRun *TaskRun) LogText() string {
+	execution := taskRun.getExecution()
+	if execution == nil {
+		return ""
+	}
+	return execution.LogText()
+}
+
+// DeletePod cancels the task's current attempt, deleting its pod, or its Job when the DAG runs
+// its tasks as Jobs. An attempt that cannot be cancelled is logged and left behind.
+func (taskRun *TaskRun) DeletePod() {
+	execution := taskRun.getExecution()
+	if execution == nil {
+		return
+	}
+	err := execution.Cancel()
+	if err != nil {
+		taskRun.log().With(logs.Fields{logs.ErrorField: err}).Warningf(
+			"Could not delete pod %s of task %s",
+			taskRun.PodName,
+			taskRun.Name,
+		)
+	}
+}
+
+// MostRecentPod returns the pod run for this task run
+func (taskRun *TaskRun) MostRecentPod() (core.Pod, error) {
+	execution := taskRun.getExecution()
+	if execution == nil {
+		return core.Pod{}, fmt.Errorf("pod %s has not been created yet", taskRun.PodName)
+	}
+	return *execution.Pod(), nil
+}
+
+func (taskRun *TaskRun) String() string {
+	return jsonpanic.JSONPanicFormat(taskRun)
+}
@@ -0,0 +1,599 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"goflow/internal/dag/activeruns"
+	dagconfig "goflow/internal/dag/config"
+	"goflow/internal/database"
+	"goflow/internal/executor"
+	"goflow/internal/jsonpanic"
+	"strings"
+	"testing"
+	"time"
+
+	"goflow/internal/k8s/pod/event/holder"
+	podutils "goflow/internal/k8s/pod/utils"
+
+	"github.com/google/go-cmp/cmp"
+	core "k8s.io/api/core/v1"
+	k8sapi "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func getTestTaskRun(
+	client kubernetes.Interface,
+	name string,
+	command []string,
+	withLogs bool,
+) *TaskRun {
+	dagRun := NewDAGRun(
+		getTestDate(),
+		getTestDAGConfig(name, command),
+		withLogs,
+		client,
+		holder.New(),
+		activeruns.New(),
+		TABLECLIENT,
+		TASKTABLECLIENT,
+		0,
+	)
+	return dagRun.Tasks[0]
+}
+
+// podClient returns the api endpoint for the pods of the task
+func podClient(taskRun *TaskRun) v1.PodInterface {
+	return taskRun.dagRun.kubeClient.CoreV1().Pods(taskRun.dagRun.Config.Namespace)
+}
+
+// taskPod returns the latest pod of the task's current attempt, or nil if it has not been launched
+func taskPod(taskRun *TaskRun) *core.Pod {
+	execution := taskRun.getExecution()
+	if execution == nil {
+		return nil
+	}
+	return execution.Pod()
+}
+
+// completePod sends the task's pod through the informer channels, finishing in the given phase
+func completePod(taskRun *TaskRun, phase core.PodPhase) {
+	completePodWithStatus(taskRun, core.PodStatus{Phase: phase})
+}
+
+func completePodWithStatus(taskRun *TaskRun, status core.PodStatus) {
+	channelGroup := taskRun.dagRun.holder.GetChannelGroup(taskRun.PodName)
+	channelGroup.Ready <- taskPod(taskRun)
+	podCopy := taskPod(taskRun).DeepCopy()
+	podCopy.Status = status
+	channelGroup.Update <- podCopy
+}
+
+func TestCreatePod(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	defer podutils.CleanUpEnvironment(client)
+	setupDatabase()
+	defer database.PurgeDB(SQLCLIENT)
+	taskRun := getTestTaskRun(client, "test-create-pod", []string{}, false)
+	taskRun.setState(TaskScheduled)
+	err := taskRun.Run()
+	if err != nil {
+		panic(err)
+	}
+	foundPod, err := client.CoreV1().Pods(
+		taskRun.dagRun.Config.Namespace,
+	).Get(
+		context.TODO(),
+		taskRun.PodName,
+		k8sapi.GetOptions{},
+	)
+	if err != nil {
+		panic(err)
+	}
+	foundPodValue := jsonpanic.JSONPanic(*foundPod)
+	expectedValue := jsonpanic.JSONPanic(*taskPod(taskRun))
+	if foundPodValue != expectedValue {
+		t.Error("Expected:", expectedValue)
+		t.Error("Found:", foundPodValue)
+	}
+}
+
+func TestRunPod(t *testing.T) {
+	// Test with logs and without logs
+	client := fake.NewSimpleClientset()
+	defer podutils.CleanUpEnvironment(client)
+	setupDatabase()
+	defer database.PurgeDB(SQLCLIENT)
+	tables := []struct {
+		name     string
+		withLogs bool
+	}{
+		{"Without Logs", false},
+		{"With Logs", true},
+	}
+	for _, table := range tables {
+		t.Logf("Test case: %s", table.name)
+		func() {
+			expectedLogMessage := "Hello World!!!"
+			taskRun := getTestTaskRun(
+				client,
+				"test-start-pod"+podutils.CleanK8sName(table.name),
+				[]string{"echo", expectedLogMessage},
+				table.withLogs,
+			)
+			taskRun.Run()
+
+			completePod(taskRun, core.PodSucceeded)
+
+			taskRun.getExecution().Wait()
+
+			// Test for task completion in state of task
+			phase := taskPod(taskRun).Status.Phase
+			if (phase != core.PodSucceeded) && (phase != core.PodFailed) {
+				t.Errorf(
+					"A finished dagRun should be in phase %s or state %s, but found in state %s",
+					core.PodSucceeded,
+					core.PodFailed,
+					phase,
+				)
+			}
+
+			// Test for log output if logs enabled
+			if table.withLogs {
+				logMsg := <-taskRun.Logs()
+				logMsg = strings.ReplaceAll(logMsg, "\n", "")
+				if logMsg != expectedLogMessage && logMsg != "fake logs" {
+					t.Errorf(
+						"Expected log message %s, found log message %s",
+						expectedLogMessage,
+						logMsg,
+					)
+				}
+			}
+		}()
+
+	}
+
+}
+
+func TestDeletePod(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	defer podutils.CleanUpEnvironment(client)
+	setupDatabase()
+	defer database.PurgeDB(SQLCLIENT)
+	taskRun := getTestTaskRun(client, "test-delete-pod", []string{}, false)
+	taskRun.setState(TaskScheduled)
+	err := taskRun.Run()
+	if err != nil {
+		panic(err)
+	}
+	podsClient := podClient(taskRun)
+	createdPod := taskPod(taskRun)
+	taskRun.DeletePod()
+	list, err := podsClient.List(context.TODO(), k8sapi.ListOptions{})
+	if err != nil {
+		panic(err)
+	}
+	for _, pod := range list.Items {
+		if jsonpanic.JSONPanic(*createdPod) == jsonpanic.JSONPanic(pod) {
+			t.Errorf("Pod %s should have been deleted", createdPod.Name)
+		}
+	}
+}
+
+func TestDeletePodError(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	defer podutils.CleanUpEnvironment(client)
+	setupDatabase()
+	defer database.PurgeDB(SQLCLIENT)
+	client.PrependReactor(
+		"delete",
+		"pods",
+		func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, errors.New("the server is currently unable to handle the request")
+		},
+	)
+	taskRun := getTestTaskRun(client, "test-delete-pod-error", []string{}, false)
+	taskRun.setState(TaskScheduled)
+	done := make(chan struct{})
+	go func() {
+		taskRun.Start()
+		close(done)
+	}()
+	waitForAttemptPod(taskRun, 1)
+	completePod(taskRun, core.PodSucceeded)
+	<-done
+	if taskRun.GetState() != TaskSuccess {
+		t.Errorf("Expected the task to succeed, found %s", taskRun.GetState())
+	}
+}
+
+func TestRunJob(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	defer podutils.CleanUpEnvironment(client)
+	setupDatabase()
+	defer database.PurgeDB(SQLCLIENT)
+	taskRun := getTestTaskRun(client, "test-run-job", []string{}, false)
+	completions, backoffLimit := int32(3), int32(2)
+	timeLimit := int64(60)
+	dagConfig := taskRun.dagRun.Config
+	dagConfig.ExecutionMode = dagconfig.JobExecution
+	dagConfig.Parallelism = 2
+	dagConfig.Completions = &completions
+	dagConfig.BackoffLimit = &backoffLimit
+	dagConfig.TimeLimit = &timeLimit
+	taskRun.setAttempt(1)
+	taskRun.setState(TaskScheduled)
+	err := taskRun.Run()
+	if err != nil {
+		panic(err)
+	}
+
+	jobsClient := client.BatchV1().Jobs(taskRun.dagRun.Config.Namespace)
+	job, err := jobsClient.Get(context.TODO(), taskRun.PodName, k8sapi.GetOptions{})
+	if err != nil {
+		panic(err)
+	}
+	spec := job.Spec
+	if *spec.Parallelism != 2 || *spec.Completions != completions ||
+		*spec.BackoffLimit != backoffLimit || *spec.ActiveDeadlineSeconds != timeLimit {
+		t.Errorf("Job spec does not match the DAG config: %s", jsonpanic.JSONPanic(spec))
+	}
+	if spec.Template.Spec.ActiveDeadlineSeconds != nil {
+		t.Error("The time limit should apply to the job rather than to each of its pods")
+	}
+
+	// The exit code comes from the job's pod, while the reason comes from the job
+	jobPod := spec.Template.DeepCopy()
+	jobPod.Name = taskRun.PodName + "-abcde"
+	jobPod.Labels = map[string]string{podutils.JobNameLabel: taskRun.PodName}
+	_, err = podClient(taskRun).Create(
+		context.TODO(),
+		&core.Pod{
+			ObjectMeta: jobPod.ObjectMeta,
+			Spec:       jobPod.Spec,
+			Status: core.PodStatus{
+				Phase: core.PodFailed,
+				ContainerStatuses: []core.ContainerStatus{{State: core.ContainerState{
+					Terminated: &core.ContainerStateTerminated{ExitCode: 3, Reason: "Error"},
+				}}},
+			},
+		},
+		k8sapi.CreateOptions{},
+	)
+	if err != nil {
+		panic(err)
+	}
+	completePodWithStatus(
+		taskRun,
+		core.PodStatus{Phase: core.PodFailed, Reason: "BackoffLimitExceeded"},
+	)
+	taskRun.getExecution().Wait()
+	if taskRun.GetState() != TaskFailed || taskRun.ExitCode != 3 ||
+		taskRun.Reason != "BackoffLimitExceeded" {
+		t.Errorf(
+			"Expected failed task with exit code 3 and reason BackoffLimitExceeded, found %s",
+			taskRun,
+		)
+	}
+
+	taskRun.DeletePod()
+	_, err = jobsClient.Get(context.TODO(), taskRun.PodName, k8sapi.GetOptions{})
+	if err == nil {
+		t.Errorf("Job %s should have been deleted", taskRun.PodName)
+	}
+}
+
+func TestStartWithLocalExecutor(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	setupDatabase()
+	defer database.PurgeDB(SQLCLIENT)
+	cases := []struct {
+		name             string
+		command          []string
+		expectedState    TaskState
+		expectedExitCode int32
+	}{
+		{"succeeds", []string{"sh", "-c", "echo $GOFLOW_TASK_NAME"}, TaskSuccess, 0},
+		{"fails", []string{"sh", "-c", "exit 4"}, TaskFailed, 4},
+	}
+	for _, testCase := range cases {
+		taskRun := getTestTaskRun(client, "test-local-"+testCase.name, testCase.command, true)
+		taskRun.dagRun.SetExecutor(executor.NewLocalExecutor(""))
+		taskRun.setState(TaskScheduled)
+		taskRun.Start()
+		if taskRun.GetState() != testCase.expectedState {
+			t.Errorf("Expected state %s, found %s", testCase.expectedState, taskRun.GetState())
+		}
+		if taskRun.ExitCode != testCase.expectedExitCode {
+			t.Errorf("Expected exit code %d, found %d", testCase.expectedExitCode, taskRun.ExitCode)
+		}
+		if testCase.expectedState == TaskSuccess {
+			logMsg := strings.TrimSpace(<-taskRun.Logs())
+			if logMsg != taskRun.Name {
+				t.Errorf("Expected log message %s, found %s", taskRun.Name, logMsg)
+			}
+			if strings.TrimSpace(taskRun.LogText()) != taskRun.Name {
+				t.Errorf("Expected log text %s, found %s", taskRun.Name, taskRun.LogText())
+			}
+		}
+		podList, err := podClient(taskRun).List(context.TODO(), k8sapi.ListOptions{})
+		if err != nil {
+			panic(err)
+		}
+		if len(podList.Items) != 0 {
+			t.Errorf("The local executor should not create pods, found %d", len(podList.Items))
+		}
+	}
+}
+
+// waitForAttemptPod waits until the pod of the given attempt of the task has been created
+func waitForAttemptPod(taskRun *TaskRun, attempt int) {
+	for {
+		taskRun.stateLock.Lock()
+		ready := taskRun.Attempt == attempt && taskRun.execution != nil &&
+			taskRun.dagRun.holder.Contains(taskRun.PodName)
+		taskRun.stateLock.Unlock()
+		if ready {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestStartRetriesFailedTask(t *testing.T) {
+	cases := []struct {
+		name            string
+		retries         int32
+		finalPhase      core.PodPhase
+		expectedState   TaskState
+		expectedAttempt int
+	}{
+		{"Succeeds On Last Attempt", 2, core.PodSucceeded, TaskSuccess, 3},
+		{"Runs Out Of Attempts", 1, core.PodFailed, TaskFailed, 2},
+	}
+	for _, testCase := range cases {
+		t.Logf("Test case: %s", testCase.name)
+		func() {
+			client := fake.NewSimpleClientset()
+			setupDatabase()
+			defer database.PurgeDB(SQLCLIENT)
+			taskRun := getTestTaskRun(
+				client,
+				"test-retry-"+podutils.CleanK8sName(testCase.name),
+				[]string{},
+				false,
+			)
+			taskRun.dagRun.Config.Retries = testCase.retries
+			taskRun.dagRun.Config.RetryBackoff = "exponential"
+			taskRun.setState(TaskScheduled)
+			done := make(chan struct{})
+			go func() {
+				taskRun.Start()
+				close(done)
+			}()
+
+			for attempt := 1; attempt < testCase.expectedAttempt; attempt++ {
+				waitForAttemptPod(taskRun, attempt)
+				completePod(taskRun, core.PodFailed)
+			}
+			waitForAttemptPod(taskRun, testCase.expectedAttempt)
+			completePod(taskRun, testCase.finalPhase)
+			<-done
+
+			if taskRun.GetState() != testCase.expectedState {
+				t.Errorf("Expected state %s, found %s", testCase.expectedState, taskRun.GetState())
+			}
+			rows := TASKTABLECLIENT.GetTaskInstancesForDagRun(0, taskRun.dagRun.ExecutionDate.Time)
+			if len(rows) != 1 || rows[0].Attempt != testCase.expectedAttempt {
+				t.Errorf(
+					"Expected attempt %d to be recorded, found %v",
+					testCase.expectedAttempt,
+					rows,
+				)
+			}
+
+			// Every attempt is recorded with its own pod, which is deleted once it has finished
+			attemptRows := TASKTABLECLIENT.GetTaskAttemptsForDagRun(
+				0,
+				taskRun.dagRun.ExecutionDate.Time,
+			)
+			if len(attemptRows) != testCase.expectedAttempt {
+				t.Errorf(
+					"Expected %d attempts to be recorded, found %v",
+					testCase.expectedAttempt,
+					attemptRows,
+				)
+			}
+			for i, row := range attemptRows {
+				if row.Attempt != i+1 || row.PodName != taskRun.attemptPodName(i+1) {
+					t.Errorf("Row %s does not match attempt %d", row, i+1)
+				}
+			}
+			podList, err := podClient(taskRun).List(context.TODO(), k8sapi.ListOptions{})
+			if err != nil {
+				panic(err)
+			}
+			if len(podList.Items) != 0 {
+				t.Errorf("Expected the pods of all attempts to be deleted, found %v", podList.Items)
+			}
+		}()
+	}
+}
+
+func TestLongNamesFitInLabels(t *testing.T) {
+	longName := strings.Repeat("long-", 20)
+	config := getTestDAGConfig("test-"+longName+"dag", []string{})
+	config.Tasks = []dagconfig.TaskConfig{
+		{Name: longName + "first", Command: []string{"echo", "first"}},
+		{Name: longName + "second", Command: []string{"echo", "second"}},
+	}
+	dagRun := NewDAGRun(
+		getTestDate(),
+		config,
+		false,
+		fake.NewSimpleClientset(),
+		holder.New(),
+		activeruns.New(),
+		TABLECLIENT,
+		TASKTABLECLIENT,
+		0,
+	)
+
+	names := make(map[string]bool)
+	for _, taskRun := range dagRun.Tasks {
+		for attempt := 1; attempt <= 2; attempt++ {
+			taskRun.setAttempt(attempt)
+			podFrame, err := taskRun.getPodFrame()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if names[podFrame.Name] {
+				t.Errorf("Expected pod names to be distinct, found %s twice", podFrame.Name)
+			}
+			names[podFrame.Name] = true
+			for key, value := range podFrame.Labels {
+				if len(value) > podutils.MaxNameLength {
+					t.Errorf("Label %s of pod %s is too long: %s", key, podFrame.Name, value)
+				}
+			}
+		}
+	}
+	if len(names) != 4 {
+		t.Errorf("Expected 4 distinct pod names, found %v", names)
+	}
+}
+
+func TestGetPodFrameRendersTemplates(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	taskRun := getTestTaskRun(
+		client,
+		"test-templates",
+		[]string{"process", `{{ .ExecutionDate | date "2006-01-02" }}`},
+		false,
+	)
+	taskRun.dagRun.DataIntervalEnd = k8sapi.Time{Time: getTestDate().AddDate(0, 0, 1)}
+	// The variables describing the run cannot be overridden by the conf
+	taskRun.dagRun.Conf = map[string]string{"TABLE": "users", attemptEnv: "7"}
+	taskRun.Config.Args = []string{"--table={{ .Conf.TABLE }}", "--attempt={{ .Attempt }}"}
+	taskRun.Config.Env = map[string]string{
+		"OUTPUT":   `/data/{{ .DAGName }}/{{ .DataIntervalEnd | date "20060102" }}`,
+		"TABLE":    "overridden by conf",
+		"GOFLOW_X": "custom",
+	}
+	podFrame, err := taskRun.getPodFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	container := podFrame.Spec.Containers[0]
+	expectedCommand := []string{"process", getTestDate().Format("2006-01-02")}
+	if !cmp.Equal(container.Command, expectedCommand) {
+		t.Errorf("Expected command %v, found %v", expectedCommand, container.Command)
+	}
+	expectedArgs := []string{"--table=users", "--attempt=1"}
+	if !cmp.Equal(container.Args, expectedArgs) {
+		t.Errorf("Expected args %v, found %v", expectedArgs, container.Args)
+	}
+	expectedEnv := []core.EnvVar{
+		{Name: executionDateEnv, Value: getTestDate().Format(time.RFC3339)},
+		{Name: dataIntervalStartEnv, Value: getTestDate().Format(time.RFC3339)},
+		{Name: dataIntervalEndEnv, Value: getTestDate().AddDate(0, 0, 1).Format(time.RFC3339)},
+		{Name: dagNameEnv, Value: "test-templates"},
+		{Name: runIDEnv, Value: taskRun.dagRun.Name},
+		{Name: taskNameEnv, Value: dagconfig.DefaultTaskName},
+		{Name: attemptEnv, Value: "1"},
+		{Name: "GOFLOW_X", Value: "custom"},
+		{
+			Name:  "OUTPUT",
+			Value: "/data/test-templates/" + getTestDate().AddDate(0, 0, 1).Format("20060102"),
+		},
+		{Name: "TABLE", Value: "users"},
+	}
+	if !cmp.Equal(container.Env, expectedEnv) {
+		t.Errorf("Expected env %v, found %v", expectedEnv, container.Env)
+	}
+}
+
+func TestStartFailsOnTemplateError(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	setupDatabase()
+	defer database.PurgeDB(SQLCLIENT)
+	taskRun := getTestTaskRun(
+		client,
+		"test-template-error",
+		[]string{"echo", "{{ .Conf.MISSING }}"},
+		false,
+	)
+	taskRun.dagRun.Config.Retries = 2
+	taskRun.setState(TaskScheduled)
+	taskRun.Start()
+	if taskRun.GetState() != TaskFailed || taskRun.Reason != templateErrorReason {
+		t.Errorf("Expected task to fail with reason %s, found %s", templateErrorReason, taskRun)
+	}
+	if taskRun.Attempt != 1 {
+		t.Errorf("A task with a template error should not be retried, found %d", taskRun.Attempt)
+	}
+	podList, err := podClient(taskRun).List(context.TODO(), k8sapi.ListOptions{})
+	if err != nil {
+		panic(err)
+	}
+	if len(podList.Items) != 0 {
+		t.Errorf("Expected no pods to be created, found %d", len(podList.Items))
+	}
+}
+
+func TestGetPodFrameMergesPodTemplate(t *testing.T) {
+	taskRun := getTestTaskRun(fake.NewSimpleClientset(), "test-pod-template", []string{}, false)
+	taskRun.dagRun.Config.PodTemplate = &core.PodSpec{
+		NodeSelector: map[string]string{"gpu": "true"},
+		Containers: []core.Container{{
+			Env: []core.EnvVar{{Name: "EXTRA", Value: "1"}},
+		}},
+	}
+	podFrame, err := taskRun.getPodFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if podFrame.Spec.NodeSelector["gpu"] != "true" {
+		t.Errorf("Expected the pod template's node selector, found %v", podFrame.Spec.NodeSelector)
+	}
+	container := podFrame.Spec.Containers[0]
+	envNames := make(map[string]bool)
+	for _, envVar := range container.Env {
+		envNames[envVar.Name] = true
+	}
+	if container.Image != "busybox" || !envNames[executionDateEnv] {
+		t.Errorf("Expected the generated container to be kept, found %v", container)
+	}
+	if !envNames["EXTRA"] {
+		t.Errorf("Expected the pod template's env to be added, found %v", container.Env)
+	}
+	if podFrame.Spec.ServiceAccountName != serviceAccount {
+		t.Errorf("Expected service account %s, found %v", serviceAccount, podFrame.Spec)
+	}
+}
+
+func TestGetPodFrameSecretReferences(t *testing.T) {
+	taskRun := getTestTaskRun(fake.NewSimpleClientset(), "test-secrets", []string{}, false)
+	taskRun.dagRun.Config.EnvFromSecrets = []string{"db-credentials"}
+	taskRun.dagRun.Config.SecretEnv = map[string]dagconfig.SecretKeyRef{
+		"API_KEY": {Secret: "api", Key: "key"},
+	}
+	taskRun.dagRun.Conf = map[string]string{"API_KEY": "from conf"}
+	podFrame, err := taskRun.getPodFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	container := podFrame.Spec.Containers[0]
+	if len(container.EnvFrom) != 1 || container.EnvFrom[0].SecretRef.Name != "db-credentials" {
+		t.Errorf("Expected env from secret db-credentials, found %v", container.EnvFrom)
+	}
+	apiKey := container.Env[len(container.Env)-1]
+	if apiKey.Name != "API_KEY" || apiKey.Value != "" ||
+		apiKey.ValueFrom.SecretKeyRef.Name != "api" {
+		t.Errorf("Expected API_KEY to be read from secret api, found %v", apiKey)
+	}
+}
@@ -0,0 +1,155 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	dagconfig "goflow/internal/dag/config"
+	"goflow/internal/dag/sensor"
+	"goflow/internal/dag/templating"
+	"goflow/internal/executor"
+
+	k8sapi "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// sensorTimeoutReason is the reason given for a sensor whose condition did not hold in time
+const sensorTimeoutReason = "SensorTimeout"
+
+// dagRunSucceeded returns true if the named DAG's run for the execution date has succeeded
+func (dagRun *DAGRun) dagRunSucceeded(dagName string, executionDate time.Time) bool {
+	row, found := dagRun.GetRunForDagName(dagName, executionDate)
+	return found && row.Status == string(RunSuccess)
+}
+
+// secretEnvValue returns the value of one of the DAG's SecretEnv variables, read from its Secret
+// key in the DAG's namespace. The local executor takes it from goflow's own environment instead,
+// as it does for the variables that it passes to tasks.
+func (dagRun *DAGRun) secretEnvValue(name string) (string, error) {
+	if _, local := dagRun.executor.(*executor.LocalExecutor); local {
+		value, found := os.LookupEnv(name)
+		if !found {
+			return "", fmt.Errorf("environment variable %s must be set to use it locally", name)
+		}
+		return value, nil
+	}
+	ref, ok := dagRun.Config.SecretEnv[name]
+	if !ok {
+		return "", fmt.Errorf("%s is not a SecretEnv variable of the DAG", name)
+	}
+	secret, err := dagRun.kubeClient.CoreV1().Secrets(dagRun.Config.Namespace).Get(
+		context.TODO(),
+		ref.Secret,
+		k8sapi.GetOptions{},
+	)
+	if err != nil {
+		return "", err
+	}
+	value, ok := secret.Data[ref.Key]
+	if !ok {
+		return "", fmt.Errorf("Secret %s has no key %s", ref.Secret, ref.Key)
+	}
+	return string(value), nil
+}
+
+// isSensor returns true if the task waits on a condition instead of running a pod
+func (taskRun *TaskRun) isSensor() bool {
+	return taskRun.Config.Sensor != nil
+}
+
+// renderSensor returns the config of the task's sensor with its templates rendered
+func (taskRun *TaskRun) renderSensor() (dagconfig.SensorConfig, error) {
+	config := *taskRun.Config.Sensor
+	texts, err := templating.RenderAll(config.Templates(), taskRun.templateContext())
+	if err != nil {
+		return config, err
+	}
+	config.Path, config.URL, config.Query = texts[0], texts[1], texts[2]
+	return config, nil
+}
+
+// poke checks the condition of the sensor with the given config once. The DSN of a sql sensor with
+// a DSNEnv is read before every check, so that a Secret created after the run started is used.
+func (taskRun *TaskRun) poke(config dagconfig.SensorConfig) (bool, error) {
+	if config.DSNEnv != "" {
+		dsn, err := taskRun.dagRun.secretEnvValue(config.DSNEnv)
+		if err != nil {
+			return false, err
+		}
+		config.DSN = dsn
+	}
+	taskSensor, err := sensor.New(
+		config,
+		taskRun.dagRun.ExecutionDate.Time,
+		taskRun.dagRun.dagRunSucceeded,
+	)
+	if err != nil {
+		return false, err
+	}
+	return taskSensor.Poke()
+}
+
+// sense checks the sensor's condition every poke interval until it holds or the deadline passes.
+// The sensor is up for reschedule between checks, so it does not hold a pod while waiting. A
+// sensor that times out is skipped if it soft fails, or failed otherwise.
+func (taskRun *TaskRun) sense(deadline time.Time) {
+	config, err := taskRun.renderSensor()
+	if err != nil {
+		taskRun.failTemplate(err)
+		return
+	}
+	for {
+		taskRun.setState(TaskRunning)
+		ok, err := taskRun.poke(config)
+		if err != nil {
+			taskRun.log().Warningf(
+				"Sensor %s could not check its condition: %s",
+				taskRun.PodName,
+				err.Error(),
+			)
+		}
+		if ok {
+			taskRun.setState(TaskSuccess)
+			return
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			if config.SoftFail {
+				taskRun.Reason = sensorTimeoutReason
+				taskRun.setState(TaskSkipped)
+				return
+			}
+			taskRun.fail(sensorTimeoutReason)
+			return
+		}
+		taskRun.setState(TaskUpForReschedule)
+		wait := config.PokeIntervalDuration()
+		if remaining < wait {
+			wait = remaining
+		}
+		select {
+		case <-time.After(wait):
+		case <-taskRun.dagRun.terminated:
+			taskRun.fail(terminatedReason)
+			return
+		}
+	}
+}
+
+// runSensorAttempt runs the current attempt of a sensor task until its timeout
+func (taskRun *TaskRun) runSensorAttempt() {
+	taskRun.setState(TaskQueued)
+	taskRun.sense(time.Now().Add(taskRun.Config.Sensor.TimeoutDuration()))
+}
+
+// resumeSensor continues waiting on the sensor's condition, with the timeout counted from when the
+// sensor first started checking it
+func (taskRun *TaskRun) resumeSensor() {
+	deadline := time.Now().Add(taskRun.Config.Sensor.TimeoutDuration())
+	if startTime := taskRun.StartTime; !startTime.IsZero() && taskRun.Attempt <= 1 {
+		deadline = startTime.Add(taskRun.Config.Sensor.TimeoutDuration())
+	}
+	taskRun.log().Infof("Resuming sensor %s", taskRun.PodName)
+	taskRun.sense(deadline)
+}
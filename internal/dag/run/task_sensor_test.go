@@ -0,0 +1,200 @@
+package run
+
+import (
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"goflow/internal/dag/activeruns"
+	dagconfig "goflow/internal/dag/config"
+	"goflow/internal/database"
+	"goflow/internal/k8s/pod/event/holder"
+	podutils "goflow/internal/k8s/pod/utils"
+
+	core "k8s.io/api/core/v1"
+	k8sapi "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func getTestSensorDAGRun(name string, sensor *dagconfig.SensorConfig) *DAGRun {
+	config := getTestDAGConfig(name, []string{})
+	config.Tasks = []dagconfig.TaskConfig{
+		{Name: "wait", Sensor: sensor},
+		{Name: "load", Command: []string{"echo", "load"}, DependsOn: []string{"wait"}},
+	}
+	return NewDAGRun(
+		getTestDate(),
+		config,
+		false,
+		fake.NewSimpleClientset(),
+		holder.New(),
+		activeruns.New(),
+		TABLECLIENT,
+		TASKTABLECLIENT,
+		0,
+	)
+}
+
+func startTestDAGRun(t *testing.T, dagRun *DAGRun) {
+	done := make(chan struct{})
+	go func() {
+		dagRun.Start()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("DAG run did not finish")
+	}
+}
+
+func TestFileSensorSucceeds(t *testing.T) {
+	setupDatabase()
+	defer database.PurgeDB(SQLCLIENT)
+	dir, err := ioutil.TempDir("", "sensor")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	err = ioutil.WriteFile(filepath.Join(dir, "2019-01-01.csv"), []byte{}, 0600)
+	if err != nil {
+		panic(err)
+	}
+
+	dagRun := getTestSensorDAGRun(
+		"test-file-sensor",
+		&dagconfig.SensorConfig{
+			Type: dagconfig.FileSensor,
+			Path: filepath.Join(dir, "{{ .ExecutionDate.Format \"2006-01-02\" }}.csv"),
+		},
+	)
+	defer podutils.CleanUpEnvironment(dagRun.kubeClient)
+	done := make(chan struct{})
+	go func() {
+		dagRun.Start()
+		close(done)
+	}()
+
+	wait := dagRun.Task("wait")
+	load := dagRun.Task("load")
+	waitForTaskPod(load)
+	completePod(load, core.PodSucceeded)
+	<-done
+	if dagRun.Status != RunSuccess {
+		t.Errorf("Expected dag run status %s, found %s", RunSuccess, dagRun.Status)
+	}
+	if wait.GetState() != TaskSuccess {
+		t.Errorf("Expected sensor in state %s, found %s", TaskSuccess, wait.GetState())
+	}
+	if taskPod(wait) != nil {
+		t.Error("Sensor should not create a pod")
+	}
+}
+
+func TestSensorTimeout(t *testing.T) {
+	tables := []struct {
+		softFail           bool
+		expectedSensor     TaskState
+		expectedDownstream TaskState
+		expectedStatus     RunStatus
+	}{
+		{true, TaskSkipped, TaskSkipped, RunSuccess},
+		{false, TaskFailed, TaskUpstreamFailed, RunFailed},
+	}
+	for _, table := range tables {
+		t.Logf("Test case: soft fail %t", table.softFail)
+		setupDatabase()
+		dagRun := getTestSensorDAGRun(
+			"test-sensor-timeout",
+			&dagconfig.SensorConfig{
+				Type:         dagconfig.FileSensor,
+				Path:         filepath.Join(os.TempDir(), "goflow-missing-sensor-file"),
+				PokeInterval: 1,
+				Timeout:      1,
+				SoftFail:     table.softFail,
+			},
+		)
+		startTestDAGRun(t, dagRun)
+
+		wait := dagRun.Task("wait")
+		if wait.GetState() != table.expectedSensor {
+			t.Errorf("Expected sensor in state %s, found %s", table.expectedSensor, wait.GetState())
+		}
+		if wait.Reason != sensorTimeoutReason {
+			t.Errorf("Expected sensor reason %s, found %s", sensorTimeoutReason, wait.Reason)
+		}
+		load := dagRun.Task("load")
+		if load.GetState() != table.expectedDownstream {
+			t.Errorf(
+				"Expected downstream task in state %s, found %s",
+				table.expectedDownstream,
+				load.GetState(),
+			)
+		}
+		if dagRun.Status != table.expectedStatus {
+			t.Errorf("Expected dag run status %s, found %s", table.expectedStatus, dagRun.Status)
+		}
+		database.PurgeDB(SQLCLIENT)
+	}
+}
+
+func TestSQLSensorReadsDSNFromSecret(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sensor")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	dsn := filepath.Join(dir, "sensor.db")
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		panic(err)
+	}
+	_, err = db.Exec("CREATE TABLE ready (id INTEGER); INSERT INTO ready VALUES (1)")
+	db.Close()
+	if err != nil {
+		panic(err)
+	}
+
+	dagRun := getTestSensorDAGRun(
+		"test-sql-sensor-secret",
+		&dagconfig.SensorConfig{
+			Type:   dagconfig.SQLSensor,
+			DSNEnv: "DB_DSN",
+			Query:  "SELECT id FROM ready",
+		},
+	)
+	dagRun.Config.SecretEnv = map[string]dagconfig.SecretKeyRef{
+		"DB_DSN": {Secret: "db", Key: "dsn"},
+	}
+	wait := dagRun.Task("wait")
+	config, err := wait.renderSensor()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := wait.poke(config); ok || err == nil {
+		t.Errorf("Expected an error before the Secret exists, found %t and %v", ok, err)
+	}
+
+	_, err = dagRun.kubeClient.CoreV1().Secrets(dagRun.Config.Namespace).Create(
+		context.TODO(),
+		&core.Secret{
+			ObjectMeta: k8sapi.ObjectMeta{Name: "db"},
+			Data:       map[string][]byte{"dsn": []byte(dsn)},
+		},
+		k8sapi.CreateOptions{},
+	)
+	if err != nil {
+		panic(err)
+	}
+	if ok, err := wait.poke(config); !ok || err != nil {
+		t.Errorf("Expected the sensor to hold with the DSN of the Secret, found %t and %v", ok, err)
+	}
+	if strings.Contains(dagRun.String(), dsn) {
+		t.Errorf("Expected the dag run not to contain the DSN %s", dsn)
+	}
+}
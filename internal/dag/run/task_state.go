@@ -0,0 +1,75 @@
+package run
+
+import (
+	core "k8s.io/api/core/v1"
+)
+
+// TaskState is the state of a single task within a DAG run
+type TaskState string
+
+const (
+	// TaskScheduled is the state of a task waiting on its upstream tasks
+	TaskScheduled TaskState = "scheduled"
+	// TaskQueued is the state of a task whose pod has been requested but is not yet running
+	TaskQueued TaskState = "queued"
+	// TaskRunning is the state of a task whose pod is running
+	TaskRunning TaskState = "running"
+	// TaskSuccess is the state of a task whose pod has succeeded
+	TaskSuccess TaskState = "success"
+	// TaskFailed is the state of a task whose pod has failed
+	TaskFailed TaskState = "failed"
+	// TaskUpstreamFailed is the state of a task that will not run because an upstream task failed
+	TaskUpstreamFailed TaskState = "upstream_failed"
+	// TaskSkipped is the state of a task that was intentionally not run
+	TaskSkipped TaskState = "skipped"
+	// TaskUpForRetry is the state of a failed task that will be attempted again
+	TaskUpForRetry TaskState = "up_for_retry"
+	// TaskUpForReschedule is the state of a sensor waiting to check its condition again
+	TaskUpForReschedule TaskState = "up_for_reschedule"
+)
+
+// taskTransitions maps each state to the states that a task may move to from it
+var taskTransitions = map[TaskState][]TaskState{
+	"":            {TaskScheduled},
+	TaskScheduled: {TaskQueued, TaskUpstreamFailed, TaskSkipped},
+	TaskQueued:    {TaskRunning, TaskSuccess, TaskFailed, TaskUpForRetry, TaskSkipped},
+	TaskRunning: {
+		TaskSuccess, TaskFailed, TaskUpForRetry, TaskUpForReschedule, TaskSkipped,
+	},
+	TaskUpForRetry:      {TaskQueued, TaskFailed},
+	TaskUpForReschedule: {TaskRunning, TaskFailed, TaskUpForRetry, TaskSkipped},
+	TaskSuccess:         {},
+	TaskFailed:          {},
+	TaskUpstreamFailed:  {},
+	TaskSkipped:         {},
+}
+
+// CanTransitionTo returns true if a task may move from this state to the next state
+func (state TaskState) CanTransitionTo(next TaskState) bool {
+	for _, allowed := range taskTransitions[state] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Finished returns true if the task will not change state again
+func (state TaskState) Finished() bool {
+	return len(taskTransitions[state]) == 0
+}
+
+// taskStateFromPodPhase returns the task state corresponding to a pod phase
+func taskStateFromPodPhase(phase core.PodPhase) (TaskState, bool) {
+	switch phase {
+	case core.PodPending:
+		return TaskQueued, true
+	case core.PodRunning:
+		return TaskRunning, true
+	case core.PodSucceeded:
+		return TaskSuccess, true
+	case core.PodFailed:
+		return TaskFailed, true
+	}
+	return "", false
+}
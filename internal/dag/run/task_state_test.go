@@ -0,0 +1,58 @@
+package run
+
+import (
+	"testing"
+
+	core "k8s.io/api/core/v1"
+)
+
+func TestTaskStateTransitions(t *testing.T) {
+	cases := []struct {
+		from     TaskState
+		to       TaskState
+		expected bool
+	}{
+		{"", TaskScheduled, true},
+		{"", TaskRunning, false},
+		{TaskScheduled, TaskQueued, true},
+		{TaskScheduled, TaskUpstreamFailed, true},
+		{TaskQueued, TaskSuccess, true},
+		{TaskRunning, TaskFailed, true},
+		{TaskRunning, TaskUpForRetry, true},
+		{TaskUpForRetry, TaskQueued, true},
+		{TaskRunning, TaskUpForReschedule, true},
+		{TaskUpForReschedule, TaskRunning, true},
+		{TaskUpForReschedule, TaskQueued, false},
+		{TaskSuccess, TaskRunning, false},
+		{TaskFailed, TaskQueued, false},
+		{TaskUpstreamFailed, TaskQueued, false},
+	}
+	for _, testCase := range cases {
+		if testCase.from.CanTransitionTo(testCase.to) != testCase.expected {
+			t.Errorf(
+				"Expected transition from \"%s\" to \"%s\" to be allowed: %t",
+				testCase.from,
+				testCase.to,
+				testCase.expected,
+			)
+		}
+	}
+}
+
+func TestTaskStateFromPodPhase(t *testing.T) {
+	cases := map[core.PodPhase]TaskState{
+		core.PodPending:   TaskQueued,
+		core.PodRunning:   TaskRunning,
+		core.PodSucceeded: TaskSuccess,
+		core.PodFailed:    TaskFailed,
+	}
+	for phase, expectedState := range cases {
+		state, ok := taskStateFromPodPhase(phase)
+		if !ok || state != expectedState {
+			t.Errorf("Expected phase %s to map to state %s, found %s", phase, expectedState, state)
+		}
+	}
+	if _, ok := taskStateFromPodPhase(core.PodUnknown); ok {
+		t.Errorf("Phase %s should not map to a task state", core.PodUnknown)
+	}
+}
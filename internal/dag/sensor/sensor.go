@@ -0,0 +1,112 @@
+package sensor
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	dagconfig "goflow/internal/dag/config"
+
+	_ "github.com/mattn/go-sqlite3" // Default driver of sql sensors
+)
+
+// defaultSQLDriver is the database/sql driver used by sql sensors that do not set a Driver
+const defaultSQLDriver = "sqlite3"
+
+// httpTimeout is the longest that an http sensor waits for a response to a single request
+const httpTimeout = 30 * time.Second
+
+// Sensor checks whether an external condition holds
+type Sensor interface {
+	// Poke returns true if the condition holds, or an error if it could not be checked
+	Poke() (bool, error)
+}
+
+// DAGRunSucceeded returns true if the named DAG's run for the execution date has succeeded
+type DAGRunSucceeded func(dagName string, executionDate time.Time) bool
+
+// New returns the sensor for the given configuration, whose templates must already be rendered.
+// Dag sensors look for the run of their DAG for the given execution date.
+func New(
+	config dagconfig.SensorConfig,
+	executionDate time.Time,
+	dagRunSucceeded DAGRunSucceeded,
+) (Sensor, error) {
+	switch config.Type {
+	case dagconfig.FileSensor:
+		return fileSensor{config.Path}, nil
+	case dagconfig.HTTPSensor:
+		return httpSensor{config.URL, &http.Client{Timeout: httpTimeout}}, nil
+	case dagconfig.SQLSensor:
+		driver := config.Driver
+		if driver == "" {
+			driver = defaultSQLDriver
+		}
+		return sqlSensor{driver, config.DSN, config.Query}, nil
+	case dagconfig.DAGSensor:
+		return dagSensor{config.DAG, executionDate, dagRunSucceeded}, nil
+	}
+	return nil, fmt.Errorf("unknown sensor type \"%s\"", config.Type)
+}
+
+// fileSensor waits until a file matching its pattern exists
+type fileSensor struct {
+	pattern string
+}
+
+func (sensor fileSensor) Poke() (bool, error) {
+	matches, err := filepath.Glob(sensor.pattern)
+	if err != nil {
+		return false, err
+	}
+	return len(matches) != 0, nil
+}
+
+// httpSensor waits until a GET request to its url returns 200 OK
+type httpSensor struct {
+	url    string
+	client *http.Client
+}
+
+func (sensor httpSensor) Poke() (bool, error) {
+	resp, err := sensor.client.Get(sensor.url)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode == http.StatusOK, nil
+}
+
+// sqlSensor waits until its query returns at least one row
+type sqlSensor struct {
+	driver string
+	dsn    string
+	query  string
+}
+
+func (sensor sqlSensor) Poke() (bool, error) {
+	db, err := sql.Open(sensor.driver, sensor.dsn)
+	if err != nil {
+		return false, err
+	}
+	defer db.Close()
+	rows, err := db.Query(sensor.query)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	return rows.Next(), rows.Err()
+}
+
+// dagSensor waits until the run of its DAG for the execution date has succeeded
+type dagSensor struct {
+	dagName         string
+	executionDate   time.Time
+	dagRunSucceeded DAGRunSucceeded
+}
+
+func (sensor dagSensor) Poke() (bool, error) {
+	return sensor.dagRunSucceeded(sensor.dagName, sensor.executionDate), nil
+}
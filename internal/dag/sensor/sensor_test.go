@@ -0,0 +1,109 @@
+package sensor
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	dagconfig "goflow/internal/dag/config"
+)
+
+func poke(t *testing.T, config dagconfig.SensorConfig, succeeded DAGRunSucceeded) bool {
+	sensor, err := New(config, time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), succeeded)
+	if err != nil {
+		panic(err)
+	}
+	ok, err := sensor.Poke()
+	if err != nil {
+		t.Logf("Poke returned error: %s", err.Error())
+	}
+	return ok
+}
+
+func TestFileSensor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goflow-sensor")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	config := dagconfig.SensorConfig{Type: dagconfig.FileSensor, Path: filepath.Join(dir, "*.csv")}
+	if poke(t, config, nil) {
+		t.Error("File sensor should not pass before the file exists")
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "data.csv"), []byte("1"), 0644)
+	if err != nil {
+		panic(err)
+	}
+	if !poke(t, config, nil) {
+		t.Error("File sensor should pass once the file exists")
+	}
+}
+
+func TestHTTPSensor(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	config := dagconfig.SensorConfig{Type: dagconfig.HTTPSensor, URL: server.URL}
+	if poke(t, config, nil) {
+		t.Errorf("HTTP sensor should not pass on status %d", status)
+	}
+	status = http.StatusOK
+	if !poke(t, config, nil) {
+		t.Error("HTTP sensor should pass on status 200")
+	}
+}
+
+func TestSQLSensor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goflow-sensor")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	dsn := filepath.Join(dir, "sensor.sqlite3")
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+	_, err = db.Exec("CREATE TABLE loads (day TEXT)")
+	if err != nil {
+		panic(err)
+	}
+	config := dagconfig.SensorConfig{
+		Type:  dagconfig.SQLSensor,
+		DSN:   dsn,
+		Query: "SELECT * FROM loads WHERE day = '2021-01-01'",
+	}
+	if poke(t, config, nil) {
+		t.Error("SQL sensor should not pass before the query returns rows")
+	}
+	_, err = db.Exec("INSERT INTO loads VALUES ('2021-01-01')")
+	if err != nil {
+		panic(err)
+	}
+	if !poke(t, config, nil) {
+		t.Error("SQL sensor should pass once the query returns rows")
+	}
+}
+
+func TestDAGSensor(t *testing.T) {
+	config := dagconfig.SensorConfig{Type: dagconfig.DAGSensor, DAG: "upstream"}
+	succeeded := func(dagName string, executionDate time.Time) bool {
+		expected := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+		return dagName == "upstream" && executionDate.Equal(expected)
+	}
+	if !poke(t, config, succeeded) {
+		t.Error("DAG sensor should pass once the upstream run for the same date has succeeded")
+	}
+	config.DAG = "other"
+	if poke(t, config, succeeded) {
+		t.Error("DAG sensor should not pass when the upstream run has not succeeded")
+	}
+}
@@ -6,7 +6,8 @@ import (
 	"goflow/internal/database"
 )
 
-const nameName = "name"
+// NameName is the column name for the name of the DAG
+const NameName = "name"
 const namespaceName = "namespace"
 
 // TableName is the name of the dag table
@@ -114,6 +115,19 @@ func (client *TableClient) UpdateDAGToggle(dagID int, newState bool) {
 	)
 }
 
+// UpdateDAGActive updates whether the DAG's file is still present in the DAG folder in the DB
+func (client *TableClient) UpdateDAGActive(dagID int, isActive bool) {
+	client.sqlClient.Update(
+		TableName,
+		database.ColumnWithValueSlice{
+			{Column: database.Column{Name: isActiveName, DType: database.Bool{Val: isActive}}},
+		},
+		database.ColumnWithValueSlice{
+			{Column: database.Column{Name: IDName, DType: database.Int{Val: dagID}}},
+		},
+	)
+}
+
 // UpsertDAG inserts a new dag if it does not exist or updates
 // an existing dag record
 func (client *TableClient) UpsertDAG(dagRow Row) Row {
@@ -131,7 +145,7 @@ func (client *TableClient) UpsertDAG(dagRow Row) Row {
 			[]database.ColumnWithValue{
 				{
 					Column: database.Column{
-						Name:  nameName,
+						Name:  NameName,
 						DType: database.String{Val: dagRow.Name},
 					},
 				},
@@ -145,3 +145,24 @@ func TestUpsertDagTable(t *testing.T) {
 		)
 	}
 }
+
+func TestUpdateDAGActive(t *testing.T) {
+	defer database.PurgeDB(sqlClient)
+
+	createTestTable()
+
+	row := tableClient.UpsertDAG(Row{
+		Name:      "test",
+		Namespace: "default",
+		FilePath:  "path",
+		IsActive:  true,
+	})
+	tableClient.UpdateDAGActive(row.ID, false)
+	if tableClient.GetDagRecord(row.Name, row.Namespace).IsActive {
+		t.Error("Expected DAG to be marked inactive")
+	}
+	tableClient.UpsertDAG(row)
+	if !tableClient.GetDagRecord(row.Name, row.Namespace).IsActive {
+		t.Error("Expected DAG to be marked active once it is stored again")
+	}
+}
@@ -19,18 +19,20 @@ type Row struct {
 	FileFormat      string
 	CreatedDate     time.Time
 	LastUpdatedDate time.Time
+	IsActive        bool // False once the DAG's file has been removed from the DAG folder
 }
 
 // IDName is the column name for the primary id column
 const IDName = "id"
 
 const isOnName = "is_on"
+const isActiveName = "is_active"
 
-// NewRow returns a new row with the appropriate update and create time stamps
+// NewRow returns a new row for an active DAG with the appropriate update and create time stamps
 func NewRow(id int, isOn bool, name, namespace, version, filePath, fileFormat string) Row {
 	creationTime := dateutils.GetDateTimeNowMilliSecond()
 	return Row{
-		id, isOn, name, namespace, version, filePath, fileFormat, creationTime, creationTime,
+		id, isOn, name, namespace, version, filePath, fileFormat, creationTime, creationTime, true,
 	}
 }
 
@@ -45,7 +47,8 @@ func (row Row) String() string {
 		  filePath: %s,
 		  fileFormat: %s, 
 		  createDate: %s, 
-		  lastUpdatedDate: %s
+		  lastUpdatedDate: %s,
+		  isActive: %t
 		}`,
 		row.ID,
 		row.IsOn,
@@ -56,6 +59,7 @@ func (row Row) String() string {
 		row.FileFormat,
 		row.CreatedDate.String(),
 		row.LastUpdatedDate.String(),
+		row.IsActive,
 	)
 }
 
@@ -74,7 +78,7 @@ func (row Row) columnar() database.ColumnWithValueSlice {
 	return []database.ColumnWithValue{
 		{Column: database.Column{Name: IDName, DType: database.Int{Val: row.ID}}},
 		{Column: database.Column{Name: isOnName, DType: database.Bool{Val: row.IsOn}}},
-		{Column: database.Column{Name: nameName, DType: database.String{Val: row.Name}}},
+		{Column: database.Column{Name: NameName, DType: database.String{Val: row.Name}}},
 		{
 			Column: database.Column{
 				Name:  namespaceName,
@@ -101,6 +105,7 @@ func (row Row) columnar() database.ColumnWithValueSlice {
 				DType: database.TimeStamp{Val: row.LastUpdatedDate},
 			},
 		},
+		{Column: database.Column{Name: isActiveName, DType: database.Bool{Val: row.IsActive}}},
 	}
 }
 
@@ -116,6 +121,7 @@ func (result *dagRowResult) ScanAppend(rows *sql.Rows) error {
 		&row.FileFormat,
 		&row.CreatedDate,
 		&row.LastUpdatedDate,
+		&row.IsActive,
 	)
 	result.returnedRows = append(result.returnedRows, row)
 	return err
@@ -54,51 +54,128 @@ func (client *TableClient) GetLastNRunsForDagID(dagID int, n int) []Row {
 	return result.returnedRows
 }
 
-func (client *TableClient) selectSpecificDagRun(dagID int, executionDate time.Time) dagRowResult {
+// scheduledRunType is the run type of runs created by a DAG's schedule. Runs recorded before run
+// types were stored have an empty run type, and were all scheduled.
+const scheduledRunType = "scheduled"
+
+// GetLastScheduledRunForDagID returns the scheduled run of the given dag id with the latest
+// execution date, and false if the DAG has no scheduled runs. Manual, backfill and triggered runs
+// are left out, so that their execution dates do not move the DAG's schedule.
+func (client *TableClient) GetLastScheduledRunForDagID(dagID int) (Row, bool) {
+	result := newRowResult(1)
+	client.sqlClient.QueryIntoResults(
+		&result,
+		fmt.Sprintf(
+			"SELECT * FROM dagrun WHERE %s = %d AND %s IN ('%s', '') ORDER BY %s DESC",
+			dagIDName,
+			dagID,
+			runTypeName,
+			scheduledRunType,
+			executionDateName,
+		),
+	)
+	if len(result.returnedRows) == 0 {
+		return Row{}, false
+	}
+	return result.returnedRows[0], true
+}
+
+// GetRunsForDagIDWithStatus retrieves the rows with the given status for a given dag id, ordered
+// by execution date
+func (client *TableClient) GetRunsForDagIDWithStatus(dagID int, status string) []Row {
+	result := newRowResult(0)
+	client.sqlClient.QueryIntoResults(
+		&result,
+		fmt.Sprintf(
+			"SELECT * FROM dagrun WHERE %s = %d AND %s = '%s' ORDER BY %s ASC",
+			dagIDName,
+			dagID,
+			statusName,
+			status,
+			executionDateName,
+		),
+	)
+	return result.returnedRows
+}
+
+// GetRunForDagName returns the most recently updated run of the DAG with the given name for the
+// execution date, and false if the DAG has no run for that date
+func (client *TableClient) GetRunForDagName(dagName string, executionDate time.Time) (Row, bool) {
+	// The name is quoted and escaped as a string value, since it is read from DAG files
+	dagNameValue := database.ColumnWithValue{
+		Column: database.Column{Name: dagtable.NameName, DType: database.String{Val: dagName}},
+	}
 	result := newRowResult(1)
 	client.sqlClient.QueryIntoResults(
 		&result,
 		fmt.Sprintf(
-			"SELECT * FROM dagrun WHERE dag_id = %d AND execution_date = %s ORDER BY last_updated_date desc",
+			"SELECT %s.* FROM %s JOIN %s ON %s.%s = %s.%s WHERE %s.%s = %s AND %s.%s = '%s' "+
+				"ORDER BY %s.%s DESC",
+			tableName,
+			tableName,
+			dagtable.TableName,
+			tableName,
+			dagIDName,
+			dagtable.TableName,
+			dagtable.IDName,
+			dagtable.TableName,
+			dagtable.NameName,
+			dagNameValue.ValRep(),
+			tableName,
+			executionDateName,
+			dateutils.SQLiteFormat(executionDate),
+			tableName,
+			lastUpdatedDateName,
+		),
+	)
+	if len(result.returnedRows) == 0 {
+		return Row{}, false
+	}
+	return result.returnedRows[0], true
+}
+
+// selectRunsOfDate returns the runs of the dag id for the execution date, latest run first
+func (client *TableClient) selectRunsOfDate(dagID int, executionDate time.Time) dagRowResult {
+	result := newRowResult(0)
+	client.sqlClient.QueryIntoResults(
+		&result,
+		fmt.Sprintf(
+			"SELECT * FROM dagrun WHERE %s = %d AND %s = '%s' ORDER BY %s DESC",
+			dagIDName,
 			dagID,
-			"'"+executionDate.Format(dateutils.SQLiteDateForm)+"'",
+			executionDateName,
+			dateutils.SQLiteFormat(executionDate),
+			runNumberName,
 		),
 	)
 	return result
 }
 
-func (client *TableClient) isDagRunPresent(dagID int, executionDate time.Time) bool {
-	rows := client.selectSpecificDagRun(dagID, executionDate)
-	return len(rows.returnedRows) == 1
+// NextRunNumber returns the run number of a new run of the dag id for the execution date, which
+// follows the number of the date's latest run
+func (client *TableClient) NextRunNumber(dagID int, executionDate time.Time) int {
+	rows := client.selectRunsOfDate(dagID, executionDate).returnedRows
+	if len(rows) == 0 {
+		return 1
+	}
+	return rows[0].RunNumber + 1
 }
 
-// UpsertDagRun inserts or updates the dag run
+func (client *TableClient) isDagRunPresent(row Row) bool {
+	for _, present := range client.selectRunsOfDate(row.DagID, row.ExecutionDate).returnedRows {
+		if present.RunNumber == row.RunNumber {
+			return true
+		}
+	}
+	return false
+}
+
+// UpsertDagRun inserts or updates the dag run with the row's run number, updating its status and
+// result on conflict. Every run of an execution date has a row of its own.
 func (client *TableClient) UpsertDagRun(dagRunRow Row) {
-	if !client.isDagRunPresent(dagRunRow.DagID, dagRunRow.ExecutionDate) {
+	if !client.isDagRunPresent(dagRunRow) {
 		client.sqlClient.Insert(tableName, dagRunRow.columnar())
 		return
 	}
-	client.sqlClient.Update(tableName,
-		[]database.ColumnWithValue{
-			{
-				Column: database.Column{
-					Name:  statusName,
-					DType: database.String{Val: dagRunRow.Status},
-				},
-			},
-		},
-		[]database.ColumnWithValue{
-			{
-				Column: database.Column{
-					Name:  dagIDName,
-					DType: database.Int{Val: dagRunRow.DagID},
-				},
-			},
-			{
-				Column: database.Column{
-					Name:  executionDateName,
-					DType: database.TimeStamp{Val: dagRunRow.ExecutionDate},
-				},
-			},
-		})
+	client.sqlClient.Update(tableName, dagRunRow.resultColumns(), dagRunRow.keyColumns())
 }
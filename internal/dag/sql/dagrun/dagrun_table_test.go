@@ -108,6 +108,9 @@ func TestUpsertDagRun(t *testing.T) {
 
 	startTime, _ := time.Parse("2006-01-02", "2019-01-01")
 	expectedRow := NewRow(testDagRow.ID, "RUNNING", startTime)
+	expectedRow.RunType = "manual"
+	expectedRow.Conf = `{"TABLE":"users"}`
+	expectedRow.TriggeredBy = "upstream"
 
 	tableClient.UpsertDagRun(expectedRow)
 
@@ -126,6 +129,9 @@ func TestUpsertDagRun(t *testing.T) {
 	}
 
 	expectedRow.Status = "FAILED"
+	expectedRow.EndDate = startTime.Add(time.Hour)
+	expectedRow.ExitCode = 1
+	expectedRow.Reason = "Error"
 	tableClient.UpsertDagRun(expectedRow)
 
 	rows = getTestRows()
@@ -139,4 +145,121 @@ func TestUpsertDagRun(t *testing.T) {
 			rows[0],
 		)
 	}
+	// A backfill rerun of the execution date adds a row, keeping the earlier manual run
+	earlierRow := expectedRow
+	expectedRow.RunNumber = tableClient.NextRunNumber(testDagRow.ID, startTime)
+	if expectedRow.RunNumber != earlierRow.RunNumber+1 {
+		t.Errorf("Expected run number %d, got %d", earlierRow.RunNumber+1, expectedRow.RunNumber)
+	}
+	expectedRow.Status = "RUNNING"
+	expectedRow.StartDate = startTime.Add(2 * time.Hour)
+	expectedRow.EndDate = time.Time{}
+	expectedRow.RunType = "backfill"
+	expectedRow.Conf = ""
+	expectedRow.TriggeredBy = ""
+	tableClient.UpsertDagRun(expectedRow)
+	expectedRow.Status = "SUCCESS"
+	tableClient.UpsertDagRun(expectedRow)
+
+	rows = getTestRows()
+	if len(rows) != 2 || rows[0] != earlierRow || rows[1] != expectedRow {
+		t.Errorf("Expected %s after the earlier run %s, got %v", expectedRow, earlierRow, rows)
+	}
+}
+
+func TestNextRunNumber(t *testing.T) {
+	defer database.PurgeDB(sqlClient)
+	setUpDagTable()
+	setUpTestTable()
+
+	executionDate, _ := time.Parse("2006-01-02", "2019-01-01")
+	if runNumber := tableClient.NextRunNumber(testDagRow.ID, executionDate); runNumber != 1 {
+		t.Errorf("Expected the first run of the date to be run 1, got %d", runNumber)
+	}
+	row := NewRow(testDagRow.ID, "SUCCESS", executionDate)
+	row.RunNumber = 1
+	tableClient.UpsertDagRun(row)
+	row.RunNumber = 2
+	tableClient.UpsertDagRun(row)
+	if runNumber := tableClient.NextRunNumber(testDagRow.ID, executionDate); runNumber != 3 {
+		t.Errorf("Expected the next run of the date to be run 3, got %d", runNumber)
+	}
+	otherDate := executionDate.Add(24 * time.Hour)
+	if runNumber := tableClient.NextRunNumber(testDagRow.ID, otherDate); runNumber != 1 {
+		t.Errorf("Expected the first run of another date to be run 1, got %d", runNumber)
+	}
+}
+
+func TestGetRunsForDagIDWithStatus(t *testing.T) {
+	defer database.PurgeDB(sqlClient)
+	setUpDagTable()
+	setUpTestTable()
+
+	insertedRows := insertDaysOfRuns(3)
+	insertedRows[1].Status = "success"
+	tableClient.UpsertDagRun(insertedRows[1])
+
+	rows := tableClient.GetRunsForDagIDWithStatus(testDagRow.ID, "Running")
+	expectedRows := []Row{insertedRows[0], insertedRows[2]}
+	if len(rows) != len(expectedRows) {
+		t.Fatalf("Expected %d rows, found %d", len(expectedRows), len(rows))
+	}
+	for i, row := range rows {
+		if row != expectedRows[i] {
+			t.Errorf("Expected row %s, found row %s", expectedRows[i], row)
+		}
+	}
+}
+
+func TestGetLastScheduledRunForDagID(t *testing.T) {
+	defer database.PurgeDB(sqlClient)
+	setUpDagTable()
+	setUpTestTable()
+
+	_, found := tableClient.GetLastScheduledRunForDagID(testDagRow.ID)
+	if found {
+		t.Error("No scheduled run should be found for a DAG without runs")
+	}
+
+	firstDate := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	legacyRow := NewRow(testDagRow.ID, "success", firstDate)
+	tableClient.UpsertDagRun(legacyRow)
+	scheduledRow := NewRow(testDagRow.ID, "success", firstDate.AddDate(0, 0, 1))
+	scheduledRow.RunType = "scheduled"
+	tableClient.UpsertDagRun(scheduledRow)
+	manualRow := NewRow(testDagRow.ID, "success", firstDate.AddDate(1, 0, 0))
+	manualRow.RunType = "manual"
+	tableClient.UpsertDagRun(manualRow)
+
+	row, found := tableClient.GetLastScheduledRunForDagID(testDagRow.ID)
+	if !found || row != scheduledRow {
+		t.Errorf("Expected %s, got %s", scheduledRow, row)
+	}
+}
+
+func TestGetRunForDagName(t *testing.T) {
+	defer database.PurgeDB(sqlClient)
+	setUpDagTable()
+	setUpTestTable()
+
+	executionDate, _ := time.Parse("2006-01-02", "2019-01-01")
+	expectedRow := NewRow(testDagRow.ID, "success", executionDate)
+	tableClient.UpsertDagRun(expectedRow)
+
+	row, found := tableClient.GetRunForDagName(testDagRow.Name, executionDate)
+	if !found || row != expectedRow {
+		t.Errorf("Expected %s, got %s", expectedRow, row)
+	}
+	_, found = tableClient.GetRunForDagName(testDagRow.Name, executionDate.Add(time.Hour))
+	if found {
+		t.Error("No run should be found for a different execution date")
+	}
+	_, found = tableClient.GetRunForDagName("missing-dag", executionDate)
+	if found {
+		t.Error("No run should be found for a missing DAG")
+	}
+	_, found = tableClient.GetRunForDagName("missing' OR '1'='1", executionDate)
+	if found {
+		t.Error("Quotes in the DAG name should be escaped")
+	}
 }
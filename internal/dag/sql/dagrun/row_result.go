@@ -11,6 +11,14 @@ import (
 const statusName = "status"
 const dagIDName = "dag_id"
 const executionDateName = "execution_date"
+const endDateName = "end_date"
+const lastUpdatedDateName = "last_updated_date"
+const exitCodeName = "exit_code"
+const reasonName = "reason"
+const runTypeName = "run_type"
+const confName = "conf"
+const triggeredByName = "triggered_by"
+const runNumberName = "run_number"
 
 // Row is a struct containing data about a particular dag
 type Row struct {
@@ -20,6 +28,12 @@ type Row struct {
 	StartDate       time.Time
 	EndDate         time.Time
 	LastUpdatedDate time.Time
+	ExitCode        int
+	Reason          string
+	RunType         string
+	Conf            string
+	TriggeredBy     string
+	RunNumber       int // Counts the runs of the execution date, so reruns keep earlier runs
 }
 
 func (row Row) String() string {
@@ -83,17 +97,46 @@ func (row Row) columnar() database.ColumnWithValueSlice {
 			},
 		},
 		{
-			Column: database.Column{Name: "end_date", DType: database.TimeStamp{Val: row.EndDate}},
+			Column: database.Column{Name: endDateName, DType: database.TimeStamp{Val: row.EndDate}},
 		},
 		{
 			Column: database.Column{
-				Name:  "last_updated_date",
+				Name:  lastUpdatedDateName,
 				DType: database.TimeStamp{Val: row.LastUpdatedDate},
 			},
 		},
+		{Column: database.Column{Name: exitCodeName, DType: database.Int{Val: row.ExitCode}}},
+		{Column: database.Column{Name: reasonName, DType: database.String{Val: row.Reason}}},
+		{Column: database.Column{Name: runTypeName, DType: database.String{Val: row.RunType}}},
+		{Column: database.Column{Name: confName, DType: database.String{Val: row.Conf}}},
+		{
+			Column: database.Column{
+				Name:  triggeredByName,
+				DType: database.String{Val: row.TriggeredBy},
+			},
+		},
+		{Column: database.Column{Name: runNumberName, DType: database.Int{Val: row.RunNumber}}},
+	}
+}
+
+// resultColumns returns the columns that change once a dag run has been created
+func (row Row) resultColumns() database.ColumnWithValueSlice {
+	columns := row.columnar()
+	return []database.ColumnWithValue{
+		columns[1],
+		columns[4],
+		columns[5],
+		columns[6],
+		columns[7],
 	}
 }
 
+// keyColumns returns the columns that identify the dag run's row
+func (row Row) keyColumns() database.ColumnWithValueSlice {
+	columns := row.columnar()
+	return []database.ColumnWithValue{columns[0], columns[2], columns[11]}
+}
+
 func (result *dagRowResult) ScanAppend(rows *sql.Rows) error {
 	row := Row{}
 	err := rows.Scan(
@@ -103,6 +146,12 @@ func (result *dagRowResult) ScanAppend(rows *sql.Rows) error {
 		&row.StartDate,
 		&row.EndDate,
 		&row.LastUpdatedDate,
+		&row.ExitCode,
+		&row.Reason,
+		&row.RunType,
+		&row.Conf,
+		&row.TriggeredBy,
+		&row.RunNumber,
 	)
 	result.returnedRows = append(result.returnedRows, row)
 	return err
@@ -0,0 +1,47 @@
+package importerror
+
+import (
+	"fmt"
+	"goflow/internal/database"
+)
+
+const tableName = "import_errors"
+
+// TableClient is a struct that interacts with the import errors table
+type TableClient struct {
+	sqlClient *database.SQLClient
+	tableDef  database.Table
+}
+
+// NewTableClient returns a new table client
+func NewTableClient(sqlClient *database.SQLClient) *TableClient {
+	return &TableClient{sqlClient, database.Table{Name: tableName,
+		Cols: Row{}.columnar().Columns(),
+	}}
+}
+
+// CreateTable creates the table for storing the errors that kept DAG files from being loaded
+func (client *TableClient) CreateTable() {
+	client.sqlClient.CreateTable(client.tableDef)
+}
+
+// GetImportErrors retrieves all of the stored import errors, ordered by file path
+func (client *TableClient) GetImportErrors() RowList {
+	result := newRowResult(0)
+	client.sqlClient.QueryIntoResults(
+		&result,
+		fmt.Sprintf("SELECT * FROM %s ORDER BY %s ASC", tableName, filePathName),
+	)
+	return result.returnedRows
+}
+
+// ReplaceImportErrors replaces all of the stored import errors with the given rows
+func (client *TableClient) ReplaceImportErrors(rows []Row) {
+	err := client.sqlClient.Exec(fmt.Sprintf("DELETE FROM %s", tableName))
+	if err != nil {
+		panic(err)
+	}
+	for _, row := range rows {
+		client.sqlClient.Insert(tableName, row.columnar())
+	}
+}
@@ -0,0 +1,42 @@
+package importerror
+
+import (
+	"goflow/internal/database"
+	"goflow/internal/testutils"
+	"testing"
+)
+
+var sqlClient *database.SQLClient
+var tableClient *TableClient
+
+func TestMain(m *testing.M) {
+	testutils.RemoveSQLiteDB()
+	sqlClient = database.NewSQLiteClient(testutils.GetSQLiteLocation())
+	tableClient = NewTableClient(sqlClient)
+	m.Run()
+}
+
+func TestReplaceImportErrors(t *testing.T) {
+	defer database.PurgeDB(sqlClient)
+	tableClient.CreateTable()
+	tableClient.ReplaceImportErrors([]Row{
+		NewRow("b_dag.json", "invalid character 'x' looking for beginning of value"),
+		NewRow("a_dag.json", "DAG a has an invalid schedule"),
+	})
+	rows := tableClient.GetImportErrors()
+	if len(rows) != 2 {
+		t.Fatalf("Expected 2 import errors, found %d", len(rows))
+	}
+	if rows[0].FilePath != "a_dag.json" || rows[1].FilePath != "b_dag.json" {
+		t.Errorf("Expected import errors ordered by file path, found %s", rows)
+	}
+	if rows[1].Error != "invalid character 'x' looking for beginning of value" {
+		t.Errorf("Expected the error message to be stored unchanged, found \"%s\"", rows[1].Error)
+	}
+
+	tableClient.ReplaceImportErrors([]Row{NewRow("b_dag.json", "still broken")})
+	rows = tableClient.GetImportErrors()
+	if len(rows) != 1 || rows[0].Error != "still broken" {
+		t.Errorf("Expected only the latest import error, found %s", rows)
+	}
+}
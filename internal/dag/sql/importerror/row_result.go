@@ -0,0 +1,83 @@
+package importerror
+
+import (
+	"database/sql"
+	"goflow/internal/database"
+	"goflow/internal/dateutils"
+	"goflow/internal/jsonpanic"
+	"time"
+)
+
+const filePathName = "file_path"
+const errorName = "error"
+const lastUpdatedDateName = "last_updated_date"
+
+// Row is a struct containing the error that kept a DAG file from being loaded
+type Row struct {
+	FilePath        string
+	Error           string
+	LastUpdatedDate time.Time
+}
+
+// NewRow returns a new row with the appropriate update time stamp
+func NewRow(filePath string, errorMessage string) Row {
+	return Row{
+		FilePath:        filePath,
+		Error:           errorMessage,
+		LastUpdatedDate: dateutils.GetDateTimeNowMilliSecond(),
+	}
+}
+
+func (row Row) String() string {
+	return jsonpanic.JSONPanicFormat(row)
+}
+
+// RowList is a list of Rows
+type RowList []Row
+
+func (rowList RowList) String() string {
+	return jsonpanic.JSONPanicFormat(rowList)
+}
+
+type importErrorRowResult struct {
+	returnedRows         RowList
+	hasUnlimitedCapacity bool
+}
+
+func newRowResult(n int) importErrorRowResult {
+	return importErrorRowResult{
+		returnedRows: make([]Row, 0, n), hasUnlimitedCapacity: n == 0,
+	}
+}
+
+func (row Row) columnar() database.ColumnWithValueSlice {
+	return []database.ColumnWithValue{
+		{Column: database.Column{Name: filePathName, DType: database.String{Val: row.FilePath}}},
+		{Column: database.Column{Name: errorName, DType: database.String{Val: row.Error}}},
+		{
+			Column: database.Column{
+				Name:  lastUpdatedDateName,
+				DType: database.TimeStamp{Val: row.LastUpdatedDate},
+			},
+		},
+	}
+}
+
+func (result *importErrorRowResult) ScanAppend(rows *sql.Rows) error {
+	row := Row{}
+	err := rows.Scan(
+		&row.FilePath,
+		&row.Error,
+		&row.LastUpdatedDate,
+	)
+	result.returnedRows = append(result.returnedRows, row)
+	return err
+}
+
+func (result *importErrorRowResult) Capacity() int {
+	return cap(result.returnedRows)
+}
+
+func (result *importErrorRowResult) HasUnlimitedCapacity() bool {
+	return result.hasUnlimitedCapacity
+}
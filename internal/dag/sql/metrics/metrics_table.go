@@ -28,7 +28,7 @@ func (client *TableClient) CreateTable() {
 }
 
 func fmtSQLDate(dateStruct time.Time) string {
-	return "'" + dateStruct.Format(dateutils.SQLiteDateForm) + "'"
+	return "'" + dateutils.SQLiteFormat(dateStruct) + "'"
 }
 
 // GetMetricsForDag retrieves the metrics rows for a given dag id
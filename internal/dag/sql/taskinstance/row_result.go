@@ -0,0 +1,139 @@
+package taskinstance
+
+import (
+	"database/sql"
+	"goflow/internal/database"
+	"goflow/internal/dateutils"
+	"goflow/internal/jsonpanic"
+	"time"
+)
+
+const dagIDName = "dag_id"
+const taskNameName = "task_name"
+const executionDateName = "execution_date"
+const stateName = "state"
+const attemptName = "attempt"
+
+// Row is a struct containing data about a particular task instance
+type Row struct {
+	DagID           int
+	TaskName        string
+	ExecutionDate   time.Time
+	State           string
+	PodName         string
+	StartDate       time.Time
+	EndDate         time.Time
+	LastUpdatedDate time.Time
+	Attempt         int
+}
+
+func (row Row) String() string {
+	return jsonpanic.JSONPanicFormat(row)
+}
+
+// NewRow returns a new row with the appropriate update time stamp
+func NewRow(
+	dagID int,
+	taskName string,
+	executionDate time.Time,
+	state string,
+	podName string,
+	startDate time.Time,
+	endDate time.Time,
+) Row {
+	return Row{
+		DagID:           dagID,
+		TaskName:        taskName,
+		ExecutionDate:   executionDate,
+		State:           state,
+		PodName:         podName,
+		StartDate:       startDate,
+		EndDate:         endDate,
+		LastUpdatedDate: dateutils.GetDateTimeNowMilliSecond(),
+	}
+}
+
+type taskInstanceRowResult struct {
+	returnedRows         []Row
+	hasUnlimitedCapacity bool
+}
+
+func newRowResult(n int) taskInstanceRowResult {
+	return taskInstanceRowResult{
+		returnedRows: make([]Row, 0, n), hasUnlimitedCapacity: n == 0,
+	}
+}
+
+func (row Row) columnar() database.ColumnWithValueSlice {
+	return []database.ColumnWithValue{
+		{
+			Column: database.Column{Name: dagIDName, DType: database.Int{Val: row.DagID}},
+		},
+		{
+			Column: database.Column{Name: taskNameName, DType: database.String{Val: row.TaskName}},
+		},
+		{
+			Column: database.Column{
+				Name:  executionDateName,
+				DType: database.TimeStamp{Val: row.ExecutionDate},
+			},
+		},
+		{Column: database.Column{Name: stateName, DType: database.String{Val: row.State}}},
+		{Column: database.Column{Name: "pod_name", DType: database.String{Val: row.PodName}}},
+		{
+			Column: database.Column{
+				Name:  "start_date",
+				DType: database.TimeStamp{Val: row.StartDate},
+			},
+		},
+		{
+			Column: database.Column{Name: "end_date", DType: database.TimeStamp{Val: row.EndDate}},
+		},
+		{
+			Column: database.Column{
+				Name:  "last_updated_date",
+				DType: database.TimeStamp{Val: row.LastUpdatedDate},
+			},
+		},
+		{Column: database.Column{Name: attemptName, DType: database.Int{Val: row.Attempt}}},
+	}
+}
+
+// keyColumns returns the columns that uniquely identify a task instance
+func (row Row) keyColumns() database.ColumnWithValueSlice {
+	return row.columnar()[:3]
+}
+
+// attemptKeyColumns returns the columns that uniquely identify an attempt of a task instance
+func (row Row) attemptKeyColumns() database.ColumnWithValueSlice {
+	columns := row.columnar()
+	return []database.ColumnWithValue{columns[0], columns[1], columns[2], columns[8]}
+}
+
+// valueColumns returns the columns of a task instance that change over time
+func (row Row) valueColumns() database.ColumnWithValueSlice {
+	return row.columnar()[3:]
+}
+
+func (result *taskInstanceRowResult) ScanAppend(rows *sql.Rows) error {
+	row := Row{}
+	err := rows.Scan(
+		&row.DagID,
+		&row.TaskName,
+		&row.ExecutionDate,
+		&row.State,
+		&row.PodName,
+		&row.StartDate,
+		&row.EndDate,
+		&row.LastUpdatedDate,
+		&row.Attempt,
+	)
+	result.returnedRows = append(result.returnedRows, row)
+	return err
+}
+func (result *taskInstanceRowResult) Capacity() int {
+	return cap(result.returnedRows)
+}
+func (result *taskInstanceRowResult) HasUnlimitedCapacity() bool {
+	return result.hasUnlimitedCapacity
+}
@@ -0,0 +1,116 @@
+package taskinstance
+
+import (
+	"fmt"
+	dagtable "goflow/internal/dag/sql/dag"
+	"goflow/internal/database"
+	"goflow/internal/dateutils"
+	"time"
+)
+
+const tableName = "taskinstance"
+
+// attemptTableName is the table that keeps a row for every attempt of each task instance, while
+// the task instance table only keeps its most recent attempt
+const attemptTableName = "taskattempt"
+
+// TableClient is a struct that interacts with the task instance and task attempt tables
+type TableClient struct {
+	sqlClient       *database.SQLClient
+	tableDef        database.Table
+	attemptTableDef database.Table
+}
+
+// newTableDef returns the definition of a table of task instance rows with the given unique key
+func newTableDef(name string, keyColumns []database.Column) database.Table {
+	return database.Table{Name: name,
+		Cols:       Row{}.columnar().Columns(),
+		UniqueCols: keyColumns,
+		ForeignKeys: []database.KeyReference{{
+			Key:      keyColumns[0],
+			RefTable: dagtable.TableName,
+			RefCol: database.Column{
+				Name:  dagtable.IDName,
+				DType: database.Int{},
+			},
+		}},
+	}
+}
+
+// NewTableClient returns a new table client
+func NewTableClient(sqlClient *database.SQLClient) *TableClient {
+	return &TableClient{
+		sqlClient,
+		newTableDef(tableName, Row{}.keyColumns().Columns()),
+		newTableDef(attemptTableName, Row{}.attemptKeyColumns().Columns()),
+	}
+}
+
+// CreateTable creates the tables for storing task instance and task attempt information
+func (client *TableClient) CreateTable() {
+	client.sqlClient.CreateTable(client.tableDef)
+	client.sqlClient.CreateTable(client.attemptTableDef)
+}
+
+// GetTaskInstancesForDagRun retrieves the task instance rows for a given dag id and execution date
+func (client *TableClient) GetTaskInstancesForDagRun(dagID int, executionDate time.Time) []Row {
+	result := newRowResult(0)
+	client.sqlClient.QueryIntoResults(
+		&result,
+		fmt.Sprintf(
+			"SELECT * FROM %s WHERE %s = %d AND %s = '%s' ORDER BY %s ASC",
+			tableName,
+			dagIDName,
+			dagID,
+			executionDateName,
+			dateutils.SQLiteFormat(executionDate),
+			taskNameName,
+		),
+	)
+	return result.returnedRows
+}
+
+// GetTaskAttemptsForDagRun retrieves a row for every attempt of each task of the dag run with the
+// given dag id and execution date, ordered by task name and attempt
+func (client *TableClient) GetTaskAttemptsForDagRun(dagID int, executionDate time.Time) []Row {
+	result := newRowResult(0)
+	client.sqlClient.QueryIntoResults(
+		&result,
+		fmt.Sprintf(
+			"SELECT * FROM %s WHERE %s = %d AND %s = '%s' ORDER BY %s ASC, %s ASC",
+			attemptTableName,
+			dagIDName,
+			dagID,
+			executionDateName,
+			dateutils.SQLiteFormat(executionDate),
+			taskNameName,
+			attemptName,
+		),
+	)
+	return result.returnedRows
+}
+
+// isRowPresent returns true if the table has a row with the given key
+func (client *TableClient) isRowPresent(table string, key database.ColumnWithValueSlice) bool {
+	result := newRowResult(1)
+	client.sqlClient.QueryIntoResults(
+		&result,
+		fmt.Sprintf("SELECT * FROM %s WHERE %s", table, key.Join(" AND ")),
+	)
+	return len(result.returnedRows) == 1
+}
+
+// upsert inserts the row into the table, or updates the row with the same key
+func (client *TableClient) upsert(table string, row Row, key database.ColumnWithValueSlice) {
+	if !client.isRowPresent(table, key) {
+		client.sqlClient.Insert(table, row.columnar())
+		return
+	}
+	client.sqlClient.Update(table, row.valueColumns(), key)
+}
+
+// UpsertTaskInstance inserts or updates the task instance, along with the row of its attempt
+func (client *TableClient) UpsertTaskInstance(row Row) {
+	client.upsert(tableName, row, row.keyColumns())
+	client.upsert(attemptTableName, row, row.attemptKeyColumns())
+}
@@ -0,0 +1,102 @@
+package taskinstance
+
+import (
+	dagtable "goflow/internal/dag/sql/dag"
+	"goflow/internal/database"
+	"goflow/internal/testutils"
+	"testing"
+	"time"
+)
+
+var sqlClient *database.SQLClient
+var tableClient *TableClient
+
+var testDagRow = dagtable.NewRow(0, true, "dag_num_1", "default", "v1", "/my/path", "json")
+
+func setUpTables() {
+	dagTableClient := dagtable.NewTableClient(sqlClient)
+	dagTableClient.CreateTable()
+	dagTableClient.UpsertDAG(testDagRow)
+	tableClient.CreateTable()
+}
+
+func TestMain(m *testing.M) {
+	testutils.RemoveSQLiteDB()
+	sqlClient = database.NewSQLiteClient(testutils.GetSQLiteLocation())
+	tableClient = NewTableClient(sqlClient)
+	m.Run()
+}
+
+func TestCreateTaskInstanceTable(t *testing.T) {
+	defer database.PurgeDB(sqlClient)
+	setUpTables()
+	for _, expectedTable := range []string{tableName, attemptTableName} {
+		found := false
+		for _, table := range sqlClient.Tables() {
+			if table == expectedTable {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Did not find table %s in tables", expectedTable)
+		}
+	}
+}
+
+func TestUpsertTaskInstance(t *testing.T) {
+	defer database.PurgeDB(sqlClient)
+	setUpTables()
+
+	executionDate, _ := time.Parse("2006-01-02", "2019-01-01")
+	noTime := time.Time{}
+	expectedRows := []Row{
+		NewRow(testDagRow.ID, "extract", executionDate, "queued", "pod-1", noTime, noTime),
+		NewRow(testDagRow.ID, "load", executionDate, "scheduled", "pod-2", noTime, noTime),
+	}
+	for _, row := range expectedRows {
+		tableClient.UpsertTaskInstance(row)
+	}
+	expectedRows[0].State = "success"
+	expectedRows[0].Attempt = 2
+	expectedRows[0].EndDate = executionDate.Add(time.Hour)
+	tableClient.UpsertTaskInstance(expectedRows[0])
+
+	rows := tableClient.GetTaskInstancesForDagRun(testDagRow.ID, executionDate)
+	if len(rows) != len(expectedRows) {
+		t.Fatalf("Expected %d rows, found %d", len(expectedRows), len(rows))
+	}
+	for i, row := range rows {
+		if row != expectedRows[i] {
+			t.Errorf("Expected %s, got %s", expectedRows[i], row)
+		}
+	}
+}
+
+func TestGetTaskAttemptsForDagRun(t *testing.T) {
+	defer database.PurgeDB(sqlClient)
+	setUpTables()
+
+	executionDate, _ := time.Parse("2006-01-02", "2019-01-01")
+	noTime := time.Time{}
+	firstAttempt := NewRow(
+		testDagRow.ID, "extract", executionDate, "running", "pod", noTime, noTime,
+	)
+	firstAttempt.Attempt = 1
+	tableClient.UpsertTaskInstance(firstAttempt)
+	firstAttempt.State = "failed"
+	tableClient.UpsertTaskInstance(firstAttempt)
+	secondAttempt := firstAttempt
+	secondAttempt.Attempt = 2
+	secondAttempt.PodName = "pod-attempt-2"
+	secondAttempt.State = "success"
+	tableClient.UpsertTaskInstance(secondAttempt)
+
+	attempts := tableClient.GetTaskAttemptsForDagRun(testDagRow.ID, executionDate)
+	if len(attempts) != 2 || attempts[0] != firstAttempt || attempts[1] != secondAttempt {
+		t.Errorf("Expected attempts %s and %s, found %v", firstAttempt, secondAttempt, attempts)
+	}
+	instances := tableClient.GetTaskInstancesForDagRun(testDagRow.ID, executionDate)
+	if len(instances) != 1 || instances[0] != secondAttempt {
+		t.Errorf("Expected only the latest attempt %s, found %v", secondAttempt, instances)
+	}
+}
@@ -0,0 +1,64 @@
+package templating
+
+import (
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Context holds the values that may be used in the templates of a task's Command, Args and Env,
+// e.g. {{ .ExecutionDate | date "2006-01-02" }}
+type Context struct {
+	ExecutionDate     time.Time
+	DataIntervalStart time.Time
+	DataIntervalEnd   time.Time
+	DAGName           string
+	RunID             string
+	TaskName          string
+	Attempt           int
+	Conf              map[string]string
+}
+
+var funcs = template.FuncMap{
+	"date": func(layout string, date time.Time) string {
+		return date.Format(layout)
+	},
+}
+
+// Parse parses the given template text, returning an error if it is not a valid template
+func Parse(text string) (*template.Template, error) {
+	return template.New("").Funcs(funcs).Option("missingkey=error").Parse(text)
+}
+
+// Render fills in the given template text with the values from the context
+func Render(text string, context Context) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	parsed, err := Parse(text)
+	if err != nil {
+		return "", err
+	}
+	builder := &strings.Builder{}
+	err = parsed.Execute(builder, context)
+	if err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
+
+// RenderAll fills in each of the given template texts with the values from the context
+func RenderAll(texts []string, context Context) ([]string, error) {
+	if texts == nil {
+		return nil, nil
+	}
+	rendered := make([]string, 0, len(texts))
+	for _, text := range texts {
+		renderedText, err := Render(text, context)
+		if err != nil {
+			return nil, err
+		}
+		rendered = append(rendered, renderedText)
+	}
+	return rendered, nil
+}
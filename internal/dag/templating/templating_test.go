@@ -0,0 +1,47 @@
+package templating
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	context := Context{
+		ExecutionDate: time.Date(2021, 3, 1, 12, 30, 0, 0, time.UTC),
+		DAGName:       "my-dag",
+		Attempt:       2,
+		Conf:          map[string]string{"TABLE": "users"},
+	}
+	cases := []struct {
+		text     string
+		expected string
+	}{
+		{"plain text", "plain text"},
+		{`{{ .ExecutionDate | date "2006-01-02" }}`, "2021-03-01"},
+		{"{{ .DAGName }}-attempt-{{ .Attempt }}", "my-dag-attempt-2"},
+		{"--table={{ .Conf.TABLE }}", "--table=users"},
+	}
+	for _, testCase := range cases {
+		rendered, err := Render(testCase.text, context)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rendered != testCase.expected {
+			t.Errorf(
+				"Expected %s to render as %s, found %s",
+				testCase.text,
+				testCase.expected,
+				rendered,
+			)
+		}
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	for _, text := range []string{"{{ .ExecutionDate", "{{ .Missing }}", "{{ .Conf.MISSING }}"} {
+		_, err := Render(text, Context{Conf: map[string]string{}})
+		if err == nil {
+			t.Errorf("Expected an error rendering %s", text)
+		}
+	}
+}
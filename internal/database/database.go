@@ -80,13 +80,55 @@ func (client *SQLClient) Exec(queryString string) error {
 	return err
 }
 
-// CreateTable creates a table in the database for the given SQLClient
+// CreateTable creates a table in the database for the given SQLClient, adding any columns that
+// are missing from a table created by an earlier version
 func (client *SQLClient) CreateTable(t Table) {
 	query := t.createQuery()
 	err := client.Exec(query)
 	if err != nil {
 		panic(queryErrorMessage(query, err))
 	}
+	client.addMissingColumns(t)
+}
+
+// columnNames returns the names of the columns currently stored for the given table
+func (client *SQLClient) columnNames(table string) map[string]bool {
+	rows, err := client.database.Query(
+		fmt.Sprintf("SELECT name FROM pragma_table_info('%s')", table),
+	)
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+	names := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		rows.Scan(&name)
+		names[name] = true
+	}
+	return names
+}
+
+// addMissingColumns adds the columns of the table definition that are not in the stored table,
+// existing rows receive the zero value of the column's type
+func (client *SQLClient) addMissingColumns(t Table) {
+	existingColumns := client.columnNames(t.Name)
+	for _, col := range t.Cols {
+		if existingColumns[col.Name] {
+			continue
+		}
+		logs.Infof("Adding column %s to table %s", col.Name, t.Name)
+		query := fmt.Sprintf(
+			"ALTER TABLE %s ADD COLUMN %s DEFAULT %s",
+			t.Name,
+			col.String(),
+			ColumnWithValue{col}.ValRep(),
+		)
+		err := client.Exec(query)
+		if err != nil {
+			panic(queryErrorMessage(query, err))
+		}
+	}
 }
 
 // Insert inserts rows into a given table in the database
@@ -106,9 +148,8 @@ func (client *SQLClient) Insert(table string, columns []ColumnWithValue) {
 	)
 	err := client.Exec(query)
 	if err != nil {
-		fmt.Println(err.Error())
 		if strings.Contains(err.Error(), "no such table") {
-			logs.ErrorLogger.Println("Insert table", table, "is missing")
+			logs.Errorf("Insert table %s is missing: %s", table, err)
 			return
 		}
 		panic(queryErrorMessage(query, err))
@@ -148,6 +148,39 @@ func TestCreateTable(t *testing.T) {
 	}
 }
 
+func TestCreateTableAddsMissingColumns(t *testing.T) {
+	defer PurgeDB(client)
+	err := client.Exec(createTableQuery)
+	if err != nil {
+		panic(err)
+	}
+	err = client.Exec(insertionQuery)
+	if err != nil {
+		panic(err)
+	}
+	countColumn := Column{"count", Int{}}
+	client.CreateTable(Table{Name: testTable, Cols: []Column{idColumn, nameColumn, countColumn}})
+
+	if !client.columnNames(testTable)[countColumn.Name] {
+		t.Fatalf("Expected column %s to be added to table %s", countColumn.Name, testTable)
+	}
+	rows, err := client.Query(fmt.Sprintf("SELECT %s FROM %s", countColumn.Name, testTable))
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var count int
+		err = rows.Scan(&count)
+		if err != nil {
+			t.Error(err)
+		}
+		if count != 0 {
+			t.Errorf("Expected existing rows to have a count of 0, found %d", count)
+		}
+	}
+}
+
 func TestCreateTablesForeignKey(t *testing.T) {
 	defer PurgeDB(client)
 	t1 := Table{
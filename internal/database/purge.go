@@ -3,6 +3,7 @@ package database
 import (
 	"database/sql"
 	"fmt"
+	"goflow/internal/logs"
 	"goflow/internal/stringutils"
 	"time"
 )
@@ -36,7 +37,7 @@ func (result *depQueryResult) HasUnlimitedCapacity() bool {
 
 // getDependentTables returns a slice of table names that are dependent on this table
 func getDependentTables(table string, client *SQLClient) []string {
-	result := depQueryResult{}
+	result := depQueryResult{hasUnlimitedCapacity: true}
 	client.QueryIntoResults(
 		&result,
 		fmt.Sprintf(`SELECT 
@@ -74,10 +75,10 @@ func VerifyTableDrop(tableName string, client *SQLClient) bool {
 func PurgeDB(client *SQLClient) {
 	const dagRun = "dagrun"
 	tables := client.Tables()
-	fmt.Println("Tables Present", tables)
+	logs.Debugf("Tables present: %v", tables)
 	tableSet := stringutils.NewStringSet(tables)
 	if tableSet.Contains(dagRun) {
-		fmt.Println("Dropping dagrun first")
+		logs.Debugf("Dropping table %s first", dagRun)
 		client.Exec(fmt.Sprintf("DROP TABLE %s", dagRun))
 		tableSet.Remove(dagRun)
 	}
@@ -95,16 +96,16 @@ func PurgeDB(client *SQLClient) {
 			stack = stack[:n]
 			if tableSet.Contains(currTable) {
 				dependents := getDependentTables(currTable, client)
-				fmt.Println("Dependents of ", currTable, " are ", dependents)
+				logs.Debugf("Dependents of table %s are %v", currTable, dependents)
 				switch len(dependents) {
 				case 0:
-					fmt.Println("DROPPING", currTable)
+					logs.Infof("Dropping table %s", currTable)
 					_, err := client.database.Exec(fmt.Sprintf("DROP TABLE %s", currTable))
 					if err != nil {
 						panic(err)
 					}
 					tableSet.Remove(currTable)
-					for VerifyTableDrop(table, client) {
+					for VerifyTableDrop(currTable, client) {
 						time.Sleep(1 * time.Second)
 					}
 				default:
@@ -3,6 +3,7 @@ package database
 import (
 	"fmt"
 	"goflow/internal/dateutils"
+	"strings"
 	"time"
 )
 
@@ -19,7 +20,7 @@ func (s String) typeName() string {
 	return "STRING"
 }
 func (s String) getValRep() string {
-	return "'" + s.Val + "'"
+	return "'" + strings.ReplaceAll(s.Val, "'", "''") + "'"
 }
 
 // Int is an integer sql datatype
@@ -51,7 +52,7 @@ func (t TimeStamp) typeName() string {
 	return "TIMESTAMP"
 }
 func (t TimeStamp) getValRep() string {
-	return "'" + t.Val.Format(dateutils.SQLiteDateForm) + "'"
+	return "'" + dateutils.SQLiteFormat(t.Val) + "'"
 }
 
 // Bool is a bool sql datatype
@@ -1,23 +1,41 @@
 package dateutils
 
 import (
+	"fmt"
 	"time"
 )
 
 // SQLiteDateForm is the date format for SQLite
 const SQLiteDateForm = "2006-01-02 15:04:05"
 
-// GetDateTimeNowMilliSecond returns the current time down to millisecond
+// dateTimeForms are the formats, other than RFC3339, accepted for dates given in a time zone
+var dateTimeForms = []string{"2006-01-02", "2006-01-02T15:04:05", SQLiteDateForm}
+
+// GetDateTimeNowMilliSecond returns the current time in UTC, down to the second
 func GetDateTimeNowMilliSecond() time.Time {
-	creationTime := time.Now()
-	return time.Date(
-		creationTime.Year(),
-		creationTime.Month(),
-		creationTime.Day(),
-		creationTime.Hour(),
-		creationTime.Minute(),
-		creationTime.Second(),
-		0,
-		time.UTC,
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+// SQLiteFormat formats the time for SQLite, which stores all times in UTC
+func SQLiteFormat(t time.Time) string {
+	return t.UTC().Format(SQLiteDateForm)
+}
+
+// ParseDateTime parses an RFC3339 timestamp, or a date or date and time without an offset, which
+// is taken to be in the given location. The result is in the given location.
+func ParseDateTime(value string, location *time.Location) (time.Time, error) {
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return parsed.In(location), nil
+	}
+	for _, form := range dateTimeForms {
+		parsed, err = time.ParseInLocation(form, value, location)
+		if err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf(
+		"\"%s\" is not an RFC3339 timestamp or a date in the form 2006-01-02",
+		value,
 	)
 }
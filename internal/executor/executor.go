@@ -0,0 +1,61 @@
+package executor
+
+import (
+	"goflow/internal/logs"
+
+	batch "k8s.io/api/batch/v1"
+	core "k8s.io/api/core/v1"
+)
+
+// PhaseHandler is called every time a launched task moves to a new phase, with the exit code and
+// reason of the task once it has succeeded or failed
+type PhaseHandler func(phase core.PodPhase, exitCode int32, reason string)
+
+// Attempt describes a single attempt of a task to an executor
+type Attempt struct {
+	Pod      core.Pod   // The pod that runs the attempt, with its templates already rendered
+	Job      *batch.Job // Set when the DAG runs its tasks as kubernetes Jobs
+	WithLogs bool
+}
+
+// Name returns the name of the attempt's pod, which identifies the attempt to its executor
+func (attempt Attempt) Name() string {
+	return attempt.Pod.Name
+}
+
+// Namespace returns the namespace of the attempt's pod
+func (attempt Attempt) Namespace() string {
+	return attempt.Pod.Namespace
+}
+
+// attemptLog returns a log entry about the attempt
+func attemptLog(attempt Attempt) logs.Entry {
+	return logs.With(logs.Fields{
+		logs.PodField:       attempt.Name(),
+		logs.NamespaceField: attempt.Namespace(),
+	})
+}
+
+// Executor launches task attempts and reports on them while they run
+type Executor interface {
+	// Launch starts running the given attempt, calling the handler with every phase it moves to
+	Launch(attempt Attempt, handler PhaseHandler) (Execution, error)
+	// Resume takes over an attempt launched before goflow was restarted, returns false if the
+	// attempt can no longer be found. Only the name, namespace and kind of the attempt are used.
+	Resume(attempt Attempt, handler PhaseHandler) (Execution, bool)
+}
+
+// Execution is a launched task attempt
+type Execution interface {
+	// Pod returns the latest status seen for the attempt, as a pod
+	Pod() *core.Pod
+	// Logs returns the channel that the attempt's logs are sent to
+	Logs() chan string
+	// LogText returns the logs of the attempt so far, without taking them from Logs. It is empty
+	// unless the attempt was launched WithLogs.
+	LogText() string
+	// Wait returns once the attempt has succeeded or failed
+	Wait()
+	// Cancel stops the attempt if it is still running and removes what it left behind
+	Cancel() error
+}
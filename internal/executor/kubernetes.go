@@ -0,0 +1,279 @@
+package executor
+
+import (
+	"context"
+	"sync"
+
+	"goflow/internal/k8s/pod/event/holder"
+	"goflow/internal/k8s/pod/utils"
+	podwatch "goflow/internal/k8s/pod/watch"
+	"goflow/internal/logs"
+
+	core "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	k8sapi "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// KubernetesExecutor runs task attempts as pods, or as Jobs, in a kubernetes cluster and follows
+// them through the events that the informer passes to its channel holder
+type KubernetesExecutor struct {
+	client kubernetes.Interface
+	holder *holder.ChannelHolder
+}
+
+// NewKubernetesExecutor returns a new executor for the given cluster
+func NewKubernetesExecutor(
+	client kubernetes.Interface,
+	channelHolder *holder.ChannelHolder,
+) *KubernetesExecutor {
+	return &KubernetesExecutor{client, channelHolder}
+}
+
+// kubernetesExecution is a task attempt running as a pod or a Job
+type kubernetesExecution struct {
+	name      string
+	namespace string
+	isJob     bool
+	client    kubernetes.Interface
+	watcher   *podwatch.PodWatcher
+	pod       *core.Pod
+	waitOnce  *sync.Once
+	done      chan struct{}
+}
+
+// watch returns an execution whose watcher reports the attempt's phases to the given handler
+func (executor *KubernetesExecutor) watch(
+	attempt Attempt,
+	handler PhaseHandler,
+) *kubernetesExecution {
+	watcher := podwatch.NewPodWatcher(
+		attempt.Name(),
+		attempt.Namespace(),
+		executor.client,
+		attempt.WithLogs,
+		executor.holder,
+	)
+	if attempt.Job != nil {
+		watcher.WatchJob()
+	}
+	watcher.SetPhaseHandler(func(phase core.PodPhase) {
+		var exitCode int32
+		var reason string
+		if phase == core.PodSucceeded || phase == core.PodFailed {
+			exitCode, reason = watcher.Termination()
+		}
+		handler(phase, exitCode, reason)
+	})
+	executor.holder.AddChannelGroup(attempt.Name())
+	return &kubernetesExecution{
+		name:      attempt.Name(),
+		namespace: attempt.Namespace(),
+		isJob:     attempt.Job != nil,
+		client:    executor.client,
+		watcher:   watcher,
+		waitOnce:  &sync.Once{},
+		done:      make(chan struct{}),
+	}
+}
+
+// Launch creates the attempt's pod, or its Job, and starts monitoring it
+func (executor *KubernetesExecutor) Launch(
+	attempt Attempt,
+	handler PhaseHandler,
+) (Execution, error) {
+	execution := executor.watch(attempt, handler)
+	var err error
+	if attempt.Job != nil {
+		err = execution.createJob(attempt)
+	} else {
+		err = execution.createPod(attempt)
+	}
+	if err != nil {
+		executor.holder.DeleteChannelGroup(attempt.Name())
+		return nil, err
+	}
+	go execution.watcher.MonitorPod()
+	return execution, nil
+}
+
+// Resume takes over the attempt's pod, or its Job, if it is still present in the cluster and
+// monitors it until it finishes
+func (executor *KubernetesExecutor) Resume(
+	attempt Attempt,
+	handler PhaseHandler,
+) (Execution, bool) {
+	execution := executor.watch(attempt, handler)
+	var pod *core.Pod
+	var found bool
+	if attempt.Job != nil {
+		pod, found = execution.findJob()
+	} else {
+		pod, found = execution.findPod()
+	}
+	if !found {
+		executor.holder.DeleteChannelGroup(attempt.Name())
+		return nil, false
+	}
+	execution.log().Infof("Adopting pod %s in phase %s", pod.Name, pod.Status.Phase)
+	execution.pod = pod
+	switch pod.Status.Phase {
+	case core.PodRunning, core.PodSucceeded, core.PodFailed:
+		// The informer may have already seen this pod, so it will not be announced again
+		select {
+		case executor.holder.GetChannelGroup(attempt.Name()).Ready <- pod:
+		default:
+		}
+	}
+	go execution.watcher.MonitorPod()
+	return execution, true
+}
+
+// log returns a log entry about the attempt's pod, or its Job
+func (execution *kubernetesExecution) log() logs.Entry {
+	return logs.With(logs.Fields{
+		logs.PodField:       execution.name,
+		logs.NamespaceField: execution.namespace,
+	})
+}
+
+// createPod creates the attempt's pod
+func (execution *kubernetesExecution) createPod(attempt Attempt) error {
+	attemptLog(attempt).Debugf("Creating pod %s...", attempt.Name())
+	pod, err := execution.client.CoreV1().Pods(execution.namespace).Create(
+		context.TODO(),
+		&attempt.Pod,
+		k8sapi.CreateOptions{},
+	)
+	if err != nil {
+		return err
+	}
+	attemptLog(attempt).Infof(
+		"Pod '%s' created in namespace '%s'",
+		attempt.Name(),
+		attempt.Namespace(),
+	)
+	execution.pod = pod
+	return nil
+}
+
+// createJob creates the attempt's Job and records its status as the attempt's pod
+func (execution *kubernetesExecution) createJob(attempt Attempt) error {
+	attemptLog(attempt).Debugf("Creating job %s...", attempt.Job.Name)
+	job, err := execution.client.BatchV1().Jobs(execution.namespace).Create(
+		context.TODO(),
+		attempt.Job,
+		k8sapi.CreateOptions{},
+	)
+	if err != nil {
+		return err
+	}
+	attemptLog(attempt).Infof(
+		"Job '%s' created in namespace '%s'",
+		attempt.Job.Name,
+		attempt.Job.Namespace,
+	)
+	execution.pod = utils.JobStatusPod(job)
+	return nil
+}
+
+// findPod returns the attempt's pod by its labels if it is still present in the cluster
+func (execution *kubernetesExecution) findPod() (*core.Pod, bool) {
+	podList, err := execution.client.CoreV1().Pods(execution.namespace).List(
+		context.TODO(),
+		k8sapi.ListOptions{
+			LabelSelector: utils.LabelSelectorString(map[string]string{
+				utils.AppSelectorKey: utils.AppName,
+				"Name":               execution.name,
+			}),
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+	if len(podList.Items) == 0 {
+		return nil, false
+	}
+	return &podList.Items[0], true
+}
+
+// findJob returns the status of the attempt's Job as a pod if the Job is still present in the
+// cluster
+func (execution *kubernetesExecution) findJob() (*core.Pod, bool) {
+	job, err := execution.client.BatchV1().Jobs(execution.namespace).Get(
+		context.TODO(),
+		execution.name,
+		k8sapi.GetOptions{},
+	)
+	if k8serrors.IsNotFound(err) {
+		return nil, false
+	}
+	if err != nil {
+		panic(err)
+	}
+	return utils.JobStatusPod(job), true
+}
+
+// Pod returns the latest state seen for the attempt's pod, or the status of its Job
+func (execution *kubernetesExecution) Pod() *core.Pod {
+	if pod := execution.watcher.Pod(); pod != nil {
+		return pod
+	}
+	return execution.pod
+}
+
+// Logs returns the channel holding the watcher's logs
+func (execution *kubernetesExecution) Logs() chan string {
+	return execution.watcher.Logs
+}
+
+// LogText returns the logs that the watcher has sent so far
+func (execution *kubernetesExecution) LogText() string {
+	return execution.watcher.LogText()
+}
+
+// Wait returns when the watcher is done monitoring
+func (execution *kubernetesExecution) Wait() {
+	execution.waitOnce.Do(func() {
+		execution.watcher.WaitForMonitorDone()
+		close(execution.done)
+	})
+	<-execution.done
+}
+
+// Cancel deletes the attempt's pod, or its Job along with the pods that it created. A pod that is
+// already deleted, or a Job that kubernetes already deleted after its TTLSecondsAfterFinished, is
+// ignored.
+func (execution *kubernetesExecution) Cancel() error {
+	if execution.isJob {
+		execution.log().Infof(
+			"Deleting job %s, in namespace %s",
+			execution.name,
+			execution.namespace,
+		)
+		propagation := k8sapi.DeletePropagationBackground
+		err := execution.client.BatchV1().Jobs(execution.namespace).Delete(
+			context.TODO(),
+			execution.name,
+			k8sapi.DeleteOptions{PropagationPolicy: &propagation},
+		)
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	execution.log().Infof(
+		"Deleting pod %s, in namespace %s",
+		execution.name,
+		execution.namespace,
+	)
+	err := execution.client.CoreV1().Pods(execution.namespace).Delete(
+		context.TODO(),
+		execution.name,
+		k8sapi.DeleteOptions{},
+	)
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
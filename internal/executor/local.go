@@ -0,0 +1,313 @@
+package executor
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	dagconfig "goflow/internal/dag/config"
+	"goflow/internal/logs"
+
+	core "k8s.io/api/core/v1"
+	k8sapi "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Reasons given for a locally run task once it has finished
+const (
+	localCompletedReason = "Completed"
+	localErrorReason     = "Error"
+	localCanceledReason  = "Canceled"
+)
+
+// localLogsBuffer is how many lines of a task's output the Logs channel holds. Lines that arrive
+// while it is full are left out of the channel, but are still kept in the attempt's LogText.
+const localLogsBuffer = 1000
+
+// LocalExecutor runs task attempts on the machine that goflow runs on, either as subprocesses or,
+// when a container runtime such as docker or podman is given, as local containers of the task's
+// image
+type LocalExecutor struct {
+	containerRuntime string
+}
+
+// NewLocalExecutor returns a new executor that runs tasks with the given container runtime, or
+// as subprocesses when the runtime is empty
+func NewLocalExecutor(containerRuntime string) *LocalExecutor {
+	return &LocalExecutor{containerRuntime}
+}
+
+// localExecution is a task attempt running as a local process
+type localExecution struct {
+	name             string
+	containerRuntime string
+	cmd              *exec.Cmd
+	output           *io.PipeWriter // Receives the process's stdout and stderr
+	logText          *strings.Builder
+	logs             chan string
+	logsDone         chan struct{} // Closed once all of the output has been read
+	withLogs         bool
+	handler          PhaseHandler
+	pod              *core.Pod
+	canceled         bool
+	lock             *sync.Mutex
+	done             chan struct{}
+}
+
+// taskContainer returns the container of the pod that runs the task
+func taskContainer(pod core.Pod) (core.Container, error) {
+	for _, container := range pod.Spec.Containers {
+		if container.Name == dagconfig.TaskContainerName {
+			return container, nil
+		}
+	}
+	return core.Container{}, fmt.Errorf(
+		"pod %s has no %s container",
+		pod.Name,
+		dagconfig.TaskContainerName,
+	)
+}
+
+// localEnv returns the container's environment variables as NAME=value pairs. Variables that
+// kubernetes would read from a Secret or a ConfigMap are taken from goflow's own environment.
+func localEnv(container core.Container) ([]string, error) {
+	if len(container.EnvFrom) != 0 {
+		logs.Warningf(
+			"Secrets and ConfigMaps in EnvFromSecrets and EnvFromConfigMaps are not read by the " +
+				"local executor",
+		)
+	}
+	env := make([]string, 0, len(container.Env))
+	for _, envVar := range container.Env {
+		value := envVar.Value
+		if envVar.ValueFrom != nil {
+			found := false
+			value, found = os.LookupEnv(envVar.Name)
+			if !found {
+				return nil, fmt.Errorf(
+					"environment variable %s must be set to pass it to tasks run locally",
+					envVar.Name,
+				)
+			}
+		}
+		env = append(env, envVar.Name+"="+value)
+	}
+	return env, nil
+}
+
+// command returns the command that runs the attempt's task container
+func (executor *LocalExecutor) command(attempt Attempt) (*exec.Cmd, error) {
+	container, err := taskContainer(attempt.Pod)
+	if err != nil {
+		return nil, err
+	}
+	env, err := localEnv(container)
+	if err != nil {
+		return nil, err
+	}
+	if executor.containerRuntime == "" {
+		if len(container.Command) == 0 {
+			return nil, fmt.Errorf("task %s has no Command to run", attempt.Name())
+		}
+		args := append(append([]string{}, container.Command[1:]...), container.Args...)
+		cmd := exec.Command(container.Command[0], args...)
+		cmd.Env = append(os.Environ(), env...)
+		cmd.Dir = container.WorkingDir
+		return cmd, nil
+	}
+	args := []string{"run", "--rm", "--name", attempt.Name()}
+	for _, envVar := range env {
+		args = append(args, "--env", envVar)
+	}
+	if container.WorkingDir != "" {
+		args = append(args, "--workdir", container.WorkingDir)
+	}
+	if len(container.Command) != 0 {
+		args = append(args, "--entrypoint", container.Command[0])
+	}
+	args = append(args, container.Image)
+	if len(container.Command) != 0 {
+		args = append(args, container.Command[1:]...)
+	}
+	args = append(args, container.Args...)
+	return exec.Command(executor.containerRuntime, args...), nil
+}
+
+// Launch starts the attempt's process and waits for it to finish in the background
+func (executor *LocalExecutor) Launch(attempt Attempt, handler PhaseHandler) (Execution, error) {
+	cmd, err := executor.command(attempt)
+	if err != nil {
+		return nil, err
+	}
+	reader, writer := io.Pipe()
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+	pod := attempt.Pod.DeepCopy()
+	execution := &localExecution{
+		name:             attempt.Name(),
+		containerRuntime: executor.containerRuntime,
+		cmd:              cmd,
+		output:           writer,
+		logText:          &strings.Builder{},
+		logs:             make(chan string, localLogsBuffer),
+		logsDone:         make(chan struct{}),
+		withLogs:         attempt.WithLogs,
+		handler:          handler,
+		pod:              pod,
+		lock:             &sync.Mutex{},
+		done:             make(chan struct{}),
+	}
+	attemptLog(attempt).Infof("Running task %s locally: %s", attempt.Name(), cmd.String())
+	err = cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+	execution.setStatus(core.PodStatus{
+		Phase:     core.PodRunning,
+		StartTime: &k8sapi.Time{Time: time.Now()},
+	})
+	handler(core.PodRunning, 0, "")
+	go execution.streamLogs(reader)
+	go execution.wait()
+	return execution, nil
+}
+
+// Resume always returns false, as local processes do not outlive goflow
+func (executor *LocalExecutor) Resume(attempt Attempt, handler PhaseHandler) (Execution, bool) {
+	return nil, false
+}
+
+// setStatus records the latest status of the attempt
+func (execution *localExecution) setStatus(status core.PodStatus) {
+	execution.lock.Lock()
+	defer execution.lock.Unlock()
+	pod := execution.pod.DeepCopy()
+	pod.Status = status
+	execution.pod = pod
+}
+
+// streamLogs reads the output of the attempt's process line by line while it runs, logging each
+// line and, when the attempt keeps its logs, sending it to the Logs channel
+func (execution *localExecution) streamLogs(reader io.Reader) {
+	defer close(execution.logsDone)
+	lineReader := bufio.NewReader(reader)
+	for {
+		line, err := lineReader.ReadString('\n')
+		if line != "" {
+			execution.addLogLine(line)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// addLogLine logs a line of the attempt's output and keeps it if the attempt keeps its logs
+func (execution *localExecution) addLogLine(line string) {
+	logs.With(logs.Fields{logs.PodField: execution.name}).Infof("%s", line)
+	if !execution.withLogs {
+		return
+	}
+	execution.lock.Lock()
+	execution.logText.WriteString(line)
+	execution.lock.Unlock()
+	select {
+	case execution.logs <- line:
+	default:
+	}
+}
+
+// wait waits for the attempt's process to exit and for its output to be read, then reports how it
+// finished
+func (execution *localExecution) wait() {
+	defer close(execution.done)
+	err := execution.cmd.Wait()
+	execution.output.Close()
+	<-execution.logsDone
+	exitCode := int32(execution.cmd.ProcessState.ExitCode())
+	phase := core.PodSucceeded
+	reason := localCompletedReason
+	if err != nil {
+		phase = core.PodFailed
+		reason = localErrorReason
+		execution.lock.Lock()
+		if execution.canceled {
+			reason = localCanceledReason
+		}
+		execution.lock.Unlock()
+	}
+	startTime := execution.Pod().Status.StartTime
+	execution.setStatus(core.PodStatus{
+		Phase:     phase,
+		Reason:    reason,
+		StartTime: startTime,
+		ContainerStatuses: []core.ContainerStatus{{
+			Name: dagconfig.TaskContainerName,
+			State: core.ContainerState{Terminated: &core.ContainerStateTerminated{
+				ExitCode:   exitCode,
+				Reason:     reason,
+				FinishedAt: k8sapi.Time{Time: time.Now()},
+			}},
+		}},
+	})
+	logs.With(logs.Fields{logs.PodField: execution.name}).Infof(
+		"Task %s finished with exit code %d",
+		execution.name,
+		exitCode,
+	)
+	execution.handler(phase, exitCode, reason)
+}
+
+// Pod returns the latest status of the attempt as a pod
+func (execution *localExecution) Pod() *core.Pod {
+	execution.lock.Lock()
+	defer execution.lock.Unlock()
+	return execution.pod
+}
+
+// Logs returns the channel that each line of the attempt's output is sent to as it arrives
+func (execution *localExecution) Logs() chan string {
+	return execution.logs
+}
+
+// LogText returns the output of the attempt's process so far
+func (execution *localExecution) LogText() string {
+	execution.lock.Lock()
+	defer execution.lock.Unlock()
+	return execution.logText.String()
+}
+
+// Wait returns once the attempt's process has exited
+func (execution *localExecution) Wait() {
+	<-execution.done
+}
+
+// Cancel kills the attempt's process, or removes its container, if it is still running
+func (execution *localExecution) Cancel() error {
+	select {
+	case <-execution.done:
+		return nil
+	default:
+	}
+	execution.lock.Lock()
+	execution.canceled = true
+	execution.lock.Unlock()
+	if execution.containerRuntime != "" {
+		output, err := exec.Command(
+			execution.containerRuntime, "rm", "--force", execution.name,
+		).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("%s: %s", err.Error(), strings.TrimSpace(string(output)))
+		}
+		return nil
+	}
+	err := execution.cmd.Process.Kill()
+	if err != nil && !strings.Contains(err.Error(), "process already finished") {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package executor
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	dagconfig "goflow/internal/dag/config"
+
+	core "k8s.io/api/core/v1"
+	k8sapi "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type phaseRecorder struct {
+	phases   []core.PodPhase
+	exitCode int32
+	reason   string
+}
+
+func (recorder *phaseRecorder) handle(phase core.PodPhase, exitCode int32, reason string) {
+	recorder.phases = append(recorder.phases, phase)
+	recorder.exitCode = exitCode
+	recorder.reason = reason
+}
+
+func getTestAttempt(name string, command []string, env []core.EnvVar) Attempt {
+	return Attempt{
+		Pod: core.Pod{
+			ObjectMeta: k8sapi.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: core.PodSpec{Containers: []core.Container{{
+				Name:    dagconfig.TaskContainerName,
+				Image:   "busybox",
+				Command: command,
+				Env:     env,
+			}}},
+		},
+		WithLogs: true,
+	}
+}
+
+func TestLocalExecutorLaunch(t *testing.T) {
+	cases := []struct {
+		name             string
+		command          []string
+		expectedPhase    core.PodPhase
+		expectedExitCode int32
+		expectedLogs     string
+	}{
+		{"succeeds", []string{"sh", "-c", "echo $GREETING"}, core.PodSucceeded, 0, "hello\n"},
+		{"fails", []string{"sh", "-c", "echo failing; exit 3"}, core.PodFailed, 3, "failing\n"},
+	}
+	for _, testCase := range cases {
+		recorder := &phaseRecorder{}
+		attempt := getTestAttempt(
+			testCase.name,
+			testCase.command,
+			[]core.EnvVar{{Name: "GREETING", Value: "hello"}},
+		)
+		execution, err := NewLocalExecutor("").Launch(attempt, recorder.handle)
+		if err != nil {
+			panic(err)
+		}
+		execution.Wait()
+		expectedPhases := []core.PodPhase{core.PodRunning, testCase.expectedPhase}
+		if len(recorder.phases) != 2 || recorder.phases[1] != testCase.expectedPhase {
+			t.Errorf("Expected phases %v, found %v", expectedPhases, recorder.phases)
+		}
+		if recorder.exitCode != testCase.expectedExitCode {
+			t.Errorf(
+				"Expected exit code %d, found %d",
+				testCase.expectedExitCode,
+				recorder.exitCode,
+			)
+		}
+		if execution.Pod().Status.Phase != testCase.expectedPhase {
+			t.Errorf("Expected pod in phase %s, found %s", testCase.expectedPhase, execution.Pod())
+		}
+		logs := <-execution.Logs()
+		if logs != testCase.expectedLogs {
+			t.Errorf("Expected logs %q, found %q", testCase.expectedLogs, logs)
+		}
+		if execution.LogText() != testCase.expectedLogs {
+			t.Errorf("Expected log text %q, found %q", testCase.expectedLogs, execution.LogText())
+		}
+	}
+}
+
+func TestLocalExecutorStreamsLogs(t *testing.T) {
+	command := []string{"sh", "-c", "echo first; echo second; sleep 30"}
+	attempt := getTestAttempt("stream", command, nil)
+	execution, err := NewLocalExecutor("").Launch(attempt, (&phaseRecorder{}).handle)
+	if err != nil {
+		panic(err)
+	}
+	defer execution.Cancel()
+	for _, expected := range []string{"first\n", "second\n"} {
+		select {
+		case line := <-execution.Logs():
+			if line != expected {
+				t.Errorf("Expected line %q, found %q", expected, line)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected line %q to be sent while the task runs", expected)
+		}
+	}
+	if execution.LogText() != "first\nsecond\n" {
+		t.Errorf("Expected the log text to hold both lines, found %q", execution.LogText())
+	}
+	if phase := execution.Pod().Status.Phase; phase != core.PodRunning {
+		t.Errorf("Expected the task to still be running, found %s", phase)
+	}
+}
+
+func TestLocalExecutorSecretEnv(t *testing.T) {
+	secretEnv := []core.EnvVar{{
+		Name: "GOFLOW_TEST_SECRET",
+		ValueFrom: &core.EnvVarSource{SecretKeyRef: &core.SecretKeySelector{
+			LocalObjectReference: core.LocalObjectReference{Name: "secret"},
+			Key:                  "key",
+		}},
+	}}
+	attempt := getTestAttempt("secret", []string{"sh", "-c", "echo $GOFLOW_TEST_SECRET"}, secretEnv)
+	_, err := NewLocalExecutor("").Launch(attempt, (&phaseRecorder{}).handle)
+	if err == nil {
+		t.Error("A secret that is not in goflow's environment should not be passed to the task")
+	}
+
+	os.Setenv("GOFLOW_TEST_SECRET", "from-env")
+	defer os.Unsetenv("GOFLOW_TEST_SECRET")
+	execution, err := NewLocalExecutor("").Launch(attempt, (&phaseRecorder{}).handle)
+	if err != nil {
+		panic(err)
+	}
+	execution.Wait()
+	if logs := <-execution.Logs(); strings.TrimSpace(logs) != "from-env" {
+		t.Errorf("Expected secret to be read from goflow's environment, found %q", logs)
+	}
+}
+
+func TestLocalExecutorCancel(t *testing.T) {
+	recorder := &phaseRecorder{}
+	attempt := getTestAttempt("cancel", []string{"sleep", "30"}, nil)
+	execution, err := NewLocalExecutor("").Launch(attempt, recorder.handle)
+	if err != nil {
+		panic(err)
+	}
+	err = execution.Cancel()
+	if err != nil {
+		panic(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		execution.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Canceled task should have stopped")
+	}
+	if recorder.reason != localCanceledReason {
+		t.Errorf("Expected reason %s, found %s", localCanceledReason, recorder.reason)
+	}
+}
+
+func TestLocalExecutorContainerCommand(t *testing.T) {
+	attempt := getTestAttempt("container", []string{"echo", "1"}, nil)
+	attempt.Pod.Spec.Containers[0].Args = []string{"2"}
+	cmd, err := NewLocalExecutor("docker").command(attempt)
+	if err != nil {
+		panic(err)
+	}
+	expected := "docker run --rm --name container --entrypoint echo busybox 1 2"
+	if cmd.String() != expected && !strings.HasSuffix(cmd.String(), expected[len("docker"):]) {
+		t.Errorf("Expected command %s, found %s", expected, cmd.String())
+	}
+}
@@ -144,7 +144,7 @@ func waitUntilPodsGoneOrTimePassed(
 	client kubernetes.Interface,
 	firstDAGRunPodNamesSet map[string]struct{},
 ) {
-	logs.InfoLogger.Println(firstDAGRunPodNamesSet)
+	logs.Infof("Waiting for pods %v to be gone", firstDAGRunPodNamesSet)
 	endWait := make(chan struct{})
 
 	secondsTime := seconds * time.Second
@@ -163,7 +163,7 @@ func waitUntilPodsGoneOrTimePassed(
 				break
 			default:
 				if !podNamesInPodMap(firstDAGRunPodNamesSet, defaultNameSpaceMap) {
-					logs.InfoLogger.Println("Pods gone")
+					logs.Infof("Pods gone")
 					close(endWait)
 					return
 				}
@@ -174,7 +174,7 @@ func waitUntilPodsGoneOrTimePassed(
 	}()
 
 	_, _ = <-endWait
-	logs.InfoLogger.Println("Wait done")
+	logs.Infof("Wait done")
 }
 
 func getDateFromString(dateStr string) time.Time {
@@ -216,11 +216,11 @@ func startServer() {
 	waitUntilPodsGoneOrTimePassed(30, kubeClient, firstRunDagNames)
 
 	for _, run := range orch.DagRuns() {
-		logs.InfoLogger.Println(run)
+		logs.Infof("%v", run)
 		_, ok := firstRunDagNames[run.Name]
 		if ok {
 			select {
-			case logText := <-run.Logs():
+			case logText := <-run.Tasks[0].Logs():
 				withoutNewlines := strings.TrimSpace(logText)
 				expectedLogMessage := getLogMessage(getDagID(*run.Config))
 				if withoutNewlines != expectedLogMessage {
@@ -233,7 +233,7 @@ func startServer() {
 					)
 				}
 			default:
-				logs.InfoLogger.Println(run.Logs())
+				logs.Infof("%v", run.Tasks[0].Logs())
 				panic(fmt.Sprintf("No logs available for pod %s!!!", run.Name))
 			}
 		}
@@ -244,7 +244,7 @@ func startServer() {
 }
 
 func init() {
-	logs.InfoLogger.Println("Starting goflow simulation program...")
+	logs.Infof("Starting goflow simulation program...")
 	expectedDagCount = 6
 }
 
@@ -3,20 +3,24 @@ package inform
 import (
 	"goflow/internal/k8s/pod/event/channel"
 	"goflow/internal/k8s/pod/event/holder"
+	"goflow/internal/k8s/pod/utils"
 	"goflow/internal/logs"
 	"time"
 
 	"fmt"
 
+	batch "k8s.io/api/batch/v1"
 	core "k8s.io/api/core/v1"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 )
 
-// TaskInformer is a custom informer that updates the pod states while in the channel holder
+// TaskInformer is a custom informer that updates the pod states while in the channel holder. Jobs
+// are passed to the channel holder as pods whose phase describes the status of the Job.
 type TaskInformer struct {
 	podInformer         cache.SharedInformer
+	jobInformer         cache.SharedInformer
 	channelHolder       *holder.ChannelHolder
 	stopInformerChannel chan struct{}
 }
@@ -28,68 +32,105 @@ func New(
 ) TaskInformer {
 	factory := informers.NewSharedInformerFactory(client, 2*time.Second)
 	sharedInformer := factory.Core().V1().Pods().Informer()
-	taskInformer := TaskInformer{sharedInformer, channelHolder, make(chan struct{})}
+	jobInformer := factory.Batch().V1().Jobs().Informer()
+	taskInformer := TaskInformer{
+		sharedInformer,
+		jobInformer,
+		channelHolder,
+		make(chan struct{}),
+	}
 
 	sharedInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
-			pod := getPodFromInterface(obj)
-			if taskInformer.channelHolder.Contains(pod.Name) && podReadyToLog(pod) {
-				select {
-				case taskInformer.getChannelGroup(pod.Name).Ready <- pod:
-					logs.InfoLogger.Printf(
-						"Pod with name %s added and ready in phase %s\n",
-						pod.Name,
-						pod.Status.Phase,
-					)
-				default:
-					logs.InfoLogger.Printf(
-						"Pod with name %s already added from update",
-						pod.Name,
-					)
-				}
-
-			}
+			taskInformer.podAdded(getPodFromInterface(obj))
 		},
 		UpdateFunc: func(old interface{}, new interface{}) {
-			oldPod := getPodFromInterface(old)
-			newPod := getPodFromInterface(new)
-			if !taskInformer.channelHolder.Contains(newPod.Name) {
-				return
-			}
-			if podReadyToLog(newPod) {
-				select {
-				case taskInformer.getChannelGroup(newPod.Name).Ready <- newPod:
-					logs.InfoLogger.Printf(
-						"Pod %s updated to ready in phase %s",
-						newPod.Name,
-						newPod.Status.Phase,
-					)
-				default:
-					logs.InfoLogger.Printf(
-						"Pod with name %s already added from update\n",
-						newPod.Name,
-					)
-				}
-
-			}
-			if oldPod.Status.Phase != newPod.Status.Phase {
-				taskInformer.getChannelGroup(newPod.Name).Update <- newPod
-				logs.InfoLogger.Printf(
-					"Pod %s updated from phase %s to phase %s",
-					newPod.Name,
-					oldPod.Status.Phase,
-					newPod.Status.Phase,
-				)
-			}
+			taskInformer.podUpdated(getPodFromInterface(old), getPodFromInterface(new))
 		},
 		DeleteFunc: func(obj interface{}) {
 			pod := getPodFromInterface(obj)
-			logs.InfoLogger.Printf("Pod %s was successfully deleted\n", pod.Name)
+			podLog(pod).Infof("Pod %s was successfully deleted", pod.Name)
+		},
+	})
+	jobInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			taskInformer.podAdded(utils.JobStatusPod(getJobFromInterface(obj)))
+		},
+		UpdateFunc: func(old interface{}, new interface{}) {
+			taskInformer.podUpdated(
+				utils.JobStatusPod(getJobFromInterface(old)),
+				utils.JobStatusPod(getJobFromInterface(new)),
+			)
+		},
+		DeleteFunc: func(obj interface{}) {
+			job := getJobFromInterface(obj)
+			logs.With(logs.Fields{logs.NamespaceField: job.Namespace}).Infof(
+				"Job %s was successfully deleted",
+				job.Name,
+			)
 		},
 	})
 	return taskInformer
 }
 
+// podLog returns a log entry about the pod
+func podLog(pod *core.Pod) logs.Entry {
+	return logs.With(logs.Fields{logs.PodField: pod.Name, logs.NamespaceField: pod.Namespace})
+}
+
+// podAdded announces a pod in the channel holder once it is ready to log
+func (taskInformer *TaskInformer) podAdded(pod *core.Pod) {
+	if taskInformer.channelHolder.Contains(pod.Name) && podReadyToLog(pod) {
+		select {
+		case taskInformer.getChannelGroup(pod.Name).Ready <- pod:
+			podLog(pod).Debugf(
+				"Pod with name %s added and ready in phase %s",
+				pod.Name,
+				pod.Status.Phase,
+			)
+		default:
+			podLog(pod).Debugf(
+				"Pod with name %s already added from update",
+				pod.Name,
+			)
+		}
+
+	}
+}
+
+// podUpdated announces a pod in the channel holder once it is ready to log, and every time that
+// its phase changes
+func (taskInformer *TaskInformer) podUpdated(oldPod *core.Pod, newPod *core.Pod) {
+	if !taskInformer.channelHolder.Contains(newPod.Name) {
+		return
+	}
+	if podReadyToLog(newPod) {
+		select {
+		case taskInformer.getChannelGroup(newPod.Name).Ready <- newPod:
+			podLog(newPod).Debugf(
+				"Pod %s updated to ready in phase %s",
+				newPod.Name,
+				newPod.Status.Phase,
+			)
+		default:
+			podLog(newPod).Debugf(
+				"Pod with name %s already added from update",
+				newPod.Name,
+			)
+		}
+
+	}
+	if oldPod.Status.Phase != newPod.Status.Phase {
+		taskInformer.getChannelGroup(newPod.Name).Update <- newPod
+		podLog(newPod).Infof(
+			"Pod %s updated from phase %s to phase %s",
+			newPod.Name,
+			oldPod.Status.Phase,
+			newPod.Status.Phase,
+		)
+	}
+}
+
 func podReadyToLog(pod *core.Pod) bool {
 	return (pod.Status.Phase == core.PodRunning) || (pod.Status.Phase == core.PodSucceeded) ||
 		(pod.Status.Phase == core.PodFailed)
@@ -103,16 +144,25 @@ func getPodFromInterface(obj interface{}) *core.Pod {
 	return pod
 }
 
+func getJobFromInterface(obj interface{}) *batch.Job {
+	job, ok := obj.(*batch.Job)
+	if !ok {
+		panic(fmt.Sprintf("Expected %T, but go %T", &batch.Job{}, obj))
+	}
+	return job
+}
+
 func (taskInformer *TaskInformer) getChannelGroup(podName string) *channel.FuncChannelGroup {
 	return taskInformer.channelHolder.GetChannelGroup(podName)
 }
 
-// Stop stops the running informer
+// Stop stops the running informers
 func (taskInformer *TaskInformer) Stop() {
-	taskInformer.stopInformerChannel <- struct{}{}
+	close(taskInformer.stopInformerChannel)
 }
 
-// Start starts the informer
+// Start starts the informers
 func (taskInformer *TaskInformer) Start() {
 	go taskInformer.podInformer.Run(taskInformer.stopInformerChannel)
+	go taskInformer.jobInformer.Run(taskInformer.stopInformerChannel)
 }
@@ -1,13 +1,16 @@
 package inform
 
 import (
+	"context"
 	"goflow/internal/k8s/pod/event/holder"
 	podutils "goflow/internal/k8s/pod/utils"
 	"goflow/internal/testutils"
 
 	"testing"
 
+	batch "k8s.io/api/batch/v1"
 	core "k8s.io/api/core/v1"
+	k8sapi "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"k8s.io/client-go/kubernetes/fake"
 )
@@ -61,3 +64,71 @@ func TestPodReadyForLogging(t *testing.T) {
 	}
 
 }
+
+func TestJobStatusReportedAsPod(t *testing.T) {
+	table := []struct {
+		status         batch.JobStatus
+		expectedPhase  core.PodPhase
+		expectedReason string
+	}{
+		{batch.JobStatus{Active: 1}, core.PodRunning, ""},
+		{
+			batch.JobStatus{Conditions: []batch.JobCondition{
+				{Type: batch.JobComplete, Status: core.ConditionTrue},
+			}},
+			core.PodSucceeded,
+			"Completed",
+		},
+		{
+			batch.JobStatus{Conditions: []batch.JobCondition{
+				{Type: batch.JobFailed, Status: core.ConditionTrue, Reason: "BackoffLimitExceeded"},
+			}},
+			core.PodFailed,
+			"BackoffLimitExceeded",
+		},
+	}
+	for _, testCase := range table {
+		func() {
+			KUBECLIENT := fake.NewSimpleClientset()
+			channelHolder := holder.New()
+			taskInformer := New(KUBECLIENT, channelHolder)
+			jobName := "test-job-informer-add-job"
+			namespace := "default"
+
+			go taskInformer.Start()
+			defer taskInformer.Stop()
+
+			channelHolder.AddChannelGroup(jobName)
+			_, err := KUBECLIENT.BatchV1().Jobs(namespace).Create(
+				context.TODO(),
+				&batch.Job{
+					ObjectMeta: k8sapi.ObjectMeta{Name: jobName, Namespace: namespace},
+					Status:     testCase.status,
+				},
+				k8sapi.CreateOptions{},
+			)
+			if err != nil {
+				panic(err)
+			}
+
+			pod := <-channelHolder.GetChannelGroup(jobName).Ready
+			if pod.Name != jobName {
+				t.Errorf("Pod should have name %s, but saw name %s", jobName, pod.Name)
+			}
+			if pod.Status.Phase != testCase.expectedPhase {
+				t.Errorf(
+					"Expected job to be reported in phase %s, found %s",
+					testCase.expectedPhase,
+					pod.Status.Phase,
+				)
+			}
+			if pod.Status.Reason != testCase.expectedReason {
+				t.Errorf(
+					"Expected job to be reported with reason %s, found %s",
+					testCase.expectedReason,
+					pod.Status.Reason,
+				)
+			}
+		}()
+	}
+}
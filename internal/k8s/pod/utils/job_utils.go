@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"context"
+	"goflow/internal/logs"
+
+	batch "k8s.io/api/batch/v1"
+	core "k8s.io/api/core/v1"
+	k8sapi "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// JobNameLabel is the label that kubernetes puts on every pod created by a Job
+const JobNameLabel = "job-name"
+
+// jobCompletedReason is the reason given to a Job that completed without a condition reason
+const jobCompletedReason = "Completed"
+
+// jobCondition returns the condition of the given type if it is true for the Job
+func jobCondition(job *batch.Job, conditionType batch.JobConditionType) (batch.JobCondition, bool) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == conditionType && condition.Status == core.ConditionTrue {
+			return condition, true
+		}
+	}
+	return batch.JobCondition{}, false
+}
+
+// JobStatusPod returns a pod, named after the Job, whose phase and reason describe the status of
+// the Job as a whole. A Job is pending until one of its pods is active, running while any are
+// active, and succeeded or failed once its Complete or Failed condition is set.
+func JobStatusPod(job *batch.Job) *core.Pod {
+	status := core.PodStatus{Phase: core.PodPending, StartTime: job.Status.StartTime}
+	if condition, ok := jobCondition(job, batch.JobComplete); ok {
+		status.Phase = core.PodSucceeded
+		status.Reason = condition.Reason
+		if status.Reason == "" {
+			status.Reason = jobCompletedReason
+		}
+	} else if condition, ok := jobCondition(job, batch.JobFailed); ok {
+		status.Phase = core.PodFailed
+		status.Reason = condition.Reason
+		status.Message = condition.Message
+	} else if job.Status.Active > 0 {
+		status.Phase = core.PodRunning
+	}
+	return &core.Pod{
+		TypeMeta: k8sapi.TypeMeta{
+			Kind:       "Pod",
+			APIVersion: "v1",
+		},
+		ObjectMeta: k8sapi.ObjectMeta{
+			Name:        job.Name,
+			Namespace:   job.Namespace,
+			Labels:      job.Labels,
+			Annotations: job.Annotations,
+		},
+		Status: status,
+	}
+}
+
+// CleanUpJobs deletes all jobs, and the pods they created, currently present in the k8s cluster
+// in all namespaces that are accessible
+func CleanUpJobs(client kubernetes.Interface) {
+	namespaces := getNamespaces(client)
+	propagation := k8sapi.DeletePropagationBackground
+	for _, namespace := range namespaces {
+		jobsClient := client.BatchV1().Jobs(namespace)
+		jobList, err := jobsClient.List(
+			context.TODO(),
+			k8sapi.ListOptions{LabelSelector: getAppLabelSelectorString()},
+		)
+		if err != nil {
+			panic(err)
+		}
+		for _, job := range jobList.Items {
+			logs.With(logs.Fields{logs.NamespaceField: job.Namespace}).Infof(
+				"Deleting job \"%s\" in namespace \"%s\"",
+				job.Name,
+				job.Namespace,
+			)
+			jobsClient.Delete(
+				context.TODO(),
+				job.Name,
+				k8sapi.DeleteOptions{PropagationPolicy: &propagation},
+			)
+		}
+	}
+}
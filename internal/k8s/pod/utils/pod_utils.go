@@ -2,7 +2,9 @@ package utils
 
 import (
 	"context"
+	"fmt"
 	"goflow/internal/logs"
+	"hash/fnv"
 	"strings"
 
 	core "k8s.io/api/core/v1"
@@ -17,6 +19,10 @@ const AppSelectorKey = "App"
 // AppName is the name of the application
 const AppName = "goflow"
 
+// MaxNameLength is the longest a label value can be, which also limits the names of Jobs since
+// they are given to their pods as the job-name label
+const MaxNameLength = 63
+
 func getAppLabelSelectorString() string {
 	return LabelSelectorString(map[string]string{AppSelectorKey: AppName})
 }
@@ -50,8 +56,11 @@ func CleanUpPods(client kubernetes.Interface) {
 			panic(err)
 		}
 		for _, pod := range podList.Items {
-			logs.InfoLogger.Printf(
-				"Deleting pod \"%s\" in namespace \"%s\"\n",
+			logs.With(logs.Fields{
+				logs.PodField:       pod.Name,
+				logs.NamespaceField: pod.Namespace,
+			}).Infof(
+				"Deleting pod \"%s\" in namespace \"%s\"",
 				pod.Name,
 				pod.Namespace,
 			)
@@ -73,8 +82,8 @@ func CleanUpServiceAccounts(client kubernetes.Interface) {
 			panic(err)
 		}
 		for _, account := range serviceAccountList.Items {
-			logs.InfoLogger.Printf(
-				"Deleting service account \"%s\" in namespace \"%s\"\n",
+			logs.With(logs.Fields{logs.NamespaceField: namespace}).Infof(
+				"Deleting service account \"%s\" in namespace \"%s\"",
 				account.Name,
 				namespace,
 			)
@@ -85,7 +94,8 @@ func CleanUpServiceAccounts(client kubernetes.Interface) {
 
 // CleanUpEnvironment deletes all associated application resources
 func CleanUpEnvironment(client kubernetes.Interface) {
-	logs.InfoLogger.Println("Cleaning up...")
+	logs.Infof("Cleaning up...")
+	CleanUpJobs(client)
 	CleanUpPods(client)
 	CleanUpServiceAccounts(client)
 }
@@ -148,3 +158,16 @@ func CleanK8sName(name string) string {
 	name = strings.ToLower(name)
 	return name
 }
+
+// ShortenK8sName returns the name unchanged if it fits in MaxNameLength, otherwise it is truncated
+// and ended with a hash of the full name, so that distinct long names remain distinct
+func ShortenK8sName(name string) string {
+	if len(name) <= MaxNameLength {
+		return name
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(name))
+	suffix := fmt.Sprintf("-%08x", hash.Sum32())
+	prefix := strings.TrimRight(name[:MaxNameLength-len(suffix)], "-_.")
+	return prefix + suffix
+}
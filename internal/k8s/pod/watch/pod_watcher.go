@@ -6,9 +6,12 @@ import (
 	"encoding/json"
 
 	"goflow/internal/k8s/pod/event/holder"
+	"goflow/internal/k8s/pod/utils"
 	"goflow/internal/logs"
 	"io"
+	"io/ioutil"
 	"strings"
+	"sync"
 
 	core "k8s.io/api/core/v1"
 	k8sapi "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -30,6 +33,11 @@ type PodWatcher struct {
 	Phase          core.PodPhase
 	informerChans  *holder.ChannelHolder
 	monitoringDone chan struct{}
+	phaseHandler   func(core.PodPhase)
+	lastPod        *core.Pod
+	watchesJob     bool
+	logText        *strings.Builder // Everything sent to Logs, kept for LogText
+	logLock        *sync.Mutex
 }
 
 // NewPodWatcher returns a new pod watcher
@@ -48,9 +56,112 @@ func NewPodWatcher(
 		withLogs:       withLogs,
 		informerChans:  channelGroupHolder,
 		monitoringDone: make(chan struct{}, 1),
+		logText:        &strings.Builder{},
+		logLock:        &sync.Mutex{},
 	}
 }
 
+// log returns a log entry about the watched pod
+func (podWatcher *PodWatcher) log() logs.Entry {
+	return logs.With(logs.Fields{
+		logs.PodField:       podWatcher.podName,
+		logs.NamespaceField: podWatcher.namespace,
+	})
+}
+
+// SetPhaseHandler registers a function that is called every time the watcher sees the pod phase
+func (podWatcher *PodWatcher) SetPhaseHandler(handler func(core.PodPhase)) {
+	podWatcher.phaseHandler = handler
+}
+
+// WatchJob makes the watcher follow a kubernetes Job of the watcher's name instead of a pod. The
+// Job's status is received as a pod of the same name, while logs and exit codes are taken from the
+// most recent pod that the Job created.
+func (podWatcher *PodWatcher) WatchJob() {
+	podWatcher.watchesJob = true
+}
+
+// jobPod returns the most recently created pod of the watched Job, if there is one
+func (podWatcher *PodWatcher) jobPod() (*core.Pod, bool) {
+	podList, err := podWatcher.podClient().List(context.TODO(), k8sapi.ListOptions{
+		LabelSelector: utils.LabelSelectorString(
+			map[string]string{utils.JobNameLabel: podWatcher.podName},
+		),
+	})
+	if err != nil {
+		panic(err)
+	}
+	var latest *core.Pod
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if latest == nil || latest.CreationTimestamp.Before(&pod.CreationTimestamp) {
+			latest = pod
+		}
+	}
+	return latest, latest != nil
+}
+
+// logPodName returns the name of the pod whose logs are streamed
+func (podWatcher *PodWatcher) logPodName() string {
+	if podWatcher.watchesJob {
+		if pod, found := podWatcher.jobPod(); found {
+			return pod.Name
+		}
+	}
+	return podWatcher.podName
+}
+
+// setPod records the latest state seen for the pod and notifies the phase handler
+func (podWatcher *PodWatcher) setPod(pod *core.Pod) {
+	podWatcher.lastPod = pod
+	podWatcher.Phase = pod.Status.Phase
+	if podWatcher.phaseHandler != nil {
+		podWatcher.phaseHandler(pod.Status.Phase)
+	}
+}
+
+// Pod returns the latest state seen for the pod, or nil if the pod has not been seen yet
+func (podWatcher *PodWatcher) Pod() *core.Pod {
+	return podWatcher.lastPod
+}
+
+// Termination returns the exit code and reason of the pod's terminated container. If no container
+// has terminated, the exit code is 0 and the reason is taken from the pod status. When watching a
+// Job, the exit code is taken from the Job's most recent pod, while the reason given for the Job's
+// status, such as BackoffLimitExceeded, takes precedence over that of the container.
+func (podWatcher *PodWatcher) Termination() (exitCode int32, reason string) {
+	pod := podWatcher.lastPod
+	if pod == nil {
+		return
+	}
+	if podWatcher.watchesJob {
+		jobPod, found := podWatcher.jobPod()
+		if !found {
+			return 0, pod.Status.Reason
+		}
+		exitCode, reason = containerTermination(jobPod)
+		if pod.Status.Reason != "" {
+			reason = pod.Status.Reason
+		}
+		return exitCode, reason
+	}
+	return containerTermination(pod)
+}
+
+// containerTermination returns the exit code and reason of the pod's terminated container
+func containerTermination(pod *core.Pod) (exitCode int32, reason string) {
+	for _, status := range pod.Status.ContainerStatuses {
+		terminated := status.State.Terminated
+		if terminated == nil {
+			terminated = status.LastTerminationState.Terminated
+		}
+		if terminated != nil {
+			return terminated.ExitCode, terminated.Reason
+		}
+	}
+	return 0, pod.Status.Reason
+}
+
 // podClient returns the api endpoint for pods
 func (podWatcher *PodWatcher) podClient() v1.PodInterface {
 	return podWatcher.kubeClient.CoreV1().Pods(podWatcher.namespace)
@@ -72,19 +183,19 @@ func eventObjectToPod(result watch.Event) *core.Pod {
 
 // waitForPodAdded returns when the pod has been added
 func (podWatcher *PodWatcher) waitForPodAdded() {
-	logs.InfoLogger.Printf("Waiting for pod %s to be added...\n", podWatcher.podName)
+	podWatcher.log().Debugf("Waiting for pod %s to be added...", podWatcher.podName)
 	if !podWatcher.informerChans.Contains(podWatcher.podName) {
-		logs.ErrorLogger.Printf("Channels not found for pod %s\n", podWatcher.podName)
+		podWatcher.log().Errorf("Channels not found for pod %s", podWatcher.podName)
 	}
 	pod := <-podWatcher.informerChans.GetChannelGroup(podWatcher.podName).Ready
-	podWatcher.Phase = pod.Status.Phase
-	logs.InfoLogger.Printf("Pod %s added\n", podWatcher.podName)
+	podWatcher.setPod(pod)
+	podWatcher.log().Debugf("Pod %s added", podWatcher.podName)
 }
 
 func (podWatcher *PodWatcher) getLogStreamerWithOptions(
 	options *core.PodLogOptions,
 ) (io.ReadCloser, error) {
-	req := podWatcher.podClient().GetLogs(podWatcher.podName, options)
+	req := podWatcher.podClient().GetLogs(podWatcher.logPodName(), options)
 	return req.Stream(context.Background())
 }
 
@@ -95,7 +206,13 @@ func (podWatcher *PodWatcher) getLogsContainerNotFound() (io.ReadCloser, error)
 
 // getLogger returns when logs are ready to be received
 func (podWatcher *PodWatcher) getLogger() (io.ReadCloser, error) {
-	logs.InfoLogger.Printf("Retrieving logger for pod %s...\n", podWatcher.podName)
+	podWatcher.log().Debugf("Retrieving logger for pod %s...", podWatcher.podName)
+	if podWatcher.watchesJob {
+		if _, found := podWatcher.jobPod(); !found {
+			podWatcher.log().Debugf("No pods found for job %s", podWatcher.podName)
+			return ioutil.NopCloser(strings.NewReader("")), nil
+		}
+	}
 	var logStreamer io.ReadCloser
 	for {
 		streamer, err := podWatcher.getLogStreamerWithOptions(&core.PodLogOptions{})
@@ -105,8 +222,8 @@ func (podWatcher *PodWatcher) getLogger() (io.ReadCloser, error) {
 		}
 		errorText := err.Error()
 		if strings.Contains(errorText, "not found") {
-			logs.InfoLogger.Printf(
-				"Container not found for pod %s, handling...\n",
+			podWatcher.log().Debugf(
+				"Container not found for pod %s, handling...",
 				podWatcher.podName,
 			)
 			return podWatcher.getLogsContainerNotFound()
@@ -134,24 +251,22 @@ func (podWatcher *PodWatcher) callFuncUntilPodSucceedOrFail(callFunc func()) {
 	}
 	for {
 		callFunc()
-		logs.InfoLogger.Println("Waiting for pod update...")
+		podWatcher.log().Debugf("Waiting for pod update...")
 		pod, ok := <-podWatcher.informerChans.GetChannelGroup(podWatcher.podName).Update
 		if ok {
 			phase := pod.Status.Phase
-			logs.InfoLogger.Printf("Pod switched to phase %s\n", phase)
+			podWatcher.log().Debugf("Pod switched to phase %s", phase)
+			podWatcher.setPod(pod)
 			if phase == core.PodSucceeded || phase == core.PodFailed {
-				podWatcher.Phase = phase
 				break
 			}
 		}
 	}
 }
 
-func getStringFromLogger(
-	logger io.ReadCloser,
-	logChan chan string,
-	podName string,
-) (addedLogs bool) {
+// sendLogs sends the logs read from the logger to the Logs channel, returns false if there were
+// none
+func (podWatcher *PodWatcher) sendLogs(logger io.ReadCloser) (addedLogs bool) {
 	addedLogs = false
 	logBuffer := new(bytes.Buffer)
 	_, err := io.Copy(logBuffer, logger)
@@ -161,38 +276,49 @@ func getStringFromLogger(
 	logString := logBuffer.String()
 	if logString != "" {
 		addedLogs = true
-		logChan <- logString
+		podWatcher.logLock.Lock()
+		podWatcher.logText.WriteString(logString)
+		podWatcher.logLock.Unlock()
+		podWatcher.Logs <- logString
 	}
 	return
 }
 
+// LogText returns all of the logs that have been sent to the Logs channel, without taking them
+// from it
+func (podWatcher *PodWatcher) LogText() string {
+	podWatcher.logLock.Lock()
+	defer podWatcher.logLock.Unlock()
+	return podWatcher.logText.String()
+}
+
 func (podWatcher *PodWatcher) readLogsUntilSucceedOrFail(
 	logger io.ReadCloser,
 ) {
 	defer logger.Close()
 	addedLogs := false
 	podWatcher.callFuncUntilPodSucceedOrFail(func() {
-		if getStringFromLogger(logger, podWatcher.Logs, podWatcher.podName) {
+		if podWatcher.sendLogs(logger) {
 			addedLogs = true
 		}
 	})
 	if !addedLogs {
-		addedLogs = getStringFromLogger(logger, podWatcher.Logs, podWatcher.podName)
+		addedLogs = podWatcher.sendLogs(logger)
 		if !addedLogs {
-			logs.InfoLogger.Printf("No logs retrieved for pod %s\n", podWatcher.podName)
+			podWatcher.log().Debugf("No logs retrieved for pod %s", podWatcher.podName)
 		}
 	}
 }
 
 func (podWatcher *PodWatcher) setMonitorDone() {
-	logs.InfoLogger.Printf("Monitoring for pod %s done", podWatcher.podName)
+	podWatcher.log().Debugf("Monitoring for pod %s done", podWatcher.podName)
 	podWatcher.monitoringDone <- struct{}{}
 }
 
 // MonitorPod collects pod logs until the pod terminates
 func (podWatcher *PodWatcher) MonitorPod() {
 	defer podWatcher.setMonitorDone()
-	logs.InfoLogger.Printf("Beginning to monitor pod %s\n", podWatcher.podName)
+	podWatcher.log().Debugf("Beginning to monitor pod %s", podWatcher.podName)
 	podWatcher.waitForPodAdded()
 	logger, err := podWatcher.getLogger()
 	if err != nil {
@@ -203,6 +329,6 @@ func (podWatcher *PodWatcher) MonitorPod() {
 
 // WaitForMonitorDone returns when the watcher is done monitoring
 func (podWatcher *PodWatcher) WaitForMonitorDone() {
-	logs.InfoLogger.Printf("Waiting for pod %s to be done\n", podWatcher.podName)
+	podWatcher.log().Debugf("Waiting for pod %s to be done", podWatcher.podName)
 	<-podWatcher.monitoringDone
 }
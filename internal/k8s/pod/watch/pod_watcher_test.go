@@ -7,6 +7,7 @@ import (
 	"goflow/internal/logs"
 	"goflow/internal/testutils"
 	"io"
+	"io/ioutil"
 	"os/exec"
 	"strings"
 	"testing"
@@ -35,6 +36,10 @@ func TestCallFuncUntilSucceedOrFail(t *testing.T) {
 			podName := "test-pod-succeed-or-fail"
 			holder := holder.New()
 			podWatcher := NewPodWatcher(podName, namespace, client, true, holder)
+			handledPhases := make([]core.PodPhase, 0)
+			podWatcher.SetPhaseHandler(func(phase core.PodPhase) {
+				handledPhases = append(handledPhases, phase)
+			})
 			podsClient, _ := testutils.GetPodClientWithTestWatcher(client, namespace)
 			testPod := podutils.CreateTestPod(podsClient, podName, namespace, "")
 			t.Log("Test pod created")
@@ -45,7 +50,7 @@ func TestCallFuncUntilSucceedOrFail(t *testing.T) {
 			holder.GetChannelGroup(podName).Update <- updatedPod
 
 			podWatcher.callFuncUntilPodSucceedOrFail(func() {
-				logs.InfoLogger.Println("I'm waiting...")
+				logs.Infof("I'm waiting...")
 			})
 
 			if podWatcher.Phase != testCase.finalPhase {
@@ -55,11 +60,79 @@ func TestCallFuncUntilSucceedOrFail(t *testing.T) {
 					podWatcher.Phase,
 				)
 			}
+			if len(handledPhases) != 1 || handledPhases[0] != testCase.finalPhase {
+				t.Errorf(
+					"Expected phase handler to receive phase %s, received %v",
+					testCase.finalPhase,
+					handledPhases,
+				)
+			}
 		}()
 	}
 
 }
 
+func TestTermination(t *testing.T) {
+	table := []struct {
+		name             string
+		status           core.PodStatus
+		expectedExitCode int32
+		expectedReason   string
+	}{
+		{"Not Terminated", core.PodStatus{Phase: core.PodRunning}, 0, ""},
+		{
+			"Container Terminated",
+			core.PodStatus{
+				Phase: core.PodFailed,
+				ContainerStatuses: []core.ContainerStatus{{
+					State: core.ContainerState{
+						Terminated: &core.ContainerStateTerminated{ExitCode: 2, Reason: "Error"},
+					},
+				}},
+			},
+			2,
+			"Error",
+		},
+		{
+			"Container Restarted",
+			core.PodStatus{
+				Phase: core.PodFailed,
+				ContainerStatuses: []core.ContainerStatus{{
+					LastTerminationState: core.ContainerState{
+						Terminated: &core.ContainerStateTerminated{
+							ExitCode: 137,
+							Reason:   "OOMKilled",
+						},
+					},
+				}},
+			},
+			137,
+			"OOMKilled",
+		},
+		{
+			"Pod Deadline Exceeded",
+			core.PodStatus{Phase: core.PodFailed, Reason: "DeadlineExceeded"},
+			0,
+			"DeadlineExceeded",
+		},
+	}
+	for _, testCase := range table {
+		podWatcher := NewPodWatcher("test-pod-termination", "default", nil, false, holder.New())
+		podWatcher.setPod(&core.Pod{Status: testCase.status})
+		exitCode, reason := podWatcher.Termination()
+		if exitCode != testCase.expectedExitCode || reason != testCase.expectedReason {
+			t.Errorf(
+				"%s: expected exit code %d and reason \"%s\", got %d and \"%s\"",
+				testCase.name,
+				testCase.expectedExitCode,
+				testCase.expectedReason,
+				exitCode,
+				reason,
+			)
+		}
+	}
+}
+
 func TestGetLogsAfterPodDone(t *testing.T) {
 	table := []struct {
 		finalPhase core.PodPhase
@@ -83,7 +156,7 @@ func TestGetLogsAfterPodDone(t *testing.T) {
 			watcher.informerChans.GetChannelGroup(podName).Update <- podCopy
 
 			watcher.callFuncUntilPodSucceedOrFail(func() {
-				logs.InfoLogger.Println("Waiting for pod done...")
+				logs.Infof("Waiting for pod done...")
 			})
 
 			logger, err := watcher.getLogger()
@@ -111,3 +184,21 @@ func TestGetLogsAfterPodDone(t *testing.T) {
 	}
 
 }
+
+func TestSendLogs(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	watcher := NewPodWatcher("test-pod-send-logs", "default", client, true, holder.New())
+	if watcher.sendLogs(ioutil.NopCloser(strings.NewReader(""))) {
+		t.Error("Empty logs should not be sent")
+	}
+	if !watcher.sendLogs(ioutil.NopCloser(strings.NewReader("first\n"))) {
+		t.Error("Expected logs to be sent")
+	}
+	if sent := <-watcher.Logs; sent != "first\n" {
+		t.Errorf("Expected logs %q to be sent, found %q", "first\n", sent)
+	}
+	watcher.sendLogs(ioutil.NopCloser(strings.NewReader("second\n")))
+	if watcher.LogText() != "first\nsecond\n" {
+		t.Errorf("Expected log text to hold all sent logs, found %q", watcher.LogText())
+	}
+}
@@ -1,28 +1,234 @@
+// Package logs writes goflow's logs as JSON, one object per line, so that they can be indexed by
+// a log aggregator. Each entry has a time, level, message and caller, along with any fields that
+// identify what it is about, such as the DAG, run, pod and namespace:
+//
+//	logs.With(logs.Fields{logs.DAGField: dagName}).Infof("Starting run %s", runName)
 package logs
 
 import (
-	"log"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 )
 
-var (
-	// WarningLogger is the logger for warnings
-	WarningLogger *log.Logger
+// Level is the severity of a log entry
+type Level int
 
-	// InfoLogger is the logger for info
-	InfoLogger *log.Logger
+// Levels of log entries, from the least to the most severe
+const (
+	DebugLevel Level = iota
+	InfoLevel
+	WarningLevel
+	ErrorLevel
+)
+
+// levelNames are the names of the levels, as they are logged and set in the goflow config
+var levelNames = []string{"debug", "info", "warning", "error"}
+
+func (level Level) String() string {
+	return levelNames[level]
+}
+
+// ParseLevel returns the level with the given name, which is the info level if the name is empty
+func ParseLevel(name string) (Level, error) {
+	if name == "" {
+		return InfoLevel, nil
+	}
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return Level(level), nil
+		}
+	}
+	return InfoLevel, fmt.Errorf(
+		"log level must be one of %s, not \"%s\"",
+		strings.Join(levelNames, ", "),
+		name,
+	)
+}
 
-	// ErrorLogger is the logger for errors
-	ErrorLogger *log.Logger
+// Names of the fields that identify what a log entry is about
+const (
+	DAGField       = "dag"
+	RunField       = "run"
+	TaskField      = "task"
+	PodField       = "pod"
+	NamespaceField = "namespace"
+	FileField      = "file"
+	ErrorField     = "error"
 )
 
-func init() {
-	_, err := os.OpenFile("logs.txt", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+// Fields are the fields of a log entry beyond its time, level, message and caller
+type Fields map[string]interface{}
+
+// sink is where log entries at or above its level are written
+type sink struct {
+	lock   *sync.Mutex
+	level  Level
+	output io.Writer
+}
+
+var logSink = sink{lock: &sync.Mutex{}, level: InfoLevel, output: os.Stdout}
+
+// SetLevel sets the lowest level of the entries that are logged
+func SetLevel(level Level) {
+	logSink.lock.Lock()
+	defer logSink.lock.Unlock()
+	logSink.level = level
+}
+
+// SetOutput sets where log entries are written, closing the log file if there was one
+func SetOutput(output io.Writer) {
+	logSink.lock.Lock()
+	defer logSink.lock.Unlock()
+	if file, ok := logSink.output.(*rotatingFile); ok {
+		file.Close()
+	}
+	logSink.output = output
+}
+
+// Options set how goflow logs, as given by the goflow config
+type Options struct {
+	Level      string // Name of the lowest level that is logged, info by default
+	File       string // File that logs are written to instead of standard output
+	MaxSize    int    // Megabytes that the file may reach before it is rotated
+	MaxBackups int    // Number of rotated files that are kept
+}
+
+// Configure sets the level and output of the logs as the options give
+func Configure(options Options) error {
+	level, err := ParseLevel(options.Level)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	var output io.Writer = os.Stdout
+	if options.File != "" {
+		output, err = openRotatingFile(options.File, options.MaxSize, options.MaxBackups)
+		if err != nil {
+			return err
+		}
+	}
+	SetOutput(output)
+	SetLevel(level)
+	return nil
+}
+
+// Entry is a log entry that has fields set, whose methods log it at each level
+type Entry struct {
+	fields Fields
+}
+
+// With returns an entry with the given fields
+func With(fields Fields) Entry {
+	return Entry{}.With(fields)
+}
+
+// With returns a copy of the entry with the given fields added
+func (entry Entry) With(fields Fields) Entry {
+	merged := make(Fields, len(entry.fields)+len(fields))
+	for key, value := range entry.fields {
+		merged[key] = value
 	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+	return Entry{merged}
+}
+
+// Debugf logs the entry with the formatted message at the debug level
+func (entry Entry) Debugf(format string, args ...interface{}) {
+	entry.logf(DebugLevel, format, args...)
+}
+
+// Infof logs the entry with the formatted message at the info level
+func (entry Entry) Infof(format string, args ...interface{}) {
+	entry.logf(InfoLevel, format, args...)
+}
+
+// Warningf logs the entry with the formatted message at the warning level
+func (entry Entry) Warningf(format string, args ...interface{}) {
+	entry.logf(WarningLevel, format, args...)
+}
+
+// Errorf logs the entry with the formatted message at the error level
+func (entry Entry) Errorf(format string, args ...interface{}) {
+	entry.logf(ErrorLevel, format, args...)
+}
+
+// Debugf logs the formatted message at the debug level
+func Debugf(format string, args ...interface{}) {
+	Entry{}.logf(DebugLevel, format, args...)
+}
+
+// Infof logs the formatted message at the info level
+func Infof(format string, args ...interface{}) {
+	Entry{}.logf(InfoLevel, format, args...)
+}
 
-	InfoLogger = log.New(os.Stdout, "INFO: ", log.Ldate|log.Ltime|log.Lshortfile)
-	WarningLogger = log.New(os.Stdout, "WARNING: ", log.Ldate|log.Ltime|log.Lshortfile)
-	ErrorLogger = log.New(os.Stdout, "ERROR: ", log.Ldate|log.Ltime|log.Lshortfile)
+// Warningf logs the formatted message at the warning level
+func Warningf(format string, args ...interface{}) {
+	Entry{}.logf(WarningLevel, format, args...)
+}
+
+// Errorf logs the formatted message at the error level
+func Errorf(format string, args ...interface{}) {
+	Entry{}.logf(ErrorLevel, format, args...)
+}
+
+// logf writes the entry as a line of JSON if its level is logged. It must be called directly by
+// the method that logs at the level, so that the caller that is logged is the caller of that
+// method.
+func (entry Entry) logf(level Level, format string, args ...interface{}) {
+	logSink.lock.Lock()
+	defer logSink.lock.Unlock()
+	if level < logSink.level {
+		return
+	}
+	caller := ""
+	if _, file, line, ok := runtime.Caller(2); ok {
+		caller = fmt.Sprintf("%s:%d", filepath.Base(file), line)
+	}
+	line := &bytes.Buffer{}
+	line.WriteString("{")
+	writeField(line, "time", time.Now().UTC().Format(time.RFC3339Nano))
+	line.WriteString(",")
+	writeField(line, "level", level.String())
+	line.WriteString(",")
+	writeField(line, "msg", strings.TrimSuffix(fmt.Sprintf(format, args...), "\n"))
+	line.WriteString(",")
+	writeField(line, "caller", caller)
+	keys := make([]string, 0, len(entry.fields))
+	for key := range entry.fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		line.WriteString(",")
+		writeField(line, key, entry.fields[key])
+	}
+	line.WriteString("}\n")
+	logSink.output.Write(line.Bytes())
+}
+
+// writeField writes a key and its value to the JSON object of a log entry. Errors are written as
+// their message, and values that cannot be written as JSON are written as they are printed.
+func writeField(line *bytes.Buffer, key string, value interface{}) {
+	if err, ok := value.(error); ok {
+		value = err.Error()
+	}
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		valueBytes, _ = json.Marshal(fmt.Sprint(value))
+	}
+	keyBytes, _ := json.Marshal(key)
+	line.Write(keyBytes)
+	line.WriteString(":")
+	line.Write(valueBytes)
 }
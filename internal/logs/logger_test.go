@@ -0,0 +1,160 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// captureLogs writes the logs at or above the level to the returned buffer until the test ends
+func captureLogs(t *testing.T, level Level) *bytes.Buffer {
+	output := &bytes.Buffer{}
+	SetOutput(output)
+	SetLevel(level)
+	t.Cleanup(func() {
+		SetOutput(os.Stdout)
+		SetLevel(InfoLevel)
+	})
+	return output
+}
+
+// readEntries returns the JSON objects of the entries written to the output
+func readEntries(t *testing.T, output *bytes.Buffer) []map[string]interface{} {
+	entries := make([]map[string]interface{}, 0)
+	for _, line := range strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		entry := make(map[string]interface{})
+		err := json.Unmarshal([]byte(line), &entry)
+		if err != nil {
+			t.Fatalf("Expected each line to be a JSON object, found %s", line)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestEntryFields(t *testing.T) {
+	output := captureLogs(t, InfoLevel)
+
+	dagLog := With(Fields{DAGField: "etl", RunField: "etl-run"})
+	dagLog.With(Fields{PodField: "etl-run-extract"}).Infof("Task %s started\n", "extract")
+	dagLog.Errorf("Run failed")
+	Warningf("Could not read %s", "dags")
+	With(Fields{ErrorField: errors.New("no such file")}).Errorf("Import failed")
+
+	entries := readEntries(t, output)
+	if len(entries) != 4 {
+		t.Fatalf("Expected 4 entries, found %d:\n%s", len(entries), output.String())
+	}
+	first := entries[0]
+	expectedFields := map[string]interface{}{
+		"level": "info",
+		"msg":   "Task extract started",
+		"dag":   "etl",
+		"run":   "etl-run",
+		"pod":   "etl-run-extract",
+	}
+	for key, expected := range expectedFields {
+		if first[key] != expected {
+			t.Errorf("Expected %s to be %v, found %v", key, expected, first[key])
+		}
+	}
+	if !strings.HasPrefix(first["caller"].(string), "logger_test.go:") {
+		t.Errorf("Expected the caller to be the test, found %v", first["caller"])
+	}
+	if _, ok := first["time"]; !ok {
+		t.Error("Expected the entry to have a time")
+	}
+	if _, ok := entries[1]["pod"]; ok {
+		t.Error("Expected fields added to a copy of an entry not to be added to the entry")
+	}
+	if entries[2]["level"] != "warning" || entries[2]["msg"] != "Could not read dags" {
+		t.Errorf("Expected a warning without fields, found %v", entries[2])
+	}
+	if entries[3]["error"] != "no such file" {
+		t.Errorf("Expected the error to be logged as its message, found %v", entries[3]["error"])
+	}
+}
+
+func TestLevels(t *testing.T) {
+	output := captureLogs(t, WarningLevel)
+
+	Debugf("debug")
+	Infof("info")
+	Warningf("warning")
+	Errorf("error")
+
+	entries := readEntries(t, output)
+	if len(entries) != 2 || entries[0]["msg"] != "warning" || entries[1]["msg"] != "error" {
+		t.Errorf("Expected only the warning and error to be logged, found\n%s", output.String())
+	}
+
+	output.Reset()
+	SetLevel(DebugLevel)
+	Debugf("debug")
+	if entries := readEntries(t, output); len(entries) != 1 || entries[0]["level"] != "debug" {
+		t.Errorf("Expected the debug entry to be logged, found\n%s", output.String())
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected Level
+	}{
+		{"", InfoLevel},
+		{"debug", DebugLevel},
+		{"INFO", InfoLevel},
+		{"warning", WarningLevel},
+		{"Error", ErrorLevel},
+	}
+	for _, testCase := range testCases {
+		level, err := ParseLevel(testCase.name)
+		if err != nil {
+			t.Errorf("Expected %q to be parsed, found error %s", testCase.name, err)
+		}
+		if level != testCase.expected {
+			t.Errorf("Expected %q to be %s, found %s", testCase.name, testCase.expected, level)
+		}
+	}
+
+	_, err := ParseLevel("verbose")
+	if err == nil {
+		t.Error("Expected an error for a level that does not exist")
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	t.Cleanup(func() {
+		SetOutput(os.Stdout)
+		SetLevel(InfoLevel)
+	})
+	path := filepath.Join(t.TempDir(), "goflow.log")
+
+	err := Configure(Options{Level: "debug", File: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	Debugf("written to the file")
+	SetOutput(os.Stdout)
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "\"msg\":\"written to the file\"") {
+		t.Errorf("Expected the entry to be written to the file, found\n%s", content)
+	}
+
+	err = Configure(Options{Level: "verbose"})
+	if err == nil {
+		t.Error("Expected an error for a level that does not exist")
+	}
+}
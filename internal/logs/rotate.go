@@ -0,0 +1,104 @@
+package logs
+
+import (
+	"fmt"
+	"os"
+)
+
+// Defaults for when the goflow config does not say when log files are rotated
+const (
+	defaultMaxSize    = 100 // Megabytes
+	defaultMaxBackups = 5
+)
+
+// rotatingFile is a log file that is rotated before a write would make it larger than its
+// maximum size. The file is renamed to path.1, path.1 to path.2 and so on, keeping only
+// maxBackups of the renamed files. It is only written to while the sink is locked.
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// openRotatingFile opens the log file at the path for appending, creating it if it does not
+// exist. Sizes and backups that are not given are set to the defaults.
+func openRotatingFile(path string, maxSizeMegabytes int, maxBackups int) (*rotatingFile, error) {
+	if maxSizeMegabytes <= 0 {
+		maxSizeMegabytes = defaultMaxSize
+	}
+	if maxBackups <= 0 {
+		maxBackups = defaultMaxBackups
+	}
+	file := &rotatingFile{
+		path:       path,
+		maxSize:    int64(maxSizeMegabytes) * 1024 * 1024,
+		maxBackups: maxBackups,
+	}
+	err := file.open()
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// open opens the file at the path for appending, and records its size
+func (file *rotatingFile) open() error {
+	opened, err := os.OpenFile(file.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := opened.Stat()
+	if err != nil {
+		opened.Close()
+		return err
+	}
+	file.file = opened
+	file.size = info.Size()
+	return nil
+}
+
+// backupPath returns the path of the nth most recently rotated file
+func (file *rotatingFile) backupPath(n int) string {
+	return fmt.Sprintf("%s.%d", file.path, n)
+}
+
+// rotate renames the file and the files rotated before it, removing the oldest, and opens a new
+// file at the path
+func (file *rotatingFile) rotate() error {
+	err := file.file.Close()
+	if err != nil {
+		return err
+	}
+	os.Remove(file.backupPath(file.maxBackups))
+	for n := file.maxBackups - 1; n >= 1; n-- {
+		os.Rename(file.backupPath(n), file.backupPath(n+1))
+	}
+	renameErr := os.Rename(file.path, file.backupPath(1))
+	// The file is opened again even if it could not be renamed, so that logging carries on
+	err = file.open()
+	if renameErr != nil {
+		return renameErr
+	}
+	return err
+}
+
+// Write writes to the file, rotating it first if the write would make it too large. A write
+// that is larger than the maximum size on its own is written to an empty file.
+func (file *rotatingFile) Write(p []byte) (int, error) {
+	if file.size > 0 && file.size+int64(len(p)) > file.maxSize {
+		err := file.rotate()
+		if err != nil {
+			return 0, err
+		}
+	}
+	n, err := file.file.Write(p)
+	file.size += int64(n)
+	return n, err
+}
+
+// Close closes the file
+func (file *rotatingFile) Close() error {
+	return file.file.Close()
+}
@@ -0,0 +1,43 @@
+package logs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "goflow.log")
+	file, err := openRotatingFile(path, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	file.maxSize = 10
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err = file.Write([]byte(line))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expectedFiles := map[string]string{
+		path:               "fourth\n",
+		file.backupPath(1): "third\n",
+		file.backupPath(2): "second\n",
+	}
+	for filePath, expected := range expectedFiles {
+		content, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != expected {
+			t.Errorf("Expected %s to contain %q, found %q", filePath, expected, content)
+		}
+	}
+	if _, err := os.Stat(file.backupPath(3)); !os.IsNotExist(err) {
+		t.Errorf("Expected only 2 rotated files to be kept")
+	}
+}
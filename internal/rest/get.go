@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"goflow/internal/dag/dagtype"
 	"goflow/internal/dag/orchestrator"
+	"goflow/internal/jsonpanic"
 	"net/http"
 	"sort"
 
@@ -11,6 +12,16 @@ import (
 )
 
 const missingDagMsg = "\"There is no DAG with given name\""
+const missingRunMsg = "\"The DAG has no run with given name\""
+
+// TaskLogs holds the logs of the latest attempt of one task of a DAG run. The logs are empty
+// unless the DAG keeps its logs with WithLogs.
+type TaskLogs struct {
+	Task    string
+	Attempt int
+	State   string
+	Logs    string
+}
 
 func getDAGNameFromRequest(orch *orchestrator.Orchestrator,
 	w http.ResponseWriter,
@@ -59,7 +70,38 @@ func registerGetHandles(orch *orchestrator.Orchestrator, router *mux.Router) {
 			return
 		}
 		fmt.Fprint(w, dag.DAGRuns)
-	})
+	}).Methods(http.MethodGet)
+
+	router.HandleFunc(
+		"/dag/{name}/runs/{run}/logs",
+		func(w http.ResponseWriter, r *http.Request) {
+			dag := getDagFromRequest(orch, w, r)
+			if dag == nil {
+				return
+			}
+			run := dag.GetRun(mux.Vars(r)["run"])
+			if run == nil {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, missingRunMsg)
+				return
+			}
+			taskLogs := make([]TaskLogs, 0, len(run.Tasks))
+			for _, task := range run.Tasks {
+				taskLogs = append(taskLogs, TaskLogs{
+					Task:    task.Name,
+					Attempt: task.Attempt,
+					State:   string(task.GetState()),
+					Logs:    task.LogText(),
+				})
+			}
+			fmt.Fprint(w, jsonpanic.JSONPanicFormat(taskLogs))
+		},
+	).Methods(http.MethodGet)
+
+	router.HandleFunc("/import-errors", func(w http.ResponseWriter, r *http.Request) {
+		setHeaders(w)
+		fmt.Fprint(w, orch.ImportErrors())
+	}).Methods(http.MethodGet)
 
 	router.HandleFunc("/dag/{name}/metrics", func(w http.ResponseWriter, r *http.Request) {
 		dagName := getDAGNameFromRequest(orch, w, r)
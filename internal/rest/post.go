@@ -5,12 +5,99 @@ import (
 	"fmt"
 	dagconfig "goflow/internal/dag/config"
 	"goflow/internal/dag/orchestrator"
+	dagrun "goflow/internal/dag/run"
+	"goflow/internal/dateutils"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
+// BackfillRequest is the body of a request to backfill a DAG. Dates may be given either as
+// YYYY-MM-DD, which is read in the DAG's time zone, or in RFC3339 format.
+type BackfillRequest struct {
+	Start string
+	End   string
+}
+
+// parseRequestDate parses a date given in a request, which is taken to be in the given location
+// unless it is an RFC3339 timestamp
+func parseRequestDate(date string, location *time.Location) (time.Time, error) {
+	return dateutils.ParseDateTime(date, location)
+}
+
+// dagLocation returns the time zone of the named DAG, in which the dates of requests about it are
+// read. UTC is returned for a DAG that does not exist, which the orchestrator reports.
+func dagLocation(orch *orchestrator.Orchestrator, dagName string) *time.Location {
+	dag := orch.GetDag(dagName)
+	if dag == nil || dag.Location() == nil {
+		return time.UTC
+	}
+	return dag.Location()
+}
+
+// Dates returns the start and end dates of the backfill in the given location
+func (request BackfillRequest) Dates(
+	location *time.Location,
+) (start time.Time, end time.Time, err error) {
+	start, err = parseRequestDate(request.Start, location)
+	if err != nil {
+		return
+	}
+	end, err = parseRequestDate(request.End, location)
+	return
+}
+
+// envNamePattern matches the names that may be given to environment variables
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// TriggerRequest is the body of a request to manually run a DAG. ExecutionDate is the logical date
+// of the run and defaults to the current time. Each key of Conf is passed to the run's pods as an
+// environment variable, with values that are not strings given as JSON.
+type TriggerRequest struct {
+	ExecutionDate string
+	Conf          map[string]interface{}
+}
+
+// Date returns the execution date of the run in the given location
+func (request TriggerRequest) Date(location *time.Location) (time.Time, error) {
+	if request.ExecutionDate == "" {
+		return dateutils.GetDateTimeNowMilliSecond(), nil
+	}
+	return parseRequestDate(request.ExecutionDate, location)
+}
+
+// Env returns the environment variables given by the conf of the request
+func (request TriggerRequest) Env() (map[string]string, error) {
+	env := make(map[string]string, len(request.Conf))
+	for name, value := range request.Conf {
+		if !envNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("conf key \"%s\" is not a valid environment variable name", name)
+		}
+		if strings.HasPrefix(name, dagrun.ReservedEnvPrefix) {
+			return nil, fmt.Errorf(
+				"conf key \"%s\" may not start with %s, which is reserved for the run's context",
+				name,
+				dagrun.ReservedEnvPrefix,
+			)
+		}
+		if str, ok := value.(string); ok {
+			env[name] = str
+			continue
+		}
+		valueBytes, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		env[name] = string(valueBytes)
+	}
+	return env, nil
+}
+
 func registerPostHandles(orch *orchestrator.Orchestrator, router *mux.Router) {
 	router.HandleFunc("/dag", func(w http.ResponseWriter, r *http.Request) {
 		dagConfig := &dagconfig.DAGConfig{}
@@ -29,4 +116,58 @@ func registerPostHandles(orch *orchestrator.Orchestrator, router *mux.Router) {
 		}
 		fmt.Fprint(w, "DAG write success")
 	}).Methods(http.MethodPost)
+
+	router.HandleFunc("/dag/{name}/backfill", func(w http.ResponseWriter, r *http.Request) {
+		setHeaders(w)
+		backfillRequest := BackfillRequest{}
+		err := json.NewDecoder(r.Body).Decode(&backfillRequest)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, err.Error())
+			return
+		}
+		start, end, err := backfillRequest.Dates(dagLocation(orch, mux.Vars(r)["name"]))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, err.Error())
+			return
+		}
+		executionDates, status, err := orch.Backfill(mux.Vars(r)["name"], start, end)
+		if err != nil {
+			w.WriteHeader(status)
+			fmt.Fprint(w, err.Error())
+			return
+		}
+		json.NewEncoder(w).Encode(executionDates)
+	}).Methods(http.MethodPost)
+	router.HandleFunc("/dag/{name}/runs", func(w http.ResponseWriter, r *http.Request) {
+		setHeaders(w)
+		triggerRequest := TriggerRequest{}
+		err := json.NewDecoder(r.Body).Decode(&triggerRequest)
+		if err != nil && err != io.EOF {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, err.Error())
+			return
+		}
+		executionDate, err := triggerRequest.Date(dagLocation(orch, mux.Vars(r)["name"]))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, err.Error())
+			return
+		}
+		env, err := triggerRequest.Env()
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, err.Error())
+			return
+		}
+		dagRun, status, err := orch.Trigger(mux.Vars(r)["name"], executionDate, env)
+		if err != nil {
+			w.WriteHeader(status)
+			fmt.Fprint(w, err.Error())
+			return
+		}
+		w.WriteHeader(status)
+		fmt.Fprint(w, dagRun)
+	}).Methods(http.MethodPost)
 }
@@ -29,4 +29,30 @@ func registerPutHandles(orch *orchestrator.Orchestrator, router *mux.Router) {
 	).Methods(
 		http.MethodPut,
 	)
+
+	registerOnOffHandle(orch, router, "/dag/{name}/pause", false)
+	registerOnOffHandle(orch, router, "/dag/{name}/unpause", true)
+}
+
+// registerOnOffHandle registers a handler at the path that switches the named DAG on or off,
+// leaving it as it is if it is already in that state
+func registerOnOffHandle(
+	orch *orchestrator.Orchestrator,
+	router *mux.Router,
+	path string,
+	isOn bool,
+) {
+	router.HandleFunc(
+		path,
+		func(w http.ResponseWriter, r *http.Request) {
+			dag := getDagFromRequest(orch, w, r)
+			if dag == nil {
+				return
+			}
+			dag.SetOnOff(isOn)
+			fmt.Fprintf(w, "%t", dag.IsOn)
+		},
+	).Methods(
+		http.MethodPut,
+	)
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"goflow/internal/config"
+	"goflow/internal/dag/activeruns"
 	dagconfig "goflow/internal/dag/config"
 	"goflow/internal/dag/dagtype"
 	"goflow/internal/dag/metrics"
@@ -11,6 +12,8 @@ import (
 	dagrun "goflow/internal/dag/run"
 	dagtable "goflow/internal/dag/sql/dag"
 	dagruntable "goflow/internal/dag/sql/dagrun"
+	importerrortable "goflow/internal/dag/sql/importerror"
+	taskinstancetable "goflow/internal/dag/sql/taskinstance"
 	"goflow/internal/database"
 	"goflow/internal/testutils"
 	"io/ioutil"
@@ -63,12 +66,28 @@ func TestMain(m *testing.M) {
 	dagRunTableClient := dagruntable.NewTableClient(SQLCLIENT)
 	dagTableClient.CreateTable()
 	dagRunTableClient.CreateTable()
+	taskTableClient := taskinstancetable.NewTableClient(SQLCLIENT)
+	taskTableClient.CreateTable()
+	importerrortable.NewTableClient(SQLCLIENT).CreateTable()
 	kubeClient := fake.NewSimpleClientset()
-	testDag = dagtype.CreateDAG(&dagconfig.DAGConfig{
+	var err error
+	testDag, err = dagtype.CreateDAG(&dagconfig.DAGConfig{
 		Name:          "test",
 		StartDateTime: "2019-01-01",
 		MaxActiveRuns: 1,
-	}, "", kubeClient, dagtype.ScheduleCache{}, dagTableClient, "", dagRunTableClient, false)
+	},
+		"",
+		kubeClient,
+		dagtype.ScheduleCache{},
+		dagTableClient,
+		"",
+		dagRunTableClient,
+		taskTableClient,
+		false,
+	)
+	if err != nil {
+		panic(err)
+	}
 	testTime = time.Now()
 	orch.AddDAG(&testDag)
 	testDAG2 := copyDAG(testDag)
@@ -170,6 +189,19 @@ func TestGetMissingDag(t *testing.T) {
 	errorCodeResponse(t, http.StatusNotFound, resp.StatusCode)
 }
 
+func TestGetImportErrors(t *testing.T) {
+	resp := get("import-errors")
+	errorCodeResponse(t, http.StatusOK, resp.StatusCode)
+	importErrors := make(importerrortable.RowList, 0)
+	err := json.Unmarshal(readRespBytes(resp), &importErrors)
+	if err != nil {
+		panic(err)
+	}
+	if len(importErrors) != 0 {
+		t.Errorf("Expected no import errors, found %s", importErrors)
+	}
+}
+
 func TestGetDagRuns(t *testing.T) {
 	resp := get(fmt.Sprintf("dag/%s/runs", testDag.Config.Name))
 	bodyBytes := readRespBytes(resp)
@@ -253,3 +285,164 @@ func TestToggleDag(t *testing.T) {
 		t.Error("DAG should be off!")
 	}
 }
+
+func TestPauseUnpauseDag(t *testing.T) {
+	orch.AddDAG(&testDag)
+	pausePath := fmt.Sprintf("dag/%s/pause", testDag.Config.Name)
+	unpausePath := fmt.Sprintf("dag/%s/unpause", testDag.Config.Name)
+	for _, path := range []string{unpausePath, unpausePath} {
+		resp := put(path)
+		errorCodeResponse(t, http.StatusOK, resp.StatusCode)
+		if !testDag.IsOn {
+			t.Error("DAG should be on!")
+		}
+	}
+	for _, path := range []string{pausePath, pausePath} {
+		put(path)
+		if testDag.IsOn {
+			t.Error("DAG should be off!")
+		}
+	}
+	resp := put("dag/fake_dag/pause")
+	errorCodeResponse(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestGetRunLogs(t *testing.T) {
+	logsDAG := copyDAG(testDag)
+	logsDAG.Config = &dagconfig.DAGConfig{
+		Name:          "test-logs",
+		Namespace:     "default",
+		Schedule:      "0 0 0 * * *",
+		MaxActiveRuns: 1,
+		StartDateTime: "2019-01-01",
+		Tasks:         []dagconfig.TaskConfig{{Name: "first"}, {Name: "second"}},
+	}
+	logsDAG.ActiveRuns = activeruns.New()
+	logsDAG.DAGRuns = nil
+	orch.AddDAG(&logsDAG)
+	run := logsDAG.AddDagRun(testTime, false, nil)
+
+	resp := get(fmt.Sprintf("dag/%s/runs/%s/logs", logsDAG.Config.Name, run.Name))
+	errorCodeResponse(t, http.StatusOK, resp.StatusCode)
+	taskLogs := make([]TaskLogs, 0)
+	err := json.Unmarshal(readRespBytes(resp), &taskLogs)
+	if err != nil {
+		panic(err)
+	}
+	if len(taskLogs) != 2 || taskLogs[0].Task != "first" || taskLogs[0].Logs != "" {
+		t.Errorf("Expected empty logs of tasks first and second, found %v", taskLogs)
+	}
+
+	resp = get(fmt.Sprintf("dag/%s/runs/fake-run/logs", logsDAG.Config.Name))
+	errorCodeResponse(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestBackfillDag(t *testing.T) {
+	backfillDAG := copyDAG(testDag)
+	backfillDAG.Config = &dagconfig.DAGConfig{
+		Name:          "test-backfill",
+		Namespace:     "default",
+		Schedule:      "0 0 0 * * *",
+		MaxActiveRuns: 1,
+		StartDateTime: "2019-01-01",
+	}
+	backfillDAG.IsOn = true
+	orch.AddDAG(&backfillDAG)
+	body := `{"Start": "2019-02-01", "End": "2019-02-03T12:00:00Z"}`
+	resp := post(fmt.Sprintf("dag/%s/backfill", backfillDAG.Config.Name), body)
+	errorCodeResponse(t, http.StatusOK, resp.StatusCode)
+	executionDates := make([]time.Time, 0)
+	err := json.Unmarshal(readRespBytes(resp), &executionDates)
+	if err != nil {
+		panic(err)
+	}
+	if len(executionDates) != 3 {
+		t.Errorf("Expected 3 backfill execution dates, found %d", len(executionDates))
+	}
+
+	resp = post("dag/fake_dag/backfill", body)
+	errorCodeResponse(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = post(fmt.Sprintf("dag/%s/backfill", backfillDAG.Config.Name), `{"Start": "yesterday"}`)
+	errorCodeResponse(t, http.StatusBadRequest, resp.StatusCode)
+
+	// A paused DAG is not backfilled
+	backfillDAG.SetOnOff(false)
+	resp = post(fmt.Sprintf("dag/%s/backfill", backfillDAG.Config.Name), body)
+	errorCodeResponse(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestParseRequestDate(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		panic(err)
+	}
+	cases := []struct {
+		date     string
+		expected time.Time
+	}{
+		{"2021-03-01", time.Date(2021, 3, 1, 0, 0, 0, 0, berlin)},
+		{"2021-03-01T12:00:00Z", time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)},
+	}
+	for _, testCase := range cases {
+		parsed, err := parseRequestDate(testCase.date, berlin)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !parsed.Equal(testCase.expected) {
+			t.Errorf("Expected %s to be %s, found %s", testCase.date, testCase.expected, parsed)
+		}
+	}
+}
+
+func TestTriggerDag(t *testing.T) {
+	triggerDAG := copyDAG(testDag)
+	triggerDAG.Config = &dagconfig.DAGConfig{
+		Name:          "test-trigger",
+		Namespace:     "default",
+		Schedule:      "0 0 0 * * *",
+		MaxActiveRuns: 1,
+		StartDateTime: "2019-01-01",
+	}
+	triggerDAG.ActiveRuns = activeruns.New()
+	triggerDAG.DAGRuns = nil
+	orch.AddDAG(&triggerDAG)
+	triggerPath := fmt.Sprintf("dag/%s/runs", triggerDAG.Config.Name)
+
+	body := `{"ExecutionDate": "2021-03-01", "conf": {"TABLE": "users", "LIMIT": 10}}`
+	resp := post(triggerPath, body)
+	errorCodeResponse(t, http.StatusCreated, resp.StatusCode)
+	dagRun := dagrun.DAGRun{}
+	err := json.Unmarshal(readRespBytes(resp), &dagRun)
+	if err != nil {
+		panic(err)
+	}
+	// The values of the conf are not served back
+	expectedConf := map[string]string{
+		"TABLE": dagconfig.RedactedValue,
+		"LIMIT": dagconfig.RedactedValue,
+	}
+	if dagRun.RunType != dagrun.RunManual || !cmp.Equal(dagRun.Conf, expectedConf) {
+		t.Errorf("Expected a manual run with conf %v, found %s", expectedConf, &dagRun)
+	}
+	expectedDate := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	if !dagRun.ExecutionDate.Time.Equal(expectedDate) {
+		t.Errorf("Expected execution date %s, found %s", expectedDate, dagRun.ExecutionDate)
+	}
+	storedConf := triggerDAG.DAGRuns[0].Conf
+	if storedConf["TABLE"] != "users" || storedConf["LIMIT"] != "10" {
+		t.Errorf("Expected the run to keep the conf it was given, found %v", storedConf)
+	}
+
+	resp = post(triggerPath, "")
+	errorCodeResponse(t, http.StatusConflict, resp.StatusCode)
+
+	resp = post(triggerPath, `{"conf": {"NOT-VALID": "1"}}`)
+	errorCodeResponse(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = post(triggerPath, `{"conf": {"GOFLOW_ATTEMPT": "7"}}`)
+	errorCodeResponse(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = post("dag/fake_dag/runs", "")
+	errorCodeResponse(t, http.StatusNotFound, resp.StatusCode)
+}
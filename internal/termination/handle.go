@@ -1,18 +1,18 @@
 package termination
 
 import (
-	"fmt"
+	"goflow/internal/logs"
 	"os"
 	"os/signal"
 )
 
 // Handle calls a function on termination of the program
 func Handle(termFunc func()) {
-	termChan := make(chan os.Signal)
+	termChan := make(chan os.Signal, 1)
 	signal.Notify(termChan, os.Interrupt)
 
 	sig := <-termChan
-	fmt.Printf("Got %s signal. Aborting and calling term func...\n", sig)
+	logs.Warningf("Got %s signal. Aborting and calling term func...", sig)
 
 	termFunc()
 }
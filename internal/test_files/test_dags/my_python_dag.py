@@ -0,0 +1,30 @@
+"""A DAG written for Airflow, which goflow loads without running it"""
+from datetime import datetime, timedelta
+
+from airflow import DAG
+from airflow.operators.bash import BashOperator
+from airflow.providers.cncf.kubernetes.operators.kubernetes_pod import KubernetesPodOperator
+
+default_args = {
+    "owner": "goflow",
+    "retries": 1,
+    "retry_delay": timedelta(seconds=10),
+}
+
+with DAG(
+    "test-python-dag",
+    default_args=default_args,
+    schedule_interval="*/5 * * * *",
+    start_date=datetime(2019, 1, 1),
+    max_active_runs=1,
+    catchup=False,
+) as dag:
+    extract = BashOperator(task_id="extract", bash_command="echo {{ ds }}")
+    load = KubernetesPodOperator(
+        task_id="load",
+        name="load",
+        image="busybox",
+        cmds=["echo"],
+        arguments=["load"],
+    )
+    extract >> load
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"goflow/pkg/dag"
+	"time"
+)
+
+func main() {
+	etl := dag.New("test-go-dag").
+		Schedule(dag.Every(time.Hour)).
+		StartDate("2019-01-01").
+		MaxActiveRuns(1)
+	extract := etl.Task("extract", "echo", "extract")
+	load := etl.Task("load", "echo", "load")
+	extract.Then(load)
+
+	report := dag.New("test-go-dag-2").TriggeredBy("test-go-dag").StartDate("2019-01-01")
+	report.Task("summarize", "echo", "summarize")
+
+	dag.Emit(etl, report)
+}
@@ -23,6 +23,11 @@ func GetTestFolder() string {
 	return filepath.Join(getRootPath(), "test_files")
 }
 
+// GetModuleFolder returns the folder of goflow's Go module
+func GetModuleFolder() string {
+	return filepath.Dir(getRootPath())
+}
+
 // GetConfigPath returns the file where the testing config is stored
 func GetConfigPath() string {
 	return filepath.Join(GetTestFolder(), "config.json")
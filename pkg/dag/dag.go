@@ -0,0 +1,226 @@
+// Package dag declares goflow DAGs in Go. A Go DAG file is a main package, named like
+// my_dag.go, whose main function builds its DAGs and passes them to Emit:
+//
+//	func main() {
+//		etl := dag.New("etl").Schedule(dag.Daily).StartDate("2021-01-01")
+//		extract := etl.Task("extract", "echo", "extract")
+//		load := etl.Task("load", "echo", "load")
+//		extract.Then(load)
+//		dag.Emit(etl)
+//	}
+package dag
+
+import (
+	"fmt"
+	"time"
+)
+
+// dagConfig is the description of a DAG that goflow reads, with the keys of a JSON DAG file
+type dagConfig struct {
+	Name           string            `json:"Name"`
+	Namespace      string            `json:"Namespace,omitempty"`
+	Schedule       string            `json:"Schedule,omitempty"`
+	Timezone       string            `json:"Timezone,omitempty"`
+	DockerImage    string            `json:"DockerImage,omitempty"`
+	Command        []string          `json:"Command,omitempty"`
+	Args           []string          `json:"Args,omitempty"`
+	Env            map[string]string `json:"Env,omitempty"`
+	Tasks          []taskConfig      `json:"Tasks,omitempty"`
+	Retries        int32             `json:"Retries,omitempty"`
+	RetryDelay     int64             `json:"RetryDelay,omitempty"`
+	MaxActiveRuns  int               `json:"MaxActiveRuns,omitempty"`
+	Catchup        *bool             `json:"Catchup,omitempty"`
+	TriggeredBy    []triggerConfig   `json:"TriggeredBy,omitempty"`
+	Produces       []string          `json:"Produces,omitempty"`
+	StartDateTime  string            `json:"StartDateTime,omitempty"`
+	EndDateTime    string            `json:"EndDateTime,omitempty"`
+	Labels         map[string]string `json:"Labels,omitempty"`
+	Annotations    map[string]string `json:"Annotations,omitempty"`
+	EnvFromSecrets []string          `json:"EnvFromSecrets,omitempty"`
+	WithLogs       bool              `json:"WithLogs,omitempty"`
+}
+
+// triggerConfig names an upstream DAG or a dataset that triggers runs of a DAG
+type triggerConfig struct {
+	DAG     string `json:"DAG,omitempty"`
+	Dataset string `json:"Dataset,omitempty"`
+}
+
+// DAG builds the description of a goflow DAG. Its methods return the DAG so that they can be
+// chained.
+type DAG struct {
+	config dagConfig
+	tasks  []*Task
+}
+
+// New returns a DAG with the given name, which runs on goflow's defaults until they are set
+func New(name string) *DAG {
+	return &DAG{config: dagConfig{Name: name}}
+}
+
+// Name returns the name of the DAG
+func (dag *DAG) Name() string {
+	return dag.config.Name
+}
+
+// Namespace sets the kubernetes namespace that the DAG's tasks run in
+func (dag *DAG) Namespace(namespace string) *DAG {
+	dag.config.Namespace = namespace
+	return dag
+}
+
+// Schedule sets when the DAG runs, as a cron expression with a leading seconds field, a preset
+// such as Daily, or an interval made by Every
+func (dag *DAG) Schedule(schedule string) *DAG {
+	dag.config.Schedule = schedule
+	return dag
+}
+
+// Timezone sets the IANA time zone that the DAG's schedule and dates are read in
+func (dag *DAG) Timezone(timezone string) *DAG {
+	dag.config.Timezone = timezone
+	return dag
+}
+
+// StartDate sets the date, or date and time, of the DAG's first scheduled run
+func (dag *DAG) StartDate(startDate string) *DAG {
+	dag.config.StartDateTime = startDate
+	return dag
+}
+
+// EndDate sets the date, or date and time, after which the DAG is no longer scheduled
+func (dag *DAG) EndDate(endDate string) *DAG {
+	dag.config.EndDateTime = endDate
+	return dag
+}
+
+// Image sets the docker image of the DAG's tasks that do not set their own
+func (dag *DAG) Image(image string) *DAG {
+	dag.config.DockerImage = image
+	return dag
+}
+
+// Command sets the command of a DAG that runs as a single task, rather than declaring Tasks
+func (dag *DAG) Command(command ...string) *DAG {
+	dag.config.Command = command
+	return dag
+}
+
+// Args sets the arguments of a DAG's command
+func (dag *DAG) Args(args ...string) *DAG {
+	dag.config.Args = args
+	return dag
+}
+
+// Env sets an environment variable of every task in the DAG
+func (dag *DAG) Env(name string, value string) *DAG {
+	if dag.config.Env == nil {
+		dag.config.Env = make(map[string]string)
+	}
+	dag.config.Env[name] = value
+	return dag
+}
+
+// EnvFromSecrets passes every key of the given kubernetes Secrets to the DAG's tasks as
+// environment variables
+func (dag *DAG) EnvFromSecrets(secrets ...string) *DAG {
+	dag.config.EnvFromSecrets = append(dag.config.EnvFromSecrets, secrets...)
+	return dag
+}
+
+// Retries sets how many times a failed task is retried, and how long to wait before each retry
+func (dag *DAG) Retries(retries int32, delay time.Duration) *DAG {
+	dag.config.Retries = retries
+	dag.config.RetryDelay = int64(delay / time.Second)
+	return dag
+}
+
+// MaxActiveRuns sets how many runs of the DAG may be in progress at once
+func (dag *DAG) MaxActiveRuns(maxActiveRuns int) *DAG {
+	dag.config.MaxActiveRuns = maxActiveRuns
+	return dag
+}
+
+// Catchup sets whether the runs that were missed since the DAG's start date are run
+func (dag *DAG) Catchup(catchup bool) *DAG {
+	dag.config.Catchup = &catchup
+	return dag
+}
+
+// TriggeredBy runs the DAG after each successful run of any of the given upstream DAGs, instead
+// of on its schedule
+func (dag *DAG) TriggeredBy(upstreamDAGs ...string) *DAG {
+	for _, upstream := range upstreamDAGs {
+		dag.config.TriggeredBy = append(dag.config.TriggeredBy, triggerConfig{DAG: upstream})
+	}
+	return dag
+}
+
+// TriggeredByDataset runs the DAG after each successful run that produces any of the given
+// datasets, instead of on its schedule
+func (dag *DAG) TriggeredByDataset(datasets ...string) *DAG {
+	for _, dataset := range datasets {
+		dag.config.TriggeredBy = append(dag.config.TriggeredBy, triggerConfig{Dataset: dataset})
+	}
+	return dag
+}
+
+// Produces sets the datasets that each successful run of the DAG updates
+func (dag *DAG) Produces(datasets ...string) *DAG {
+	dag.config.Produces = append(dag.config.Produces, datasets...)
+	return dag
+}
+
+// Label sets a label of the pods of the DAG's tasks
+func (dag *DAG) Label(name string, value string) *DAG {
+	if dag.config.Labels == nil {
+		dag.config.Labels = make(map[string]string)
+	}
+	dag.config.Labels[name] = value
+	return dag
+}
+
+// Annotation sets an annotation of the pods of the DAG's tasks
+func (dag *DAG) Annotation(name string, value string) *DAG {
+	if dag.config.Annotations == nil {
+		dag.config.Annotations = make(map[string]string)
+	}
+	dag.config.Annotations[name] = value
+	return dag
+}
+
+// WithLogs sets whether the logs of the DAG's tasks are kept
+func (dag *DAG) WithLogs(withLogs bool) *DAG {
+	dag.config.WithLogs = withLogs
+	return dag
+}
+
+// Task adds a task to the DAG that runs the given command in a pod
+func (dag *DAG) Task(name string, command ...string) *Task {
+	task := &Task{dag: dag, config: taskConfig{Name: name, Command: command}}
+	dag.tasks = append(dag.tasks, task)
+	return task
+}
+
+// description returns the description of the DAG that goflow reads, or an error if the DAG
+// cannot be described
+func (dag *DAG) description() (dagConfig, error) {
+	if dag.config.Name == "" {
+		return dagConfig{}, fmt.Errorf("a DAG must have a name")
+	}
+	config := dag.config
+	config.Tasks = make([]taskConfig, 0, len(dag.tasks))
+	names := make(map[string]bool, len(dag.tasks))
+	for _, task := range dag.tasks {
+		if names[task.config.Name] {
+			return dagConfig{}, fmt.Errorf(
+				"DAG %s has more than one task named %s",
+				dag.config.Name,
+				task.config.Name,
+			)
+		}
+		names[task.config.Name] = true
+		config.Tasks = append(config.Tasks, task.config)
+	}
+	return config, nil
+}
@@ -0,0 +1,73 @@
+package dag
+
+import (
+	"encoding/json"
+	dagconfig "goflow/internal/dag/config"
+	"testing"
+	"time"
+)
+
+func TestMarshal(t *testing.T) {
+	etl := New("etl").
+		Namespace("data").
+		Schedule(Daily).
+		Timezone("America/New_York").
+		StartDate("2021-01-01").
+		Image("busybox").
+		Env("STAGE", "prod").
+		Retries(2, time.Minute).
+		MaxActiveRuns(1).
+		Catchup(false).
+		Produces("warehouse")
+	extract := etl.Task("extract", "echo", "extract")
+	load := etl.Task("load", "echo", "load").Image("alpine").Env("STAGE", "load")
+	extract.Then(load)
+	report := New("report").TriggeredBy("etl").TriggeredByDataset("warehouse")
+	report.Task("summarize", "echo", "summarize")
+
+	jsonBytes, err := Marshal(etl, report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configs := make([]dagconfig.DAGConfig, 0)
+	err = json.Unmarshal(jsonBytes, &configs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configs) != 2 {
+		t.Fatalf("Expected 2 DAGs, found %d", len(configs))
+	}
+	etlConfig, reportConfig := configs[0], configs[1]
+	if etlConfig.Name != "etl" || etlConfig.Namespace != "data" || etlConfig.Schedule != "@daily" {
+		t.Errorf("Expected the etl DAG's settings to be described, found %s", etlConfig.JSON())
+	}
+	if etlConfig.Retries != 2 || etlConfig.RetryDelay != 60 {
+		t.Errorf("Expected 2 retries after 60s, found %s", etlConfig.JSON())
+	}
+	if etlConfig.Catchup == nil || *etlConfig.Catchup {
+		t.Errorf("Expected catchup to be off, found %s", etlConfig.JSON())
+	}
+	if len(etlConfig.Tasks) != 2 || etlConfig.Tasks[1].DependsOn[0] != "extract" {
+		t.Errorf("Expected load to depend on extract, found %s", etlConfig.JSON())
+	}
+	if etlConfig.Tasks[1].DockerImage != "alpine" || etlConfig.Tasks[1].Env["STAGE"] != "load" {
+		t.Errorf("Expected load to set its own image and env, found %s", etlConfig.JSON())
+	}
+	triggered := reportConfig.TriggersOn("etl", nil) &&
+		reportConfig.TriggersOn("other", []string{"warehouse"})
+	if !triggered {
+		t.Errorf("Expected report to be triggered by etl and warehouse, %s", reportConfig.JSON())
+	}
+}
+
+func TestMarshalInvalidDAG(t *testing.T) {
+	duplicated := New("duplicated")
+	duplicated.Task("first", "echo")
+	duplicated.Task("first", "echo")
+	cases := map[string]*DAG{"without a name": New(""), "with duplicate tasks": duplicated}
+	for name, dag := range cases {
+		if _, err := Marshal(dag); err == nil {
+			t.Errorf("Expected an error for a DAG %s", name)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package dag
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Marshal returns the JSON description of the DAGs that goflow reads from a Go DAG file
+func Marshal(dags ...*DAG) ([]byte, error) {
+	configs := make([]dagConfig, 0, len(dags))
+	for _, dag := range dags {
+		config, err := dag.description()
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, config)
+	}
+	return json.Marshal(configs)
+}
+
+// write writes the JSON description of the DAGs to the writer
+func write(writer io.Writer, dags ...*DAG) error {
+	jsonBytes, err := Marshal(dags...)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(jsonBytes)
+	return err
+}
+
+// Emit writes the description of the DAGs to standard output, where goflow reads it when it runs
+// the DAG file. It must be called once, by the file's main function, and anything else that the
+// file prints should be written to standard error. If the DAGs cannot be described, the error is
+// printed and the program exits with status 1.
+func Emit(dags ...*DAG) {
+	err := write(os.Stdout, dags...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
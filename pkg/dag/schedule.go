@@ -0,0 +1,30 @@
+package dag
+
+import (
+	"fmt"
+	"time"
+)
+
+// Presets of DAG schedules, which run at the start of each hour, day, week (on Sunday), month or
+// year in the DAG's time zone
+const (
+	Hourly  = "@hourly"
+	Daily   = "@daily"
+	Weekly  = "@weekly"
+	Monthly = "@monthly"
+	Yearly  = "@yearly"
+	// Once runs the DAG a single time, at its start date
+	Once = "@once"
+)
+
+// Every returns a schedule that runs the DAG at its start date and after every interval from then
+// on
+func Every(interval time.Duration) string {
+	return "every " + interval.String()
+}
+
+// DailyAt returns a schedule that runs the DAG every day at the given hour and minute of the
+// DAG's time zone
+func DailyAt(hour int, minute int) string {
+	return fmt.Sprintf("0 %d %d * * *", minute, hour)
+}
@@ -0,0 +1,18 @@
+package dag
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleHelpers(t *testing.T) {
+	cases := map[string]string{
+		Every(90 * time.Minute): "every 1h30m0s",
+		DailyAt(2, 30):          "0 30 2 * * *",
+	}
+	for found, expected := range cases {
+		if found != expected {
+			t.Errorf("Expected schedule %s, found %s", expected, found)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package dag
+
+import (
+	"fmt"
+	"time"
+)
+
+// Kinds of sensors, which wait on a condition instead of running a pod
+const (
+	fileSensor = "file"
+	httpSensor = "http"
+	sqlSensor  = "sql"
+	dagSensor  = "dag"
+)
+
+// taskConfig is the description of a task that goflow reads, with the keys of a JSON DAG file
+type taskConfig struct {
+	Name        string            `json:"Name"`
+	DockerImage string            `json:"DockerImage,omitempty"`
+	Command     []string          `json:"Command,omitempty"`
+	Args        []string          `json:"Args,omitempty"`
+	Env         map[string]string `json:"Env,omitempty"`
+	DependsOn   []string          `json:"DependsOn,omitempty"`
+	Sensor      *sensorConfig     `json:"Sensor,omitempty"`
+	Produces    []string          `json:"Produces,omitempty"`
+}
+
+// sensorConfig is the description of a sensor that goflow reads
+type sensorConfig struct {
+	Type         string `json:"Type"`
+	Path         string `json:"Path,omitempty"`
+	URL          string `json:"URL,omitempty"`
+	Driver       string `json:"Driver,omitempty"`
+	DSN          string `json:"DSN,omitempty"`
+	Query        string `json:"Query,omitempty"`
+	DAG          string `json:"DAG,omitempty"`
+	PokeInterval int64  `json:"PokeInterval,omitempty"`
+	Timeout      int64  `json:"Timeout,omitempty"`
+	SoftFail     bool   `json:"SoftFail,omitempty"`
+}
+
+// Task builds the description of one task of a DAG. Its methods return the task so that they can
+// be chained.
+type Task struct {
+	dag    *DAG
+	config taskConfig
+}
+
+// Name returns the name of the task
+func (task *Task) Name() string {
+	return task.config.Name
+}
+
+// Image sets the docker image that the task runs in, instead of the DAG's image
+func (task *Task) Image(image string) *Task {
+	task.config.DockerImage = image
+	return task
+}
+
+// Args sets the arguments of the task's command
+func (task *Task) Args(args ...string) *Task {
+	task.config.Args = args
+	return task
+}
+
+// Env sets an environment variable of the task, overriding the DAG's variable of the same name
+func (task *Task) Env(name string, value string) *Task {
+	if task.config.Env == nil {
+		task.config.Env = make(map[string]string)
+	}
+	task.config.Env[name] = value
+	return task
+}
+
+// Produces sets the datasets that the task updates when it succeeds
+func (task *Task) Produces(datasets ...string) *Task {
+	task.config.Produces = append(task.config.Produces, datasets...)
+	return task
+}
+
+// After makes the task wait for each of the upstream tasks to succeed before it runs, like
+// task << upstream in Airflow. It returns the task.
+func (task *Task) After(upstream ...*Task) *Task {
+	for _, upstreamTask := range upstream {
+		task.dependOn(upstreamTask)
+	}
+	return task
+}
+
+// Then makes the downstream task wait for the task to succeed before it runs, like
+// task >> downstream in Airflow. It returns the downstream task, so that
+// extract.Then(transform).Then(load) runs the three tasks in order.
+func (task *Task) Then(downstream *Task) *Task {
+	downstream.dependOn(task)
+	return downstream
+}
+
+// dependOn adds the upstream task to the tasks that the task depends on. A task of another DAG
+// cannot be depended on, so it panics, as with any other mistake in a DAG's code.
+func (task *Task) dependOn(upstream *Task) {
+	if upstream.dag != task.dag {
+		panic(fmt.Sprintf(
+			"task %s of DAG %s cannot depend on task %s of DAG %s",
+			task.config.Name,
+			task.dag.config.Name,
+			upstream.config.Name,
+			upstream.dag.config.Name,
+		))
+	}
+	for _, name := range task.config.DependsOn {
+		if name == upstream.config.Name {
+			return
+		}
+	}
+	task.config.DependsOn = append(task.config.DependsOn, upstream.config.Name)
+}
+
+// Chain makes each task wait for the task before it to succeed, like a >> b >> c in Airflow
+func Chain(tasks ...*Task) {
+	for i := 1; i < len(tasks); i++ {
+		tasks[i-1].Then(tasks[i])
+	}
+}
+
+// sensor adds a task to the DAG that waits on the given condition instead of running a pod
+func (dag *DAG) sensor(name string, sensor sensorConfig) *Task {
+	task := &Task{dag: dag, config: taskConfig{Name: name, Sensor: &sensor}}
+	dag.tasks = append(dag.tasks, task)
+	return task
+}
+
+// FileSensor adds a task that waits until a file matching the path, or glob pattern, exists
+func (dag *DAG) FileSensor(name string, path string) *Task {
+	return dag.sensor(name, sensorConfig{Type: fileSensor, Path: path})
+}
+
+// HTTPSensor adds a task that waits until a GET request to the URL returns 200 OK
+func (dag *DAG) HTTPSensor(name string, url string) *Task {
+	return dag.sensor(name, sensorConfig{Type: httpSensor, URL: url})
+}
+
+// SQLSensor adds a task that waits until the query returns at least one row, using the
+// database/sql driver and DSN
+func (dag *DAG) SQLSensor(name string, driver string, dsn string, query string) *Task {
+	return dag.sensor(name, sensorConfig{Type: sqlSensor, Driver: driver, DSN: dsn, Query: query})
+}
+
+// DAGSensor adds a task that waits until the run of the upstream DAG for the same execution date
+// has succeeded
+func (dag *DAG) DAGSensor(name string, upstreamDAG string) *Task {
+	return dag.sensor(name, sensorConfig{Type: dagSensor, DAG: upstreamDAG})
+}
+
+// Poke sets how often a sensor checks its condition, and how long it waits before giving up. A
+// sensor that sets softFail is skipped rather than failed when it gives up.
+func (task *Task) Poke(interval time.Duration, timeout time.Duration, softFail bool) *Task {
+	if task.config.Sensor == nil {
+		panic(fmt.Sprintf("task %s is not a sensor", task.config.Name))
+	}
+	task.config.Sensor.PokeInterval = int64(interval / time.Second)
+	task.config.Sensor.Timeout = int64(timeout / time.Second)
+	task.config.Sensor.SoftFail = softFail
+	return task
+}
@@ -0,0 +1,60 @@
+package dag
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTaskDependencies(t *testing.T) {
+	dag := New("test")
+	first := dag.Task("first", "echo")
+	second := dag.Task("second", "echo")
+	third := dag.Task("third", "echo")
+	fourth := dag.Task("fourth", "echo")
+	Chain(first, second, third)
+	fourth.After(second, third, third)
+	expected := map[*Task][]string{
+		first:  nil,
+		second: {"first"},
+		third:  {"second"},
+		fourth: {"second", "third"},
+	}
+	for task, dependsOn := range expected {
+		if !reflect.DeepEqual(task.config.DependsOn, dependsOn) {
+			t.Errorf(
+				"Expected %s to depend on %v, found %v",
+				task.Name(),
+				dependsOn,
+				task.config.DependsOn,
+			)
+		}
+	}
+	if last := first.Then(fourth); last != fourth {
+		t.Errorf("Expected Then to return the downstream task, found %s", last.Name())
+	}
+}
+
+func TestDependOnTaskOfOtherDAG(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected depending on a task of another DAG to panic")
+		}
+	}()
+	New("first").Task("task", "echo").Then(New("second").Task("task", "echo"))
+}
+
+func TestSensor(t *testing.T) {
+	dag := New("test")
+	wait := dag.FileSensor("wait", "/data/*.csv").Poke(30*time.Second, time.Hour, true)
+	expected := sensorConfig{
+		Type:         fileSensor,
+		Path:         "/data/*.csv",
+		PokeInterval: 30,
+		Timeout:      3600,
+		SoftFail:     true,
+	}
+	if *wait.config.Sensor != expected {
+		t.Errorf("Expected sensor %v, found %v", expected, *wait.config.Sensor)
+	}
+}
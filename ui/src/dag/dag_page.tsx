@@ -4,7 +4,7 @@ import { OnOffButton } from "../buttons/on_off_button";
 import { Switch, Route, useRouteMatch, useParams } from "react-router-dom";
 import { RouterNavLink } from "../routing/router_nav";
 import { fetchDAG } from "../backend/fetch_calls";
-import { DAG } from "../typing/dag_types";
+import { DAG, countRunsWithStatus } from "../typing/dag_types";
 import { useState } from "react";
 import { useComponentWillMount } from "../hooks/component_will_mount";
 import { DAGConfigBody } from "./dag_config";
@@ -101,8 +101,8 @@ function DagInfo() {
                 <div>
                   <p>Current Job Name: {currentActiveRun}</p>
                   <p>Schedule: {dag.config.Schedule}</p>
-                  <p>Successes: {0}</p>
-                  <p>Failures: {0}</p>
+                  <p>Successes: {countRunsWithStatus(dag, "success")}</p>
+                  <p>Failures: {countRunsWithStatus(dag, "failed")}</p>
                   <p>Max Memory Usage: {0}</p>
                   <p>Logs</p>
                 </div>
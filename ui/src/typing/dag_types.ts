@@ -3,6 +3,8 @@ export type DAGConfig = {
   Namespace: string;
   Schedule: string;
   Command: Array<string>;
+  Args: Array<string> | null;
+  Env: { [name: string]: string } | null;
   Retries: number;
   DockerImage: string;
 };
@@ -12,6 +14,11 @@ type DAGRun = {
   StartTime: string;
   EndTime: string;
   ExecutionDate: string;
+  Status: "running" | "success" | "failed";
+  ExitCode: number;
+  Reason: string;
+  RunType: "scheduled" | "manual" | "backfill";
+  Conf: { [name: string]: string } | null;
 };
 
 export type DAG = {
@@ -28,6 +35,13 @@ export type DAGConfigProps = {
   config: DAGConfig;
 };
 
+export function countRunsWithStatus(dag: DAG, status: DAGRun["Status"]) {
+  if (!dag.DAGRuns) {
+    return 0;
+  }
+  return dag.DAGRuns.filter((run) => run.Status === status).length;
+}
+
 type configKey = keyof DAGConfig;
 type configKeyArr = Array<configKey>;
 